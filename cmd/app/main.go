@@ -6,29 +6,48 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 
+	adminHandler "roulette/internal/admin/handler"
+	adminRepo "roulette/internal/admin/repo"
+	adminService "roulette/internal/admin/service"
 	"roulette/internal/config"
 	"roulette/internal/database"
 	depositHandler "roulette/internal/deposit/handler"
 	depositRepo "roulette/internal/deposit/repo"
 	depositService "roulette/internal/deposit/service"
+	eventsBus "roulette/internal/events/bus"
+	eventsService "roulette/internal/events/service"
 	gameHandler "roulette/internal/game/handler"
 	gameRepo "roulette/internal/game/repo"
 	gameService "roulette/internal/game/service"
 	giftHandler "roulette/internal/gift/handler"
 	giftRepo "roulette/internal/gift/repo"
 	giftService "roulette/internal/gift/service"
+	ledgerRepo "roulette/internal/ledger/repo"
+	ledgerService "roulette/internal/ledger/service"
+	limitHandler "roulette/internal/limit/handler"
+	limitRepo "roulette/internal/limit/repo"
+	limitService "roulette/internal/limit/service"
 	"roulette/internal/middleware"
+	pauseRepo "roulette/internal/pause/repo"
+	pauseService "roulette/internal/pause/service"
+	"roulette/internal/tg/bot"
 	tgService "roulette/internal/tg/service"
 	tonService "roulette/internal/ton/service"
 	userHandler "roulette/internal/user/handler"
 	userRepo "roulette/internal/user/repo"
 	userService "roulette/internal/user/service"
+	valuationRepo "roulette/internal/valuation/repo"
+	valuationService "roulette/internal/valuation/service"
+	walletHandler "roulette/internal/wallet/handler"
+	walletRepo "roulette/internal/wallet/repo"
+	walletService "roulette/internal/wallet/service"
 	withdrawHandler "roulette/internal/withdraw/handler"
 	withdrawRepo "roulette/internal/withdraw/repo"
 	withdrawService "roulette/internal/withdraw/service"
@@ -40,6 +59,11 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	runApplication()
 }
 
@@ -55,13 +79,33 @@ func runApplication() {
 		fx.Provide(
 			database.NewDatabase,
 
+			eventsBus.NewBus,
+			eventsService.NewService,
+
+			ledgerRepo.NewRepo,
+			ledgerService.NewService,
+
+			pauseRepo.NewRepo,
+			pauseService.NewService,
+
+			limitRepo.NewRepo,
+			limitService.NewService,
+			limitHandler.NewHandler,
+			valuationRepo.NewRepo,
+			valuationService.NewService,
+
 			tonService.NewService,
 			tgService.NewService,
+			bot.NewClient,
 
 			userRepo.NewRepo,
 			userService.NewService,
 			userHandler.NewHandler,
 
+			walletRepo.NewRepo,
+			walletService.NewService,
+			walletHandler.NewHandler,
+
 			giftRepo.NewRepo,
 			giftService.NewService,
 			giftHandler.NewHandler,
@@ -78,14 +122,22 @@ func runApplication() {
 			gameService.NewService,
 			gameHandler.NewHandler,
 
+			adminRepo.NewRepo,
+			adminService.NewService,
+			adminHandler.NewHandler,
+
 			newServer,
 		),
 		fx.Invoke(
 			userHandler.Router,
+			walletHandler.Router,
 			giftHandler.Router,
 			depositHandler.Router,
 			withdrawHandler.Router,
 			gameHandler.Router,
+			limitHandler.Router,
+			adminHandler.Router,
+			runEvents,
 			func(r *gin.Engine) {},
 		),
 	)
@@ -100,12 +152,12 @@ func newServer(lc fx.Lifecycle, cfg *config.Config) *gin.Engine {
 	r.Use(gin.Recovery())
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{cfg.Origin},
-		AllowMethods:     []string{"HEAD", "GET", "POST", "PUT"},
-		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		AllowMethods:     []string{"HEAD", "GET", "POST", "PUT", "DELETE"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", middleware.TestUserHeader},
 		AllowCredentials: true,
 	}))
-	r.Use(middleware.AuthMiddleware(cfg.TgConfig.BotToken, cfg.Mode))
-	r.Use(middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout))
+	r.Use(middleware.AuthMiddleware(cfg.TgConfig.BotToken, cfg.Mode, []string{"/game/stream"}, "/admin"))
+	r.Use(middleware.TimeoutMiddleware(cfg.ServerConfig.WriteTimeout, "/game/stream"))
 
 	r.HEAD("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -134,3 +186,24 @@ func newServer(lc fx.Lifecycle, cfg *config.Config) *gin.Engine {
 
 	return r
 }
+
+// runEvents is invoked after the server is created, so it is stopped before the server
+// and the closed subscriptions end /game/stream responses the shutdown waits for
+func runEvents(lc fx.Lifecycle, service eventsService.Service) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				if err := service.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					log.Printf("events: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"roulette/internal/config"
+	"roulette/internal/database"
+	"roulette/internal/database/migrations"
+)
+
+const migrateUsage = "usage: app migrate up|down|status"
+
+func runMigrate(args []string) {
+	if len(args) != 1 {
+		log.Fatal(migrateUsage)
+	}
+
+	cfg, err := config.Load(configPath, envPath)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		log.Fatalf("failed to init db: %v", err)
+	}
+
+	all, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(ctx, db, all)
+		for _, m := range applied {
+			log.Printf("applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("failed to migrate up: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Println("no migrations to apply")
+		}
+	case "down":
+		reverted, err := database.MigrateDown(ctx, db, all)
+		if err != nil {
+			log.Fatalf("failed to migrate down: %v", err)
+		}
+		if reverted == nil {
+			log.Println("no migrations to revert")
+			return
+		}
+		log.Printf("reverted %04d_%s", reverted.Version, reverted.Name)
+	case "status":
+		statuses, err := database.MigrationsStatus(ctx, db, all)
+		if err != nil {
+			log.Fatalf("failed to get migrations status: %v", err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%-30s %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"roulette/internal/config"
+	"roulette/internal/database"
+	depositRepo "roulette/internal/deposit/repo"
+	depositService "roulette/internal/deposit/service"
+	giftRepo "roulette/internal/gift/repo"
+	giftService "roulette/internal/gift/service"
+	ledgerRepo "roulette/internal/ledger/repo"
+	ledgerService "roulette/internal/ledger/service"
+	"roulette/internal/tg/bot"
+	tonService "roulette/internal/ton/service"
+	userRepo "roulette/internal/user/repo"
+	userService "roulette/internal/user/service"
+	walletRepo "roulette/internal/wallet/repo"
+	walletService "roulette/internal/wallet/service"
+)
+
+const (
+	configPath = "config/prod.yaml"
+	envPath    = ".env"
+
+	pollTimeout = 30 * time.Second
+)
+
+// retryDelay is waited before updates are read again after a failure
+var retryDelay = 5 * time.Second
+
+type app struct {
+	cfg     *config.Config
+	client  bot.Client
+	service depositService.Service
+}
+
+func main() {
+	runBot()
+}
+
+func runBot() {
+	cfg, err := config.Load(configPath, envPath)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		log.Fatalf("failed to init db: %v", err)
+	}
+
+	client := bot.NewClient(cfg)
+
+	serviceTon, err := tonService.NewService(cfg)
+	if err != nil {
+		log.Fatalf("failed to init ton service: %v", err)
+	}
+
+	repoUser := userRepo.NewRepo(db)
+	serviceUser := userService.NewService(repoUser)
+
+	repoGift := giftRepo.NewRepo(db)
+	serviceGift := giftService.NewService(repoGift)
+
+	repoLedger := ledgerRepo.NewRepo(db)
+	serviceLedger := ledgerService.NewService(repoLedger)
+
+	serviceWallet := walletService.NewService(walletRepo.NewRepo(db), serviceTon, cfg)
+
+	repo := depositRepo.NewRepo(db)
+	service := depositService.NewService(repo, serviceTon, serviceUser, serviceGift, serviceLedger, serviceWallet, client, cfg)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	a := &app{cfg: cfg, client: client, service: service}
+
+	log.Printf("started...")
+
+	a.poll(ctx)
+
+	log.Printf("completed...")
+}
+
+// poll acks an update by the offset only once it is processed, so a payment that failed
+// to be credited is read again from telegram until it is
+func (a *app) poll(ctx context.Context) {
+	offset := 0
+	for ctx.Err() == nil {
+		updates, err := a.client.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			log.Printf("failed to get updates: %v", err)
+			sleep(ctx, retryDelay)
+			continue
+		}
+
+		for _, update := range updates {
+			if err = a.processUpdate(ctx, update); err != nil {
+				log.Printf("failed to process update %d: %v", update.UpdateID, err)
+				break
+			}
+			offset = update.UpdateID + 1
+		}
+		if err != nil {
+			sleep(ctx, retryDelay)
+		}
+	}
+}
+
+// processUpdate returns an error only for updates that must be read again
+func (a *app) processUpdate(ctx context.Context, update *bot.Update) error {
+	switch {
+	case update.PreCheckoutQuery != nil:
+		a.processPreCheckout(ctx, update.PreCheckoutQuery)
+	case update.Message != nil && update.Message.SuccessfulPayment != nil:
+		return a.processPayment(ctx, update.Message)
+	case update.Message != nil && strings.HasPrefix(update.Message.Text, "/refund"):
+		a.processRefund(ctx, update.Message)
+	}
+	return nil
+}
+
+func (a *app) processPreCheckout(ctx context.Context, query *bot.PreCheckoutQuery) {
+	err := a.service.CheckStar(ctx, uint(query.From.ID), query.InvoicePayload, query.TotalAmount)
+	if err == nil && query.Currency != bot.StarsCurrency {
+		err = depositService.ErrInvalidStarPayment
+	}
+	if err != nil {
+		log.Printf("rejected pre checkout query %s: %v", query.ID, err)
+	}
+
+	errorMessage := ""
+	if err != nil {
+		errorMessage = "Invoice is no longer valid, please create a new one"
+	}
+	if errAnswer := a.client.AnswerPreCheckoutQuery(ctx, query.ID, err == nil, errorMessage); errAnswer != nil {
+		log.Printf("failed to answer pre checkout query %s: %v", query.ID, errAnswer)
+	}
+}
+
+// processPayment fails the update unless the payment is credited or can never be,
+// a payment that doesn't match its deposit is left for a refund by admin
+func (a *app) processPayment(ctx context.Context, m *bot.Message) error {
+	payment := m.SuccessfulPayment
+	if m.From == nil {
+		return nil
+	}
+
+	err := a.service.ConfirmStar(ctx, uint(m.From.ID), payment.InvoicePayload, payment.TelegramPaymentChargeID, payment.TotalAmount)
+	switch {
+	case err == nil:
+		log.Printf("star payment %s confirmed for user %d", payment.TelegramPaymentChargeID, m.From.ID)
+		return nil
+	case depositService.IsStarDepositNotFound(err), depositService.IsStarDepositPaid(err), depositService.IsInvalidStarPayment(err):
+		log.Printf("failed to confirm star payment %s: %v", payment.TelegramPaymentChargeID, err)
+		return nil
+	default:
+		return fmt.Errorf("failed to confirm star payment %s: %w", payment.TelegramPaymentChargeID, err)
+	}
+}
+
+func (a *app) processRefund(ctx context.Context, m *bot.Message) {
+	if m.From == nil || !slices.Contains(a.cfg.TgConfig.AdminIDs, m.From.ID) {
+		return
+	}
+
+	fields := strings.Fields(m.Text)
+	if len(fields) != 2 {
+		a.reply(ctx, m, "usage: /refund <payment_id>")
+		return
+	}
+
+	paymentID := fields[1]
+	if err := a.service.RefundStar(ctx, paymentID); err != nil {
+		log.Printf("failed to refund star payment %s: %v", paymentID, err)
+		a.reply(ctx, m, fmt.Sprintf("refund failed: %v", err))
+		return
+	}
+
+	log.Printf("star payment %s refunded by %d", paymentID, m.From.ID)
+	a.reply(ctx, m, "refunded")
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+func (a *app) reply(ctx context.Context, m *bot.Message, text string) {
+	if err := a.client.SendMessage(ctx, m.Chat.ID, text); err != nil {
+		log.Printf("failed to send message: %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"roulette/internal/config"
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	depositRepo "roulette/internal/deposit/repo"
+	depositService "roulette/internal/deposit/service"
+	ledgerModel "roulette/internal/ledger/model"
+	ledgerService "roulette/internal/ledger/service"
+	"roulette/internal/tg/bot"
+)
+
+const (
+	testToken   = "test-token"
+	testUserID  = 1001
+	testAdminID = 1
+	testAmount  = 100
+	testRate    = 1000
+	testCharge  = "charge-1"
+)
+
+// fakeBotApi serves queued updates by the offset like telegram and records bot calls
+type fakeBotApi struct {
+	mu       sync.Mutex
+	updates  []*bot.Update
+	offsets  []int
+	invoices []map[string]interface{}
+	answers  []map[string]interface{}
+	refunds  []map[string]interface{}
+	messages []map[string]interface{}
+	// failRefunds rejects as many next refunds as telegram would
+	failRefunds int
+}
+
+// push queues the update and returns its id
+func (f *fakeBotApi) push(update *bot.Update) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	update.UpdateID = len(f.updates) + 1
+	f.updates = append(f.updates, update)
+	return update.UpdateID
+}
+
+func (f *fakeBotApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+testToken+"/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var params map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	var result interface{} = true
+	switch method {
+	case "getUpdates":
+		offset := int(params["offset"].(float64))
+		f.offsets = append(f.offsets, offset)
+		updates := []*bot.Update{}
+		for _, update := range f.updates {
+			if update.UpdateID >= offset {
+				updates = append(updates, update)
+			}
+		}
+		result = updates
+	case "createInvoiceLink":
+		f.invoices = append(f.invoices, params)
+		result = "https://t.me/$invoice"
+	case "answerPreCheckoutQuery":
+		f.answers = append(f.answers, params)
+	case "refundStarPayment":
+		f.refunds = append(f.refunds, params)
+		if f.failRefunds > 0 {
+			f.failRefunds--
+			f.mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": 400, "description": "Bad Request: refund rejected"})
+			return
+		}
+	case "sendMessage":
+		f.messages = append(f.messages, params)
+	}
+	f.mu.Unlock()
+
+	// long polling is not needed, an empty answer is slowed down instead
+	if updates, ok := result.([]*bot.Update); ok && len(updates) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// wait polls the state of the fake until cond holds
+func (f *fakeBotApi) wait(t *testing.T, what string, cond func(f *fakeBotApi) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		ok := cond(f)
+		f.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// fakeDepositRepo keeps star deposits in memory, the other methods are not used by the flow
+type fakeDepositRepo struct {
+	depositRepo.Repo
+
+	mu       sync.Mutex
+	deposits []*dbModels.StarDepositDB
+	// failUpdates fails as many next updates as a lost db connection would
+	failUpdates int
+}
+
+func (r *fakeDepositRepo) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return f(ctx)
+}
+
+func (r *fakeDepositRepo) AddStar(_ context.Context, deposit *dbModels.StarDepositDB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deposit.ID = uint(len(r.deposits) + 1)
+	stored := *deposit
+	r.deposits = append(r.deposits, &stored)
+	return nil
+}
+
+func (r *fakeDepositRepo) GetStarByPayload(_ context.Context, payload string) (*dbModels.StarDepositDB, error) {
+	return r.find(func(d *dbModels.StarDepositDB) bool { return *d.Payload == payload })
+}
+
+func (r *fakeDepositRepo) GetStarByPaymentID(_ context.Context, paymentID string) (*dbModels.StarDepositDB, error) {
+	return r.find(func(d *dbModels.StarDepositDB) bool { return d.PaymentID != nil && *d.PaymentID == paymentID })
+}
+
+func (r *fakeDepositRepo) UpdateStar(_ context.Context, depositID uint, deposit *dbModels.StarDepositDB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failUpdates > 0 {
+		r.failUpdates--
+		return errors.New("connection reset")
+	}
+
+	stored := r.deposits[depositID-1]
+	if deposit.PaymentID != nil {
+		stored.PaymentID, stored.PaidAt = deposit.PaymentID, deposit.PaidAt
+	}
+	if deposit.RefundedAt != nil {
+		stored.RefundedAt = deposit.RefundedAt
+	}
+	return nil
+}
+
+func (r *fakeDepositRepo) UpdateStarRefunding(_ context.Context, depositID uint, refundingAt *time.Time, attempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.deposits[depositID-1]
+	stored.RefundingAt, stored.RefundAttempts = refundingAt, attempts
+	return nil
+}
+
+func (r *fakeDepositRepo) find(match func(d *dbModels.StarDepositDB) bool) (*dbModels.StarDepositDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, deposit := range r.deposits {
+		if match(deposit) {
+			found := *deposit
+			return &found, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+// fakeLedger keeps postings by key like the unique key of ledger entries
+type fakeLedger struct {
+	ledgerService.Service
+
+	mu       sync.Mutex
+	postings map[string]*ledgerModel.Posting
+}
+
+func (l *fakeLedger) Post(_ context.Context, posting *ledgerModel.Posting) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.postings[posting.Key]; ok {
+		return ledgerService.ErrAlreadyPosted
+	}
+	l.postings[posting.Key] = posting
+	return nil
+}
+
+func (l *fakeLedger) GetPostedAmount(_ context.Context, key string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	posting, ok := l.postings[key]
+	if !ok {
+		return 0, ledgerService.ErrPostingNotFound
+	}
+	return posting.Amount, nil
+}
+
+func (l *fakeLedger) balance(userID uint) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var balance int64
+	for _, posting := range l.postings {
+		switch ledgerModel.UserAccount(userID) {
+		case posting.Credit:
+			balance += posting.Amount
+		case posting.Debit:
+			balance -= posting.Amount
+		}
+	}
+	return balance
+}
+
+func TestStarPaymentFlow(t *testing.T) {
+	retryDelay = 20 * time.Millisecond
+
+	api := &fakeBotApi{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	cfg := &config.Config{
+		TgConfig:   config.TgConfig{BotToken: testToken, BotApiUrl: server.URL, AdminIDs: []int64{testAdminID}},
+		StarConfig: config.StarConfig{Rate: testRate, MinAmount: 1, MaxAmount: 10000},
+	}
+	client := bot.NewClient(cfg)
+	repo := &fakeDepositRepo{}
+	ledger := &fakeLedger{postings: make(map[string]*ledgerModel.Posting)}
+	service := depositService.NewService(repo, nil, nil, nil, ledger, nil, client, cfg)
+	a := &app{cfg: cfg, client: client, service: service}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.poll(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// invoice
+	if _, err := service.AddStar(ctx, testUserID, testAmount); err != nil {
+		t.Fatalf("AddStar: %v", err)
+	}
+	if len(api.invoices) != 1 {
+		t.Fatalf("invoices = %d, want 1", len(api.invoices))
+	}
+	payload := api.invoices[0]["payload"].(string)
+
+	// pre checkout
+	api.push(&bot.Update{PreCheckoutQuery: &bot.PreCheckoutQuery{
+		ID:             "query-1",
+		From:           bot.User{ID: testUserID},
+		Currency:       bot.StarsCurrency,
+		TotalAmount:    testAmount,
+		InvoicePayload: payload,
+	}})
+	api.wait(t, "pre checkout answer", func(f *fakeBotApi) bool { return len(f.answers) == 1 })
+	if ok := api.answers[0]["ok"]; ok != true {
+		t.Fatalf("pre checkout answered ok = %v, want true", ok)
+	}
+
+	// successful payment, the first confirm fails and the update must be read again
+	repo.mu.Lock()
+	repo.failUpdates = 1
+	repo.mu.Unlock()
+	payment := &bot.Message{
+		From: &bot.User{ID: testUserID},
+		Chat: bot.Chat{ID: testUserID},
+		SuccessfulPayment: &bot.SuccessfulPayment{
+			Currency:                bot.StarsCurrency,
+			TotalAmount:             testAmount,
+			InvoicePayload:          payload,
+			TelegramPaymentChargeID: testCharge,
+		},
+	}
+	paymentUpdateID := api.push(&bot.Update{Message: payment})
+	api.wait(t, "payment ack", func(f *fakeBotApi) bool { return f.offsets[len(f.offsets)-1] > paymentUpdateID })
+
+	api.mu.Lock()
+	repeated := 0
+	for _, offset := range api.offsets {
+		if offset == paymentUpdateID {
+			repeated++
+		}
+	}
+	api.mu.Unlock()
+	if repeated < 2 {
+		t.Fatalf("payment update read %d times, want it read again after the failure", repeated)
+	}
+	if got, want := ledger.balance(testUserID), int64(testAmount*testRate); got != want {
+		t.Fatalf("balance after payment = %d, want %d", got, want)
+	}
+
+	// duplicate payment id
+	duplicateUpdateID := api.push(&bot.Update{Message: payment})
+	api.wait(t, "duplicate ack", func(f *fakeBotApi) bool { return f.offsets[len(f.offsets)-1] > duplicateUpdateID })
+	if got, want := ledger.balance(testUserID), int64(testAmount*testRate); got != want {
+		t.Fatalf("balance after duplicate payment = %d, want %d", got, want)
+	}
+
+	// refund by admin, telegram rejects the first one and the debit must be reversed
+	refund := &bot.Message{
+		From: &bot.User{ID: testAdminID},
+		Chat: bot.Chat{ID: testAdminID},
+		Text: "/refund " + testCharge,
+	}
+	api.mu.Lock()
+	api.failRefunds = 1
+	api.mu.Unlock()
+	api.push(&bot.Update{Message: refund})
+	api.wait(t, "rejected refund reply", func(f *fakeBotApi) bool { return len(f.messages) == 1 })
+	if text, _ := api.messages[0]["text"].(string); !strings.HasPrefix(text, "refund failed") {
+		t.Fatalf("rejected refund reply = %v, want refund failed", text)
+	}
+	if got, want := ledger.balance(testUserID), int64(testAmount*testRate); got != want {
+		t.Fatalf("balance after rejected refund = %d, want %d", got, want)
+	}
+
+	api.push(&bot.Update{Message: refund})
+	api.wait(t, "refund reply", func(f *fakeBotApi) bool { return len(f.messages) == 2 })
+	if len(api.refunds) != 2 || api.refunds[1]["telegram_payment_charge_id"] != testCharge {
+		t.Fatalf("refunds = %v, want two of %s", api.refunds, testCharge)
+	}
+	if text := api.messages[1]["text"]; text != "refunded" {
+		t.Fatalf("refund reply = %v, want refunded", text)
+	}
+	if got := ledger.balance(testUserID); got != 0 {
+		t.Fatalf("balance after refund = %d, want 0", got)
+	}
+	deposit, err := repo.GetStarByPaymentID(ctx, testCharge)
+	if err != nil {
+		t.Fatalf("GetStarByPaymentID: %v", err)
+	}
+	if deposit.RefundedAt == nil {
+		t.Fatalf("deposit of %s is not marked refunded", testCharge)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"roulette/internal/config"
+	"roulette/internal/daemon"
+	depositService "roulette/internal/deposit/service"
+)
+
+func runDaemon(conf *config.Config, service depositService.Service) {
+	cfg := conf.DepositConfig
+
+	checkers := []*daemon.Checker{
+		{
+			Name:       "ton",
+			Interval:   cfg.TonInterval,
+			Timeout:    cfg.Timeout,
+			MaxBackoff: cfg.MaxBackoff,
+			Check:      service.CheckTonDeposit,
+		},
+		{
+			Name:       "nft",
+			Interval:   cfg.NftInterval,
+			Timeout:    cfg.Timeout,
+			MaxBackoff: cfg.MaxBackoff,
+			Check:      service.CheckNftDeposit,
+		},
+	}
+
+	daemon.Run(conf.Mode, cfg.HealthAddr, checkers)
+}
@@ -3,18 +3,26 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"sync"
 	"time"
 
+	"gorm.io/gorm"
+
 	"roulette/internal/config"
 	"roulette/internal/database"
 	depositRepo "roulette/internal/deposit/repo"
 	depositService "roulette/internal/deposit/service"
 	giftRepo "roulette/internal/gift/repo"
 	giftService "roulette/internal/gift/service"
+	ledgerRepo "roulette/internal/ledger/repo"
+	ledgerService "roulette/internal/ledger/service"
+	"roulette/internal/tg/bot"
 	tonService "roulette/internal/ton/service"
 	userRepo "roulette/internal/user/repo"
 	userService "roulette/internal/user/service"
+	walletRepo "roulette/internal/wallet/repo"
+	walletService "roulette/internal/wallet/service"
 )
 
 const (
@@ -23,10 +31,6 @@ const (
 )
 
 func main() {
-	runDeposit()
-}
-
-func runDeposit() {
 	conf, err := config.Load(configPath, envPath)
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
@@ -37,7 +41,21 @@ func runDeposit() {
 		log.Fatalf("failed to init db: %v", err)
 	}
 
-	serviceTon := tonService.NewService(conf)
+	service := newService(conf, db)
+
+	if len(os.Args) > 1 && os.Args[1] == "once" {
+		runDeposit(service)
+		return
+	}
+
+	runDaemon(conf, service)
+}
+
+func newService(conf *config.Config, db *gorm.DB) depositService.Service {
+	serviceTon, err := tonService.NewService(conf)
+	if err != nil {
+		log.Fatalf("failed to init ton service: %v", err)
+	}
 
 	repoUser := userRepo.NewRepo(db)
 	serviceUser := userService.NewService(repoUser)
@@ -45,9 +63,17 @@ func runDeposit() {
 	repoGift := giftRepo.NewRepo(db)
 	serviceGift := giftService.NewService(repoGift)
 
+	repoLedger := ledgerRepo.NewRepo(db)
+	serviceLedger := ledgerService.NewService(repoLedger)
+
+	serviceWallet := walletService.NewService(walletRepo.NewRepo(db), serviceTon, conf)
+
 	repo := depositRepo.NewRepo(db)
-	service := depositService.NewService(repo, serviceTon, serviceUser, serviceGift)
+	return depositService.NewService(repo, serviceTon, serviceUser, serviceGift, serviceLedger, serviceWallet, bot.NewClient(conf), conf)
+}
 
+// runDeposit makes a single pass of both checks
+func runDeposit(service depositService.Service) {
 	errCh := make(chan error, 2)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
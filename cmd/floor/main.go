@@ -7,9 +7,12 @@ import (
 
 	"roulette/internal/config"
 	"roulette/internal/database"
-	giftRepo "roulette/internal/gift/repo"
-	giftService "roulette/internal/gift/service"
+	floorRepo "roulette/internal/floor/repo"
+	floorService "roulette/internal/floor/service"
+	"roulette/internal/floor/source"
 	tgService "roulette/internal/tg/service"
+	"roulette/internal/ton/provider"
+	tonService "roulette/internal/ton/service"
 )
 
 const (
@@ -26,7 +29,7 @@ func runFloor() {
 
 	cfg, err := config.Load(configPath, envPath)
 	if err != nil {
-		log.Fatalf("failed to load cfgig: %v", err)
+		log.Fatalf("failed to load config: %v", err)
 	}
 
 	db, err := database.NewDatabase(cfg)
@@ -34,22 +37,50 @@ func runFloor() {
 		log.Fatalf("failed to init db: %v", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	sources, err := newSources(cfg)
+	if err != nil {
+		log.Fatalf("failed to init sources: %v", err)
+	}
+	if len(sources) == 0 {
+		log.Fatalf("no floor sources configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.FloorConfig.Timeout)
 	defer cancel()
 
-	repoGift := giftRepo.NewRepo(db)
-	serviceGift := giftService.NewService(repoGift)
+	service := floorService.NewService(floorRepo.NewRepo(db), sources, cfg)
 
-	service := tgService.NewService(cfg)
+	// floors of the working sources are written even when another source fails
+	if err = service.Update(ctx); err != nil {
+		log.Fatalf("failed to update floors: %v", err)
+	}
 
-	floors, err := service.GetFloorsLow(ctx, 2422226195, -6696550584382502344)
-	if err != nil {
-		log.Fatalf("failed to get floors: %v", err)
+	log.Println("finished", time.Now().Format("2006-01-02 15:04:05"))
+}
+
+// newSources builds the configured sources in the order they are stored in the floor history
+func newSources(cfg *config.Config) ([]source.Source, error) {
+	floorConfig := cfg.FloorConfig
+
+	var sources []source.Source
+	if floorConfig.Telegram.ChannelID != 0 {
+		sources = append(sources, source.NewTelegram(tgService.NewService(cfg), floorConfig.Telegram))
 	}
 
-	if err = serviceGift.UpdateCollectionsFloor(ctx, floors); err != nil {
-		log.Fatalf("failed to update floors: %v", err)
+	if len(floorConfig.Markets) > 0 {
+		client := provider.NewClient(cfg.TonConfig.Retries, cfg.TonConfig.RateLimits)
+		for _, market := range floorConfig.Markets {
+			sources = append(sources, source.NewMarket(client, market))
+		}
 	}
 
-	log.Println("finished", time.Now().Format("2006-01-02 15:04:05"))
+	if floorConfig.Chain.Enabled {
+		service, err := tonService.NewService(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source.NewChain(service, floorConfig.Chain))
+	}
+
+	return sources, nil
 }
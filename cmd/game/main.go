@@ -2,21 +2,37 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"roulette/internal/config"
 	"roulette/internal/database"
+	eventsBus "roulette/internal/events/bus"
+	eventsModel "roulette/internal/events/model"
+	eventsService "roulette/internal/events/service"
 	gameRepo "roulette/internal/game/repo"
 	gameService "roulette/internal/game/service"
+	ledgerRepo "roulette/internal/ledger/repo"
+	ledgerService "roulette/internal/ledger/service"
+	limitRepo "roulette/internal/limit/repo"
+	limitService "roulette/internal/limit/service"
+	pauseRepo "roulette/internal/pause/repo"
+	pauseService "roulette/internal/pause/service"
+	valuationRepo "roulette/internal/valuation/repo"
+	valuationService "roulette/internal/valuation/service"
 )
 
 const (
-	configPath    = "config/prod.yaml"
-	envPath       = ".env"
-	RoundDuration = 3 * time.Minute
-	MinPlayers    = 2
+	configPath = "config/prod.yaml"
+	envPath    = ".env"
+
+	// leaderLockKey is the advisory lock of the running game, standby instances wait for it
+	leaderLockKey = 0x67616d65
+	// maxRetryDelay bounds the backoff of a failing room
+	maxRetryDelay = 30 * time.Second
 )
 
 func main() {
@@ -30,76 +46,128 @@ func main() {
 		log.Fatalf("failed to load db: %v", err)
 	}
 
-	repo := gameRepo.NewRepo(db)
-	service := gameService.NewService(repo)
-
-	for {
-		fmt.Println("checking...: ", time.Now())
-		nextCheckTime := checkRound(context.Background(), service)
+	bus, err := eventsBus.NewBus(cfg, db)
+	if err != nil {
+		log.Fatalf("failed to init events bus: %v", err)
+	}
 
-		delay := time.Until(nextCheckTime)
-		if delay < 0 {
-			delay = 0
+	repo := gameRepo.NewRepo(db)
+	serviceEvents := eventsService.NewService(bus)
+	serviceLedger := ledgerService.NewService(ledgerRepo.NewRepo(db))
+	servicePause := pauseService.NewService(pauseRepo.NewRepo(db))
+	serviceLimit := limitService.NewService(limitRepo.NewRepo(db), cfg)
+	serviceValuation := valuationService.NewService(valuationRepo.NewRepo(db), cfg)
+	service := gameService.NewService(repo, serviceEvents, serviceLedger, servicePause, serviceLimit, serviceValuation, cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := serviceEvents.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("events stopped: %v", err)
 		}
+	}()
 
-		select {
-		case <-time.After(delay):
-		}
+	log.Printf("started...")
+
+	err = database.RunAsLeader(ctx, db, leaderLockKey, cfg.GameConfig.LeaderRetry, func(ctx context.Context) {
+		runGame(ctx, service, serviceEvents, cfg.GameConfig.FeeCollectInterval)
+	})
+	if err != nil {
+		log.Fatalf("failed to run game: %v", err)
 	}
+
+	log.Printf("completed...")
 }
 
-func checkRound(ctx context.Context, service gameService.Service) time.Time {
-	roundWithPlayers, err := service.GetCurrentRoundWithPlayers(ctx)
-	if err != nil {
-		if gameService.IsRoundNotFound(err) {
-			errAdd := service.AddRound(ctx)
-			if errAdd != nil {
-				log.Fatalf("failed to add round: %v", err)
-			}
+// runGame advances every room and collects fees until ctx is done
+func runGame(ctx context.Context, service gameService.Service, serviceEvents eventsService.Service, feeInterval time.Duration) {
+	events, unsubscribe := serviceEvents.Subscribe()
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	rooms := service.GetRooms(ctx)
+	wakes := make([]chan struct{}, len(rooms))
+	for i, room := range rooms {
+		wakes[i] = make(chan struct{}, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runRoom(ctx, service, room.Name, wakes[i])
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		collectFees(ctx, service, feeInterval)
+	}()
 
-			roundWithPlayers, err = service.GetCurrentRoundWithPlayers(ctx)
-			if err != nil {
-				log.Fatalf("failed to get new round: %v", err)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
 			}
-		} else {
-			return time.Now().Add(5 * time.Second)
-		}
-	}
-	if roundWithPlayers.IsFinished {
-		_, err = service.GetWinner(ctx, roundWithPlayers.ID)
-		if err != nil {
-			if time.Now().Unix() >= roundWithPlayers.StartedAt.Add(RoundDuration).Unix() {
-				if err = service.AddWinner(ctx, roundWithPlayers); err != nil {
-					return time.Now().Add(1 * time.Second)
+			// a bet may bring an open round to its min players
+			if event.Type != eventsModel.BetPlaced {
+				continue
+			}
+			for _, wake := range wakes {
+				select {
+				case wake <- struct{}{}:
+				default:
 				}
 			}
 		}
+	}
+}
 
-		errAdd := service.AddRound(ctx)
-		if errAdd != nil {
-			log.Fatalf("failed to add round: %v", err)
+// runRoom moves the rounds of one room when their timers fire or a bet wakes the room
+func runRoom(ctx context.Context, service gameService.Service, room string, wake <-chan struct{}) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-wake:
 		}
 
-		roundWithPlayers, err = service.GetCurrentRoundWithPlayers(ctx)
+		delay, err := service.Advance(ctx, room)
 		if err != nil {
-			log.Fatalf("failed to get new round: %v", err)
+			failures++
+			delay = min(time.Second<<min(failures, 5), maxRetryDelay)
+			log.Printf("room %s failed %d times, retry in %s: %v", room, failures, delay, err)
+		} else {
+			failures = 0
 		}
+
+		timer.Reset(delay)
 	}
+}
 
-	fmt.Println(*roundWithPlayers.Round)
+// collectFees settles fees winners could not pay at payout
+func collectFees(ctx context.Context, service gameService.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	if len(roundWithPlayers.UniquePlayers) < MinPlayers {
-		return time.Now().Add(3 * time.Second)
-	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
-	if roundWithPlayers.StartedAt == nil {
-		if err = service.StartRound(ctx, roundWithPlayers.ID); err != nil {
-			return time.Now().Add(1 * time.Second)
+		if err := service.CollectFees(ctx); err != nil {
+			log.Printf("failed to collect fees: %v", err)
 		}
-		roundWithPlayers, _ = service.GetCurrentRoundWithPlayers(ctx)
-		return roundWithPlayers.StartedAt.Add(RoundDuration)
 	}
-
-	// maybe not necessary
-	return roundWithPlayers.StartedAt.Add(RoundDuration)
 }
@@ -15,9 +15,21 @@ import (
 
 	"roulette/internal/config"
 	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	depositRepo "roulette/internal/deposit/repo"
+	depositService "roulette/internal/deposit/service"
 	giftRepo "roulette/internal/gift/repo"
 	giftService "roulette/internal/gift/service"
+	ledgerRepo "roulette/internal/ledger/repo"
+	ledgerService "roulette/internal/ledger/service"
+	"roulette/internal/models"
+	"roulette/internal/tg/bot"
 	tgService "roulette/internal/tg/service"
+	tonService "roulette/internal/ton/service"
+	userRepo "roulette/internal/user/repo"
+	userService "roulette/internal/user/service"
+	walletRepo "roulette/internal/wallet/repo"
+	walletService "roulette/internal/wallet/service"
 )
 
 const (
@@ -31,12 +43,43 @@ func main() {
 	runGift()
 }
 
+type app struct {
+	giftService    giftService.Service
+	depositService depositService.Service
+}
+
 func runGift() {
 	cfg, err := config.Load(configPath, envPath)
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		log.Fatalf("failed to init db: %v", err)
+	}
+
+	serviceTon, err := tonService.NewService(cfg)
+	if err != nil {
+		log.Fatalf("failed to init ton service: %v", err)
+	}
+
+	repoUser := userRepo.NewRepo(db)
+	serviceUser := userService.NewService(repoUser)
+
+	repoGift := giftRepo.NewRepo(db)
+	serviceGift := giftService.NewService(repoGift)
+
+	repoLedger := ledgerRepo.NewRepo(db)
+	serviceLedger := ledgerService.NewService(repoLedger)
+
+	serviceWallet := walletService.NewService(walletRepo.NewRepo(db), serviceTon, cfg)
+
+	repoDeposit := depositRepo.NewRepo(db)
+	serviceDeposit := depositService.NewService(repoDeposit, serviceTon, serviceUser, serviceGift, serviceLedger, serviceWallet, bot.NewClient(cfg), cfg)
+
+	a := &app{giftService: serviceGift, depositService: serviceDeposit}
+
 	service := tgService.NewService(cfg)
 
 	client, err := service.GetClient(context.Background())
@@ -52,7 +95,7 @@ func runGift() {
 		default:
 			return false
 		}
-	}, processGift))
+	}, a.processGift))
 	dp.AddHandler(handlers.NewMessage(func(m *types.Message) bool {
 		channel, ok := m.PeerID.(*tg.PeerChannel)
 		if !ok {
@@ -74,7 +117,7 @@ func runGift() {
 	log.Printf("completed...")
 }
 
-func processGift(ctx *ext.Context, update *ext.Update) error {
+func (a *app) processGift(ctx *ext.Context, update *ext.Update) error {
 	m := update.EffectiveMessage
 
 	log.Printf("processing...")
@@ -83,89 +126,71 @@ func processGift(ctx *ext.Context, update *ext.Update) error {
 	gift := giftAction.GetGift()
 	if gift == nil {
 		errGift := errors.New("failed to get gift")
-		log.Printf(errGift.Error())
+		log.Print(errGift)
 		return errGift
 	}
 
 	uniqueStarGift, ok := gift.(*tg.StarGiftUnique)
 	if !ok {
 		errUniqueStarGift := errors.New("failed to get unique star gift")
-		log.Printf(errUniqueStarGift.Error())
+		log.Print(errUniqueStarGift)
 		return errUniqueStarGift
 	}
 
-	cfg, err := config.Load(configPath, envPath)
-	if err != nil {
-		log.Printf("failed to load config: %v", err)
-		return err
-	}
-	db, err := database.NewDatabase(cfg)
-	if err != nil {
-		log.Printf("failed to init db: %v", err)
-		return err
-	}
-	repo := giftRepo.NewRepo(db)
-	service := giftService.NewService(repo)
-
 	giftID := uniqueStarGift.GetID()
 	if giftID == 0 {
 		errGiftID := errors.New("failed to get gift id")
-		log.Printf(errGiftID.Error())
+		log.Print(errGiftID)
 		return errGiftID
 	}
 	msgID, ok := giftAction.GetSavedID()
 	if !ok {
-		errMsgID := errors.New("failed to get msg id")
-		log.Printf(errMsgID.Error(), msgID)
+		errMsgID := fmt.Errorf("failed to get msg id for gift %d", giftID)
+		log.Print(errMsgID)
 		return errMsgID
 	}
 	slug := uniqueStarGift.GetSlug()
 	if slug == "" {
-		errSlug := errors.New("failed to get gift slug")
-		log.Printf(errSlug.Error(), giftID)
+		errSlug := fmt.Errorf("failed to get gift slug for gift %d", giftID)
+		log.Print(errSlug)
 		return errSlug
 	}
 	title := uniqueStarGift.GetTitle()
 	if title == "" {
-		errTitle := errors.New("failed to get gift title")
-		log.Printf(errTitle.Error(), giftID)
+		errTitle := fmt.Errorf("failed to get gift title for gift %d", giftID)
+		log.Print(errTitle)
 		return errTitle
 	}
 	collectibleID := uniqueStarGift.GetNum()
 	if collectibleID == 0 {
-		errCollectibleID := errors.New("failed to get gift collectible id")
-		log.Printf(errCollectibleID.Error(), giftID)
+		errCollectibleID := fmt.Errorf("failed to get gift collectible id for gift %d", giftID)
+		log.Print(errCollectibleID)
 		return errCollectibleID
 	}
 
 	var document *tg.Document
-	var senderID int64
+	var attributes []*models.Attribute
 	for _, attr := range uniqueStarGift.Attributes {
-		switch v := attr.(type) {
+		switch attr := attr.(type) {
 		case *tg.StarGiftAttributeModel:
-			document, ok = v.Document.(*tg.Document)
+			document, ok = attr.Document.(*tg.Document)
 			if !ok {
 				errDocument := errors.New("failed to get document")
-				log.Printf(errDocument.Error())
-				return errDocument
-			}
-		case *tg.StarGiftAttributeOriginalDetails:
-			peer, ok := v.SenderID.(*tg.PeerUser)
-			if ok {
-				senderID = peer.UserID
-			}
-		}
-
-		model, ok := attr.(*tg.StarGiftAttributeModel)
-		if ok {
-			document, ok = model.Document.(*tg.Document)
-			if !ok {
-				errDocument := errors.New("failed to get document")
-				log.Printf(errDocument.Error())
+				log.Print(errDocument)
 				return errDocument
 			}
+			attributes = append(attributes, &models.Attribute{Trait: "model", Value: attr.Name, Rarity: attr.RarityPermille})
+		case *tg.StarGiftAttributeBackdrop:
+			attributes = append(attributes, &models.Attribute{Trait: "backdrop", Value: attr.Name, Rarity: attr.RarityPermille})
+		case *tg.StarGiftAttributePattern:
+			attributes = append(attributes, &models.Attribute{Trait: "pattern", Value: attr.Name, Rarity: attr.RarityPermille})
 		}
 	}
+	giftAttributes, err := models.MarshalAttributes(attributes)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
 
 	downloadOutputPath := filepath.Join(downloadPath, fmt.Sprintf("%s.tgs", strings.ToLower(slug)))
 	mediaDocument := &tg.MessageMediaDocument{Document: document}
@@ -176,24 +201,51 @@ func processGift(ctx *ext.Context, update *ext.Update) error {
 	)
 	if err != nil {
 		errDownload := fmt.Errorf("failed to download gift media: %v", err)
-		log.Printf(errDownload.Error())
+		log.Print(errDownload)
 		return errDownload
 	}
 
-	collection, err := service.GetCollectionByName(ctx.Context, title)
+	collection, err := a.giftService.GetCollectionByName(ctx.Context, title)
 	if err != nil {
 		log.Printf("failed to get collection: %v", err)
 		return err
 	}
 
-	lottieUrl := fmt.Sprintf("https://rouletton.ru/static/%s.tgs", strings.ToLower(slug))
-	if err = service.AddUserGift(context.Background(), senderID, giftID, msgID, title, collectibleID, collection.ID, lottieUrl); err != nil {
-		log.Printf("failed to add user gift <%d:%d>: %v", senderID, giftID, err)
+	giftDB := &dbModels.GiftDB{
+		ID:            giftID,
+		MsgID:         msgID,
+		Name:          title,
+		CollectibleID: collectibleID,
+		LottieUrl:     fmt.Sprintf("https://rouletton.ru/static/%s.tgs", strings.ToLower(slug)),
+		CollectionID:  collection.ID,
+		Attributes:    giftAttributes,
+	}
+	senderID := giftSender(m, giftAction)
+	if err = a.depositService.AddGift(context.Background(), senderID, giftDB); err != nil {
+		log.Printf("failed to add gift deposit <%d:%d>: %v", senderID, giftID, err)
+		return err
 	}
 
 	return nil
 }
 
+// giftSender returns the peer who sent the gift, 0 if it is hidden.
+// Original details are skipped, they point to the minter
+func giftSender(m *types.Message, action *tg.MessageActionStarGiftUnique) int64 {
+	if from, ok := action.GetFromID(); ok {
+		if peer, ok := from.(*tg.PeerUser); ok {
+			return peer.UserID
+		}
+	}
+	if peer, ok := m.FromID.(*tg.PeerUser); ok {
+		return peer.UserID
+	}
+	if peer, ok := m.PeerID.(*tg.PeerUser); ok && !m.Out {
+		return peer.UserID
+	}
+	return 0
+}
+
 func processGiftMonitor(ctx *ext.Context, update *ext.Update) error {
 	m := update.EffectiveMessage
 	fmt.Println(m.Text)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+
+	"roulette/internal/config"
+	"roulette/internal/daemon"
+	"roulette/internal/database"
+	giftRepo "roulette/internal/gift/repo"
+	giftService "roulette/internal/gift/service"
+	ledgerRepo "roulette/internal/ledger/repo"
+	ledgerService "roulette/internal/ledger/service"
+	pauseRepo "roulette/internal/pause/repo"
+	pauseService "roulette/internal/pause/service"
+	tgService "roulette/internal/tg/service"
+	tonService "roulette/internal/ton/service"
+	userRepo "roulette/internal/user/repo"
+	userService "roulette/internal/user/service"
+	walletRepo "roulette/internal/wallet/repo"
+	walletService "roulette/internal/wallet/service"
+	withdrawRepo "roulette/internal/withdraw/repo"
+	withdrawService "roulette/internal/withdraw/service"
+)
+
+const (
+	configPath = "config/prod.yaml"
+	envPath    = ".env"
+)
+
+func main() {
+	conf, err := config.Load(configPath, envPath)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	db, err := database.NewDatabase(conf)
+	if err != nil {
+		log.Fatalf("failed to init db: %v", err)
+	}
+
+	serviceTon, err := tonService.NewService(conf)
+	if err != nil {
+		log.Fatalf("failed to init ton service: %v", err)
+	}
+
+	repoUser := userRepo.NewRepo(db)
+	serviceUser := userService.NewService(repoUser)
+
+	repoGift := giftRepo.NewRepo(db)
+	serviceGift := giftService.NewService(repoGift)
+
+	repoLedger := ledgerRepo.NewRepo(db)
+	serviceLedger := ledgerService.NewService(repoLedger)
+
+	serviceWallet := walletService.NewService(walletRepo.NewRepo(db), serviceTon, conf)
+
+	servicePause := pauseService.NewService(pauseRepo.NewRepo(db))
+
+	repo := withdrawRepo.NewRepo(db)
+	service := withdrawService.NewService(repo, serviceTon, tgService.NewService(conf), serviceGift, serviceUser, serviceLedger, servicePause, serviceWallet, conf)
+
+	cfg := conf.WithdrawConfig
+
+	checkers := []*daemon.Checker{
+		{
+			Name:       "submit",
+			Interval:   cfg.SubmitInterval,
+			Timeout:    cfg.Timeout,
+			MaxBackoff: cfg.MaxBackoff,
+			Check:      service.SubmitWithdrawals,
+		},
+		{
+			Name:       "confirm",
+			Interval:   cfg.ConfirmInterval,
+			Timeout:    cfg.Timeout,
+			MaxBackoff: cfg.MaxBackoff,
+			Check:      service.ConfirmWithdrawals,
+		},
+		{
+			Name:       "refund",
+			Interval:   cfg.RefundInterval,
+			Timeout:    cfg.Timeout,
+			MaxBackoff: cfg.MaxBackoff,
+			Check:      service.RefundWithdrawals,
+		},
+	}
+
+	daemon.Run(conf.Mode, cfg.HealthAddr, checkers)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/admin/model"
+	"roulette/internal/admin/service"
+	"roulette/internal/config"
+	"roulette/internal/middleware"
+)
+
+type Handler struct {
+	service service.Service
+}
+
+func NewHandler(service service.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func Router(h *Handler, r *gin.Engine, cfg *config.Config) {
+	router := r.Group("admin", middleware.AdminMiddleware(cfg.TgConfig.BotToken, cfg.AdminConfig, cfg.Mode))
+
+	viewerRouter := router.Group("", middleware.RoleMiddleware(model.RoleViewer))
+	{
+		viewerRouter.GET("/users", h.getUsers)
+		viewerRouter.GET("/users/:user_id", h.getUser)
+		viewerRouter.GET("/deposits/nft", h.getNftDeposits)
+		viewerRouter.GET("/deposits/gift/pending", h.getPendingGiftDeposits)
+		viewerRouter.GET("/collections", h.getCollections)
+		viewerRouter.GET("/collections/:collection_id/premiums", h.getCollectionPremiums)
+		viewerRouter.GET("/pauses", h.getPauses)
+		viewerRouter.GET("/rounds", h.getRounds)
+	}
+
+	operatorRouter := router.Group("", middleware.RoleMiddleware(model.RoleOperator))
+	{
+		operatorRouter.POST("/deposits/nft/:deposit_id/confirm", h.confirmNftDeposit)
+		operatorRouter.POST("/deposits/nft/:deposit_id/reject", h.rejectNftDeposit)
+		operatorRouter.POST("/deposits/gift/:deposit_id/resolve", h.resolveGiftDeposit)
+		operatorRouter.PUT("/users/:user_id/limits", h.setUserLimits)
+		operatorRouter.PUT("/collections/:collection_id", h.updateCollection)
+		operatorRouter.PUT("/collections/:collection_id/premiums", h.setCollectionPremiums)
+		operatorRouter.POST("/pauses/:feature", h.pause)
+		operatorRouter.DELETE("/pauses/:feature", h.resume)
+	}
+
+	adminRouter := router.Group("", middleware.RoleMiddleware(model.RoleAdmin))
+	{
+		adminRouter.POST("/users/:user_id/balance", h.adjustBalance)
+		adminRouter.DELETE("/users/:user_id/exclusion", h.liftExclusion)
+		adminRouter.GET("/audit", h.getAuditLog)
+	}
+}
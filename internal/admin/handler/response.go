@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"roulette/internal/admin/model"
+	depositModel "roulette/internal/deposit/model"
+	giftModel "roulette/internal/gift/model"
+	pauseModel "roulette/internal/pause/model"
+	userModel "roulette/internal/user/model"
+	valuationModel "roulette/internal/valuation/model"
+)
+
+type UsersResponse struct {
+	Users []*userModel.User `json:"users"`
+}
+
+func NewUsersResponse(users []*userModel.User) *UsersResponse {
+	return &UsersResponse{
+		Users: users,
+	}
+}
+
+type UserResponse struct {
+	*model.UserInfo
+}
+
+func NewUserResponse(user *model.UserInfo) *UserResponse {
+	return &UserResponse{
+		user,
+	}
+}
+
+type NftDepositsResponse struct {
+	Deposits []*model.NftDeposit `json:"deposits"`
+}
+
+func NewNftDepositsResponse(deposits []*model.NftDeposit) *NftDepositsResponse {
+	return &NftDepositsResponse{
+		Deposits: deposits,
+	}
+}
+
+type PendingGiftDepositsResponse struct {
+	Deposits []*depositModel.PendingGiftDeposit `json:"deposits"`
+}
+
+func NewPendingGiftDepositsResponse(deposits []*depositModel.PendingGiftDeposit) *PendingGiftDepositsResponse {
+	return &PendingGiftDepositsResponse{
+		Deposits: deposits,
+	}
+}
+
+type CollectionsResponse struct {
+	Collections []*giftModel.Collection `json:"collections"`
+}
+
+func NewCollectionsResponse(collections []*giftModel.Collection) *CollectionsResponse {
+	return &CollectionsResponse{
+		Collections: collections,
+	}
+}
+
+type PremiumsResponse struct {
+	Premiums []*valuationModel.Premium `json:"premiums"`
+}
+
+func NewPremiumsResponse(premiums []*valuationModel.Premium) *PremiumsResponse {
+	return &PremiumsResponse{
+		Premiums: premiums,
+	}
+}
+
+type PausesResponse struct {
+	Pauses []*pauseModel.Pause `json:"pauses"`
+}
+
+func NewPausesResponse(pauses []*pauseModel.Pause) *PausesResponse {
+	return &PausesResponse{
+		Pauses: pauses,
+	}
+}
+
+type RoundsResponse struct {
+	Rounds []*model.Round `json:"rounds"`
+}
+
+func NewRoundsResponse(rounds []*model.Round) *RoundsResponse {
+	return &RoundsResponse{
+		Rounds: rounds,
+	}
+}
+
+type AuditLogResponse struct {
+	Logs []*model.AuditLog `json:"logs"`
+}
+
+func NewAuditLogResponse(logs []*model.AuditLog) *AuditLogResponse {
+	return &AuditLogResponse{
+		Logs: logs,
+	}
+}
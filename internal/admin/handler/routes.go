@@ -0,0 +1,483 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/admin/model"
+	"roulette/internal/admin/service"
+	dbModels "roulette/internal/database/models"
+	depositService "roulette/internal/deposit/service"
+	ledgerService "roulette/internal/ledger/service"
+	limitModel "roulette/internal/limit/model"
+	limitService "roulette/internal/limit/service"
+	"roulette/internal/middleware"
+	"roulette/internal/middleware/handler"
+	pauseService "roulette/internal/pause/service"
+	userService "roulette/internal/user/service"
+	valuationModel "roulette/internal/valuation/model"
+	valuationService "roulette/internal/valuation/service"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+type RequestPage struct {
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+}
+
+func (p *RequestPage) normalize() {
+	if p.Limit <= 0 || p.Limit > maxLimit {
+		p.Limit = defaultLimit
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+}
+
+func (h *Handler) getUsers(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestQuery struct {
+			RequestPage
+			Query string `form:"query"`
+		}
+		var query RequestQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		query.normalize()
+
+		users, err := h.service.GetUsers(c.Request.Context(), query.Query, query.Limit, query.Offset)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewUsersResponse(users))
+	})
+}
+
+func (h *Handler) getUser(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			UserID uint `uri:"user_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		user, err := h.service.GetUser(c.Request.Context(), uri.UserID)
+		if err != nil {
+			if userService.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewUserResponse(user))
+	})
+}
+
+func (h *Handler) adjustBalance(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			UserID uint `uri:"user_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		type RequestBody struct {
+			Amount int64  `json:"amount"`
+			Reason string `json:"reason"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		err := h.service.AdjustBalance(c.Request.Context(), admin(c), uri.UserID, body.Amount, body.Reason)
+		if err != nil {
+			if userService.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			if service.IsInvalidAdjustment(err) || ledgerService.IsNotEnoughBalance(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusNoContent, nil)
+	})
+}
+
+func (h *Handler) setUserLimits(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			UserID uint `uri:"user_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		var body limitModel.LimitsUpdate
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.SetUserLimits(c.Request.Context(), admin(c), uri.UserID, &body); err != nil {
+			if limitService.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			if limitService.IsInvalidLimits(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusNoContent, nil)
+	})
+}
+
+func (h *Handler) liftExclusion(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			UserID uint `uri:"user_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.LiftExclusion(c.Request.Context(), admin(c), uri.UserID); err != nil {
+			if limitService.IsExclusionNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusNoContent, nil)
+	})
+}
+
+func (h *Handler) getNftDeposits(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestQuery struct {
+			RequestPage
+			Status model.NftDepositStatus `form:"status"`
+		}
+		var query RequestQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		query.normalize()
+
+		deposits, err := h.service.GetNftDeposits(c.Request.Context(), query.Status, query.Limit, query.Offset)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewNftDepositsResponse(deposits))
+	})
+}
+
+func (h *Handler) confirmNftDeposit(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			DepositID uint `uri:"deposit_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.ConfirmNftDeposit(c.Request.Context(), admin(c), uri.DepositID); err != nil {
+			return nftDepositErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, nil)
+	})
+}
+
+func (h *Handler) rejectNftDeposit(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			DepositID uint `uri:"deposit_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		type RequestBody struct {
+			Reason string `json:"reason"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.RejectNftDeposit(c.Request.Context(), admin(c), uri.DepositID, body.Reason); err != nil {
+			return nftDepositErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, nil)
+	})
+}
+
+func (h *Handler) getPendingGiftDeposits(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		deposits, err := h.service.GetPendingGiftDeposits(c.Request.Context())
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewPendingGiftDepositsResponse(deposits))
+	})
+}
+
+func (h *Handler) resolveGiftDeposit(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			DepositID uint `uri:"deposit_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		type RequestBody struct {
+			UserID uint `json:"userId"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.ResolveGiftDeposit(c.Request.Context(), admin(c), uri.DepositID, body.UserID); err != nil {
+			if depositService.IsGiftDepositNotFound(err) || userService.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			if depositService.IsGiftDepositResolved(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, nil)
+	})
+}
+
+func (h *Handler) getCollections(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		collections, err := h.service.GetCollections(c.Request.Context())
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewCollectionsResponse(collections))
+	})
+}
+
+func (h *Handler) updateCollection(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			CollectionID uint `uri:"collection_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		var body model.CollectionUpdate
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		if body.Floor != nil && *body.Floor < 0 {
+			return handler.NewErrorResponse(http.StatusBadRequest, "floor must not be negative")
+		}
+
+		if err := h.service.UpdateCollection(c.Request.Context(), admin(c), uri.CollectionID, &body); err != nil {
+			if service.IsCollectionNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			if service.IsEmptyUpdate(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, nil)
+	})
+}
+
+func (h *Handler) getCollectionPremiums(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			CollectionID uint `uri:"collection_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		premiums, err := h.service.GetCollectionPremiums(c.Request.Context(), uri.CollectionID)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewPremiumsResponse(premiums))
+	})
+}
+
+func (h *Handler) setCollectionPremiums(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			CollectionID uint `uri:"collection_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		type RequestBody struct {
+			Premiums []*valuationModel.Premium `json:"premiums"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.SetCollectionPremiums(c.Request.Context(), admin(c), uri.CollectionID, body.Premiums); err != nil {
+			if valuationService.IsCollectionNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			if valuationService.IsInvalidPremiums(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusNoContent, nil)
+	})
+}
+
+func (h *Handler) getPauses(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		pauses, err := h.service.GetPauses(c.Request.Context())
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewPausesResponse(pauses))
+	})
+}
+
+func (h *Handler) pause(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			Feature dbModels.Feature `uri:"feature"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		type RequestBody struct {
+			Reason *string `json:"reason"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.Pause(c.Request.Context(), admin(c), uri.Feature, body.Reason); err != nil {
+			if pauseService.IsUnknownFeature(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusNoContent, nil)
+	})
+}
+
+func (h *Handler) resume(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			Feature dbModels.Feature `uri:"feature"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.Resume(c.Request.Context(), admin(c), uri.Feature); err != nil {
+			if pauseService.IsUnknownFeature(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusNoContent, nil)
+	})
+}
+
+func (h *Handler) getRounds(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		var page RequestPage
+		if err := c.ShouldBindQuery(&page); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		page.normalize()
+
+		rounds, err := h.service.GetRounds(c.Request.Context(), page.Limit, page.Offset)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewRoundsResponse(rounds))
+	})
+}
+
+func (h *Handler) getAuditLog(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		var page RequestPage
+		if err := c.ShouldBindQuery(&page); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		page.normalize()
+
+		logs, err := h.service.GetAuditLog(c.Request.Context(), page.Limit, page.Offset)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewAuditLogResponse(logs))
+	})
+}
+
+func nftDepositErrorResponse(err error) *handler.Response {
+	if depositService.IsNftDepositNotFound(err) {
+		return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+	}
+	if depositService.IsNftDepositResolved(err) || depositService.IsNftNotReceived(err) {
+		return handler.NewErrorResponse(http.StatusConflict, err.Error())
+	}
+	if depositService.IsNftRejected(err) {
+		return handler.NewUnprocessableErrorResponse(err)
+	}
+	return handler.NewInternalErrorResponse(err)
+}
+
+// admin is set by AdminMiddleware for every admin route
+func admin(c *gin.Context) *model.Admin {
+	admin, _ := middleware.Admin(c.Request.Context())
+	return admin
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	dbModels "roulette/internal/database/models"
+	userModel "roulette/internal/user/model"
+)
+
+// Role is ordered, a role allows everything of lower roles
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// Allows reports whether the role is at least required, unknown roles allow nothing
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
+
+// Admin is the caller of admin api, Actor is tg:<user id> or key:<key name>
+type Admin struct {
+	Actor string
+	Role  Role
+}
+
+type UserInfo struct {
+	*userModel.User
+	Profile *userModel.UserProfile `json:"profile"`
+}
+
+type NftDepositStatus string
+
+const (
+	NftDepositPending   NftDepositStatus = "pending"
+	NftDepositConfirmed NftDepositStatus = "confirmed"
+	NftDepositRejected  NftDepositStatus = "rejected"
+)
+
+type NftDeposit struct {
+	ID         uint             `json:"id"`
+	UserID     uint             `json:"userId"`
+	Sender     string           `json:"sender"`
+	NftAddress string           `json:"nftAddress"`
+	TraceID    *string          `json:"traceId"`
+	Status     NftDepositStatus `json:"status"`
+	Reason     *string          `json:"reason"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func NewNftDeposit(d *dbModels.NftDepositDB) *NftDeposit {
+	status := NftDepositPending
+	if d.IsConfirmed != nil {
+		status = NftDepositRejected
+		if *d.IsConfirmed {
+			status = NftDepositConfirmed
+		}
+	}
+
+	return &NftDeposit{
+		ID:         d.ID,
+		UserID:     d.UserID,
+		Sender:     d.Sender,
+		NftAddress: d.NftAddress,
+		TraceID:    d.TraceID,
+		Status:     status,
+		Reason:     d.Reason,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+// CollectionUpdate changes only set fields
+type CollectionUpdate struct {
+	Name    *string `json:"name"`
+	Address *string `json:"address"`
+	Floor   *int64  `json:"floor"`
+}
+
+// Round is a finished or current round with its winner
+type Round struct {
+	ID           uint       `json:"id"`
+	Hash         string     `json:"hash"`
+	Room         string     `json:"room"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	StartedAt    *time.Time `json:"startedAt"`
+	Players      int        `json:"players"`
+	TotalTickets int        `json:"totalTickets"`
+	WinnerID     *uint      `json:"winnerId"`
+	Ticket       *int       `json:"ticket"`
+	Fee          *int64     `json:"fee"`
+	IsPaid       *bool      `json:"isPaid"`
+}
+
+type AuditLog struct {
+	ID        uint            `json:"id"`
+	Actor     string          `json:"actor"`
+	Role      string          `json:"role"`
+	Action    string          `json:"action"`
+	Target    *string         `json:"target"`
+	Params    json.RawMessage `json:"params"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+func NewAuditLog(l *dbModels.AuditLogDB) *AuditLog {
+	log := &AuditLog{
+		ID:        l.ID,
+		Actor:     l.Actor,
+		Role:      l.Role,
+		Action:    l.Action,
+		Target:    l.Target,
+		CreatedAt: l.CreatedAt,
+	}
+	if l.Params != nil {
+		log.Params = json.RawMessage(*l.Params)
+	}
+	return log
+}
@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/admin/model"
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	userModel "roulette/internal/user/model"
+)
+
+type Repo interface {
+	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
+
+	// GetUsers matches query to id or memo exactly and to name partially, all users for an empty query
+	GetUsers(ctx context.Context, query string, limit, offset int) ([]*userModel.User, error)
+
+	// GetNftDeposits returns the newest deposits, all statuses for an empty status
+	GetNftDeposits(ctx context.Context, status model.NftDepositStatus, limit, offset int) ([]*model.NftDeposit, error)
+
+	GetRounds(ctx context.Context, limit, offset int) ([]*model.Round, error)
+
+	GetAuditLog(ctx context.Context, limit, offset int) ([]*dbModels.AuditLogDB, error)
+
+	AddAuditLog(ctx context.Context, log *dbModels.AuditLogDB) error
+
+	// UpdateCollection sets the non zero fields, a set floor clears the stale mark
+	UpdateCollection(ctx context.Context, collectionID uint, collection *dbModels.CollectionDB) error
+}
+
+type repo struct {
+	db *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repo {
+	return &repo{db: db}
+}
+
+func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, r.db, f)
+}
+
+func (r *repo) GetUsers(ctx context.Context, query string, limit, offset int) ([]*userModel.User, error) {
+	db := database.FromContext(ctx, r.db)
+
+	q := db.WithContext(ctx).
+		Model(&dbModels.UserDB{})
+	if query != "" {
+		q = q.Where("CAST(id AS TEXT) = ? OR memo = ? OR name ILIKE '%' || ? || '%'", query, query, query)
+	}
+
+	var users []*userModel.User
+	err := q.
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *repo) GetNftDeposits(ctx context.Context, status model.NftDepositStatus, limit, offset int) ([]*model.NftDeposit, error) {
+	db := database.FromContext(ctx, r.db)
+
+	q := db.WithContext(ctx)
+	switch status {
+	case model.NftDepositPending:
+		q = q.Where("is_confirmed IS NULL")
+	case model.NftDepositConfirmed:
+		q = q.Where("is_confirmed")
+	case model.NftDepositRejected:
+		q = q.Where("NOT is_confirmed")
+	}
+
+	var deps []*dbModels.NftDepositDB
+	err := q.
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	deposits := make([]*model.NftDeposit, 0, len(deps))
+	for _, dep := range deps {
+		deposits = append(deposits, model.NewNftDeposit(dep))
+	}
+
+	return deposits, nil
+}
+
+func (r *repo) GetRounds(ctx context.Context, limit, offset int) ([]*model.Round, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var rounds []*model.Round
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT r.id, r.hash, r.room, r.created_at, r.started_at,
+				   COUNT(DISTINCT rt.user_id) AS players, COALESCE(SUM(rt.tickets), 0) AS total_tickets,
+				   rw.user_id AS winner_id, rw.ticket, rw.fee, rw.is_paid
+			FROM rounds r
+				LEFT OUTER JOIN rounds_tickets rt ON rt.round_id = r.id
+				LEFT OUTER JOIN rounds_winners rw ON rw.round_id = r.id
+			GROUP BY r.id, rw.id
+			ORDER BY r.id DESC
+			LIMIT ? OFFSET ?
+		`, limit, offset).
+		Scan(&rounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rounds, nil
+}
+
+func (r *repo) GetAuditLog(ctx context.Context, limit, offset int) ([]*dbModels.AuditLogDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var logs []*dbModels.AuditLogDB
+	err := db.WithContext(ctx).
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (r *repo) AddAuditLog(ctx context.Context, log *dbModels.AuditLogDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	return db.WithContext(ctx).
+		Create(log).Error
+}
+
+func (r *repo) UpdateCollection(ctx context.Context, collectionID uint, collection *dbModels.CollectionDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Where("id = ?", collectionID).
+		Updates(collection)
+	if res.Error != nil {
+		if database.IsKeyConflictErr(res.Error) {
+			return database.ErrKeyConflict
+		}
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+
+	// a floor set by hand is fresh until the floor oracle prices the collection again
+	if collection.Floor != nil {
+		err := db.WithContext(ctx).
+			Model(&dbModels.CollectionDB{}).
+			Where("id = ?", collectionID).
+			Updates(map[string]interface{}{"is_stale": false, "floor_updated_at": gorm.Expr("CURRENT_TIMESTAMP")}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
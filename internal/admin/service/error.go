@@ -0,0 +1,21 @@
+package service
+
+import "errors"
+
+var (
+	ErrInvalidAdjustment  = errors.New("balance adjustment needs a non zero amount and a reason")
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrEmptyUpdate        = errors.New("nothing to update")
+)
+
+func IsInvalidAdjustment(err error) bool {
+	return errors.Is(err, ErrInvalidAdjustment)
+}
+
+func IsCollectionNotFound(err error) bool {
+	return errors.Is(err, ErrCollectionNotFound)
+}
+
+func IsEmptyUpdate(err error) bool {
+	return errors.Is(err, ErrEmptyUpdate)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"roulette/internal/admin/model"
+	"roulette/internal/admin/repo"
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	depositModel "roulette/internal/deposit/model"
+	depositService "roulette/internal/deposit/service"
+	giftModel "roulette/internal/gift/model"
+	giftService "roulette/internal/gift/service"
+	ledgerModel "roulette/internal/ledger/model"
+	ledgerService "roulette/internal/ledger/service"
+	limitModel "roulette/internal/limit/model"
+	limitService "roulette/internal/limit/service"
+	pauseModel "roulette/internal/pause/model"
+	pauseService "roulette/internal/pause/service"
+	userModel "roulette/internal/user/model"
+	userService "roulette/internal/user/service"
+	valuationModel "roulette/internal/valuation/model"
+	valuationService "roulette/internal/valuation/service"
+)
+
+// Service writes every change to the audit log in the tx of the change
+type Service interface {
+	GetUsers(ctx context.Context, query string, limit, offset int) ([]*userModel.User, error)
+
+	GetUser(ctx context.Context, userID uint) (*model.UserInfo, error)
+
+	// AdjustBalance credits a positive amount or debits a negative one from the house account
+	AdjustBalance(ctx context.Context, admin *model.Admin, userID uint, amount int64, reason string) error
+
+	// SetUserLimits replaces the bet limits of the user, nil limits go back to the default
+	SetUserLimits(ctx context.Context, admin *model.Admin, userID uint, update *limitModel.LimitsUpdate) error
+
+	// LiftExclusion ends the self-exclusion of the user before it expires
+	LiftExclusion(ctx context.Context, admin *model.Admin, userID uint) error
+
+	GetNftDeposits(ctx context.Context, status model.NftDepositStatus, limit, offset int) ([]*model.NftDeposit, error)
+
+	ConfirmNftDeposit(ctx context.Context, admin *model.Admin, depositID uint) error
+
+	RejectNftDeposit(ctx context.Context, admin *model.Admin, depositID uint, reason string) error
+
+	GetPendingGiftDeposits(ctx context.Context) ([]*depositModel.PendingGiftDeposit, error)
+
+	ResolveGiftDeposit(ctx context.Context, admin *model.Admin, depositID uint, userID uint) error
+
+	GetCollections(ctx context.Context) ([]*giftModel.Collection, error)
+
+	UpdateCollection(ctx context.Context, admin *model.Admin, collectionID uint, update *model.CollectionUpdate) error
+
+	GetCollectionPremiums(ctx context.Context, collectionID uint) ([]*valuationModel.Premium, error)
+
+	// SetCollectionPremiums replaces the trait premiums of the collection, bets placed before keep their valuation
+	SetCollectionPremiums(ctx context.Context, admin *model.Admin, collectionID uint, premiums []*valuationModel.Premium) error
+
+	GetPauses(ctx context.Context) ([]*pauseModel.Pause, error)
+
+	Pause(ctx context.Context, admin *model.Admin, feature dbModels.Feature, reason *string) error
+
+	Resume(ctx context.Context, admin *model.Admin, feature dbModels.Feature) error
+
+	GetRounds(ctx context.Context, limit, offset int) ([]*model.Round, error)
+
+	GetAuditLog(ctx context.Context, limit, offset int) ([]*model.AuditLog, error)
+
+	// audit runs f and logs the action in one tx, the log id is passed to f
+	audit(ctx context.Context, admin *model.Admin, action string, target string, params interface{}, f func(ctx context.Context, logID uint) error) error
+}
+
+type service struct {
+	repo             repo.Repo
+	userService      userService.Service
+	ledgerService    ledgerService.Service
+	depositService   depositService.Service
+	giftService      giftService.Service
+	pauseService     pauseService.Service
+	limitService     limitService.Service
+	valuationService valuationService.Service
+}
+
+func NewService(
+	repo repo.Repo,
+	userService userService.Service,
+	ledgerService ledgerService.Service,
+	depositService depositService.Service,
+	giftService giftService.Service,
+	pauseService pauseService.Service,
+	limitService limitService.Service,
+	valuationService valuationService.Service,
+) Service {
+	return &service{
+		repo:             repo,
+		userService:      userService,
+		ledgerService:    ledgerService,
+		depositService:   depositService,
+		giftService:      giftService,
+		pauseService:     pauseService,
+		limitService:     limitService,
+		valuationService: valuationService,
+	}
+}
+
+func (s *service) GetUsers(ctx context.Context, query string, limit, offset int) ([]*userModel.User, error) {
+	return s.repo.GetUsers(ctx, strings.TrimSpace(query), limit, offset)
+}
+
+func (s *service) GetUser(ctx context.Context, userID uint) (*model.UserInfo, error) {
+	user, err := s.userService.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.userService.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserInfo{User: user, Profile: profile}, nil
+}
+
+func (s *service) AdjustBalance(ctx context.Context, admin *model.Admin, userID uint, amount int64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if amount == 0 || reason == "" {
+		return ErrInvalidAdjustment
+	}
+
+	params := map[string]interface{}{"amount": amount, "reason": reason}
+	return s.audit(ctx, admin, "user.balance.adjust", fmt.Sprintf("user:%d", userID), params, func(ctx context.Context, logID uint) error {
+		posting := &ledgerModel.Posting{
+			Key:     ledgerModel.Key(ledgerModel.AdminAdjust, logID),
+			Kind:    ledgerModel.AdminAdjust,
+			Debit:   ledgerModel.HouseAccount,
+			Credit:  ledgerModel.UserAccount(userID),
+			Amount:  amount,
+			Comment: &reason,
+		}
+		if amount < 0 {
+			posting.Debit, posting.Credit, posting.Amount = posting.Credit, posting.Debit, -amount
+		}
+
+		if err := s.ledgerService.Post(ctx, posting); err != nil {
+			if ledgerService.IsUserNotFound(err) {
+				return userService.ErrUserNotFound
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *service) SetUserLimits(ctx context.Context, admin *model.Admin, userID uint, update *limitModel.LimitsUpdate) error {
+	return s.audit(ctx, admin, "user.limits.set", fmt.Sprintf("user:%d", userID), update, func(ctx context.Context, _ uint) error {
+		return s.limitService.SetLimits(ctx, userID, update, admin.Actor)
+	})
+}
+
+func (s *service) LiftExclusion(ctx context.Context, admin *model.Admin, userID uint) error {
+	return s.audit(ctx, admin, "user.exclusion.lift", fmt.Sprintf("user:%d", userID), nil, func(ctx context.Context, _ uint) error {
+		return s.limitService.LiftExclusion(ctx, userID)
+	})
+}
+
+func (s *service) GetNftDeposits(ctx context.Context, status model.NftDepositStatus, limit, offset int) ([]*model.NftDeposit, error) {
+	return s.repo.GetNftDeposits(ctx, status, limit, offset)
+}
+
+func (s *service) ConfirmNftDeposit(ctx context.Context, admin *model.Admin, depositID uint) error {
+	return s.audit(ctx, admin, "deposit.nft.confirm", fmt.Sprintf("nft_deposit:%d", depositID), nil, func(ctx context.Context, _ uint) error {
+		return s.depositService.ConfirmNft(ctx, depositID)
+	})
+}
+
+func (s *service) RejectNftDeposit(ctx context.Context, admin *model.Admin, depositID uint, reason string) error {
+	params := map[string]interface{}{"reason": reason}
+	return s.audit(ctx, admin, "deposit.nft.reject", fmt.Sprintf("nft_deposit:%d", depositID), params, func(ctx context.Context, _ uint) error {
+		return s.depositService.RejectNft(ctx, depositID, reason)
+	})
+}
+
+func (s *service) GetPendingGiftDeposits(ctx context.Context) ([]*depositModel.PendingGiftDeposit, error) {
+	return s.depositService.GetPendingGifts(ctx)
+}
+
+func (s *service) ResolveGiftDeposit(ctx context.Context, admin *model.Admin, depositID uint, userID uint) error {
+	params := map[string]interface{}{"userId": userID}
+	return s.audit(ctx, admin, "deposit.gift.resolve", fmt.Sprintf("gift_deposit:%d", depositID), params, func(ctx context.Context, _ uint) error {
+		return s.depositService.ResolveGift(ctx, depositID, userID)
+	})
+}
+
+func (s *service) GetCollections(ctx context.Context) ([]*giftModel.Collection, error) {
+	return s.giftService.GetCollections(ctx)
+}
+
+func (s *service) UpdateCollection(ctx context.Context, admin *model.Admin, collectionID uint, update *model.CollectionUpdate) error {
+	if update.Name == nil && update.Address == nil && update.Floor == nil {
+		return ErrEmptyUpdate
+	}
+
+	return s.audit(ctx, admin, "collection.update", fmt.Sprintf("collection:%d", collectionID), update, func(ctx context.Context, _ uint) error {
+		err := s.repo.UpdateCollection(ctx, collectionID, &dbModels.CollectionDB{
+			Name:    stringValue(update.Name),
+			Address: update.Address,
+			Floor:   update.Floor,
+		})
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return ErrCollectionNotFound
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *service) GetCollectionPremiums(ctx context.Context, collectionID uint) ([]*valuationModel.Premium, error) {
+	return s.valuationService.GetPremiums(ctx, collectionID)
+}
+
+func (s *service) SetCollectionPremiums(ctx context.Context, admin *model.Admin, collectionID uint, premiums []*valuationModel.Premium) error {
+	return s.audit(ctx, admin, "collection.premiums.set", fmt.Sprintf("collection:%d", collectionID), premiums, func(ctx context.Context, _ uint) error {
+		return s.valuationService.SetPremiums(ctx, collectionID, premiums, admin.Actor)
+	})
+}
+
+func (s *service) GetPauses(ctx context.Context) ([]*pauseModel.Pause, error) {
+	return s.pauseService.GetPauses(ctx)
+}
+
+func (s *service) Pause(ctx context.Context, admin *model.Admin, feature dbModels.Feature, reason *string) error {
+	params := map[string]interface{}{"reason": reason}
+	return s.audit(ctx, admin, "feature.pause", string(feature), params, func(ctx context.Context, _ uint) error {
+		return s.pauseService.Pause(ctx, feature, admin.Actor, reason)
+	})
+}
+
+func (s *service) Resume(ctx context.Context, admin *model.Admin, feature dbModels.Feature) error {
+	return s.audit(ctx, admin, "feature.resume", string(feature), nil, func(ctx context.Context, _ uint) error {
+		return s.pauseService.Resume(ctx, feature)
+	})
+}
+
+func (s *service) GetRounds(ctx context.Context, limit, offset int) ([]*model.Round, error) {
+	return s.repo.GetRounds(ctx, limit, offset)
+}
+
+func (s *service) GetAuditLog(ctx context.Context, limit, offset int) ([]*model.AuditLog, error) {
+	logs, err := s.repo.GetAuditLog(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.AuditLog, 0, len(logs))
+	for _, log := range logs {
+		res = append(res, model.NewAuditLog(log))
+	}
+
+	return res, nil
+}
+
+func (s *service) audit(ctx context.Context, admin *model.Admin, action string, target string, params interface{}, f func(ctx context.Context, logID uint) error) error {
+	log := &dbModels.AuditLogDB{
+		Actor:  admin.Actor,
+		Role:   string(admin.Role),
+		Action: action,
+		Target: &target,
+	}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit params: %v", err)
+		}
+		p := string(b)
+		log.Params = &p
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddAuditLog(ctx, log); err != nil {
+			return err
+		}
+		return f(ctx, log.ID)
+	})
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -15,12 +15,22 @@ import (
 )
 
 type Config struct {
-	Mode         string       `json:"mode"`
-	Origin       string       `json:"origin"`
-	ServerConfig ServerConfig `json:"server"`
-	DBConfig     DBConfig     `json:"db"`
-	TgConfig     TgConfig     `json:"tg"`
-	TonConfig    TonConfig    `json:"ton"`
+	Mode            string          `json:"mode"`
+	Origin          string          `json:"origin"`
+	ServerConfig    ServerConfig    `json:"server"`
+	DBConfig        DBConfig        `json:"db"`
+	TgConfig        TgConfig        `json:"tg"`
+	TonConfig       TonConfig       `json:"ton"`
+	EventsConfig    EventsConfig    `json:"events"`
+	StarConfig      StarConfig      `json:"star"`
+	DepositConfig   DepositConfig   `json:"deposit"`
+	WithdrawConfig  WithdrawConfig  `json:"withdraw"`
+	AdminConfig     AdminConfig     `json:"admin"`
+	GameConfig      GameConfig      `json:"game"`
+	LimitConfig     LimitConfig     `json:"limit"`
+	FloorConfig     FloorConfig     `json:"floor"`
+	ValuationConfig ValuationConfig `json:"valuation"`
+	WalletConfig    WalletConfig    `json:"wallet"`
 }
 
 type ServerConfig struct {
@@ -39,18 +49,204 @@ type DBConfig struct {
 }
 
 type TgConfig struct {
-	BotToken    string `json:"botToken"`
-	ClientID    int    `json:"clientID"`
-	ClientHash  string `json:"clientHash"`
-	ClientPhone string `json:"clientPhone"`
+	BotToken    string  `json:"botToken"`
+	BotApiUrl   string  `json:"botApiUrl"`
+	AdminIDs    []int64 `json:"adminIds"`
+	ClientID    int     `json:"clientID"`
+	ClientHash  string  `json:"clientHash"`
+	ClientPhone string  `json:"clientPhone"`
 }
 
 type TonConfig struct {
 	IsTestnet              bool   `json:"isTestnet"`
 	TonCenterApiKey        string `json:"tonCenterApiKey"`
 	TonCenterApiKeyTestnet string `json:"tonCenterApiKeyTestnet"`
+	TonApiKey              string `json:"tonApiKey"`
 	AdminWallet            string `json:"adminWallet"`
 	Mnemonic               string `json:"mnemonic"`
+	// Provider and FailoverProvider are one of toncenter, tonapi, lite
+	Provider         string `json:"provider"`
+	FailoverProvider string `json:"failoverProvider"`
+	// RateLimits are requests per second by provider name
+	RateLimits map[string]float64 `json:"rateLimits"`
+	Retries    int                `json:"retries"`
+}
+
+type EventsConfig struct {
+	Bus     string `json:"bus"`
+	Channel string `json:"channel"`
+}
+
+type DepositConfig struct {
+	TonInterval time.Duration `json:"tonInterval"`
+	// TonBackfill makes the first run read the admin wallet history, otherwise
+	// the ton cursor starts at the newest transaction
+	TonBackfill bool          `json:"tonBackfill"`
+	NftInterval time.Duration `json:"nftInterval"`
+	// NftBackfill makes the first run read the nft transfers history, otherwise
+	// the nft cursor starts at the newest admin wallet transaction
+	NftBackfill bool `json:"nftBackfill"`
+	// Timeout limits one check run
+	Timeout    time.Duration `json:"timeout"`
+	MaxBackoff time.Duration `json:"maxBackoff"`
+	HealthAddr string        `json:"healthAddr"`
+}
+
+type WithdrawConfig struct {
+	SubmitInterval  time.Duration `json:"submitInterval"`
+	ConfirmInterval time.Duration `json:"confirmInterval"`
+	RefundInterval  time.Duration `json:"refundInterval"`
+	// BatchWindow is how long a ton withdrawal waits for others to fill a batch of BatchSize
+	BatchWindow time.Duration `json:"batchWindow"`
+	BatchSize   int           `json:"batchSize"`
+	// Timeout limits one check run
+	Timeout    time.Duration `json:"timeout"`
+	MaxBackoff time.Duration `json:"maxBackoff"`
+	HealthAddr string        `json:"healthAddr"`
+}
+
+// GameConfig is copied to each new round, rounds keep the rules they were created with
+type GameConfig struct {
+	RoundDuration time.Duration `json:"roundDuration"`
+	MinPlayers    int           `json:"minPlayers"`
+	// HouseFee is percent of the total bet taken from the winner,
+	// ReferralFee and SpecReferralFee are percents of the house fee paid to the referrer
+	HouseFee        int64 `json:"houseFee"`
+	ReferralFee     int64 `json:"referralFee"`
+	SpecReferralFee int64 `json:"specReferralFee"`
+	// TicketPrice is nanotons of bet per ticket, the rest of a bet below one ticket gives no chance
+	TicketPrice int64 `json:"ticketPrice"`
+	// FeeInItems lets the house keep the cheapest won item covering the fee instead of taking balance,
+	// kept items go to HouseUserID and the option is off without it
+	FeeInItems  bool  `json:"feeInItems"`
+	HouseUserID int64 `json:"houseUserId"`
+	// FeeExpiry is how long a fee short of balance stays pending, then the house keeps
+	// the cheapest inventory item covering it or waives the fee
+	FeeExpiry          time.Duration `json:"feeExpiry"`
+	FeeCollectInterval time.Duration `json:"feeCollectInterval"`
+	// LeaderRetry is how often a standby cmd/game tries to take over the game
+	LeaderRetry time.Duration `json:"leaderRetry"`
+	// Rooms run rounds side by side with the rules above overridden by set room fields,
+	// the game runs a single main room when none are configured
+	Rooms []RoomConfig `json:"rooms"`
+}
+
+type RoomConfig struct {
+	Name string `json:"name"`
+	// MinBet and MaxBet bound the value of one bet in nanotons, zero is unbounded
+	MinBet int64 `json:"minBet"`
+	MaxBet int64 `json:"maxBet"`
+	// GiftsOnly rooms take telegram gifts but not nfts
+	GiftsOnly     bool          `json:"giftsOnly"`
+	RoundDuration time.Duration `json:"roundDuration"`
+	MinPlayers    int           `json:"minPlayers"`
+	HouseFee      *int64        `json:"houseFee"`
+	TicketPrice   int64         `json:"ticketPrice"`
+}
+
+// LimitConfig holds the bet limits of users without their own, zero is unbounded.
+// Daily limits count bets of the last 24 hours
+type LimitConfig struct {
+	RoundBets  int   `json:"roundBets"`
+	RoundValue int64 `json:"roundValue"`
+	DailyBets  int   `json:"dailyBets"`
+	DailyValue int64 `json:"dailyValue"`
+	// MaxExclusionDays bounds the self-exclusion a user can set at once
+	MaxExclusionDays int `json:"maxExclusionDays"`
+}
+
+// ValuationConfig prices items above the collection floor, premiums are percents of the floor
+type ValuationConfig struct {
+	// Rarity premiums apply to attributes with a known rarity, an attribute takes the first matching tier
+	Rarity []RarityPremiumConfig `json:"rarity"`
+	// MaxPremium caps the sum of the premiums of one item, zero is uncapped
+	MaxPremium int64 `json:"maxPremium"`
+}
+
+type RarityPremiumConfig struct {
+	// Trait limits the tier to one trait like model, empty is any trait
+	Trait string `json:"trait"`
+	// MaxRarity is the highest permille of the collection having the attribute
+	MaxRarity int   `json:"maxRarity"`
+	Premium   int64 `json:"premium"`
+}
+
+// FloorConfig drives the floor oracle of cmd/floor
+type FloorConfig struct {
+	// Timeout limits one oracle run
+	Timeout time.Duration `json:"timeout"`
+	// MaxDeviation is the percent a source floor may differ from the median of all sources before it is rejected
+	MaxDeviation int64 `json:"maxDeviation"`
+	// MinSources is how many sources have to agree for a floor to be set
+	MinSources int `json:"minSources"`
+	// StaleAfter is how long a collection keeps its floor when no floor is agreed, then it is marked stale
+	StaleAfter time.Duration       `json:"staleAfter"`
+	Telegram   TelegramFloorConfig `json:"telegram"`
+	Markets    []MarketFloorConfig `json:"markets"`
+	Chain      ChainFloorConfig    `json:"chain"`
+}
+
+// TelegramFloorConfig is the channel posting floors, the source is off without ChannelID
+type TelegramFloorConfig struct {
+	ChannelID  int64 `json:"channelId"`
+	AccessHash int64 `json:"accessHash"`
+}
+
+// MarketFloorConfig is a marketplace http api returning the floor of one collection
+type MarketFloorConfig struct {
+	Name string `json:"name"`
+	// Url has {address} or {name} replaced by the collection, {address} urls skip collections without one
+	Url string `json:"url"`
+	// ApiKey is sent as a Bearer token when set
+	ApiKey string `json:"apiKey"`
+	// FloorPath is the dot separated path of the floor in the json response, in ton when InTon is set
+	FloorPath string `json:"floorPath"`
+	InTon     bool   `json:"inTon"`
+}
+
+// ChainFloorConfig prices collections by recent marketplace sales found in toncenter nft transfers
+type ChainFloorConfig struct {
+	Enabled  bool          `json:"enabled"`
+	Lookback time.Duration `json:"lookback"`
+	// Sales is how many of the newest transfers are searched for purchases
+	Sales int `json:"sales"`
+}
+
+// WalletConfig is for linking wallets by TON Connect ton_proof
+type WalletConfig struct {
+	// Domains are the app domains a proof can be signed for, the origin host when empty
+	Domains []string `json:"domains"`
+	// PayloadTTL is how long an issued payload can be signed, ProofTTL is how old a signed proof can be
+	PayloadTTL time.Duration `json:"payloadTtl"`
+	ProofTTL   time.Duration `json:"proofTtl"`
+	// LinkedWithdrawals only lets users withdraw to the wallets they linked
+	LinkedWithdrawals bool `json:"linkedWithdrawals"`
+}
+
+// AdminConfig grants admin api roles: viewer, operator or admin
+type AdminConfig struct {
+	// Users sign in with init data like the mini app
+	Users []AdminUser `json:"users"`
+	// Keys are sent as Bearer tokens by scripts
+	Keys []AdminKey `json:"keys"`
+}
+
+type AdminUser struct {
+	ID   int64  `json:"id"`
+	Role string `json:"role"`
+}
+
+type AdminKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Role string `json:"role"`
+}
+
+type StarConfig struct {
+	// Rate is nanotons credited per star
+	Rate      int64 `json:"rate"`
+	MinAmount int   `json:"minAmount"`
+	MaxAmount int   `json:"maxAmount"`
 }
 
 func Load(configPath string, envPath string) (*Config, error) {
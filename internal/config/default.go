@@ -15,5 +15,64 @@ var defaultConfig = map[string]interface{}{
 	"db.password": "postgres",
 	"db.port":     5432,
 
-	"ton.isTestnet": true,
+	"tg.botApiUrl": "https://api.telegram.org",
+
+	"ton.isTestnet":            true,
+	"ton.provider":             "toncenter",
+	"ton.rateLimits.toncenter": 1,
+	"ton.rateLimits.tonapi":    1,
+	"ton.rateLimits.metadata":  10,
+	"ton.retries":              3,
+
+	"events.bus":     "postgres",
+	"events.channel": "game_events",
+
+	"deposit.tonInterval": "15s",
+	"deposit.nftInterval": "15s",
+	"deposit.timeout":     "1m",
+	"deposit.maxBackoff":  "5m",
+	"deposit.healthAddr":  ":8081",
+
+	"withdraw.submitInterval":  "5s",
+	"withdraw.confirmInterval": "15s",
+	"withdraw.refundInterval":  "30s",
+	"withdraw.batchWindow":     "10s",
+	"withdraw.batchSize":       254,
+	"withdraw.timeout":         "1m",
+	"withdraw.maxBackoff":      "5m",
+	"withdraw.healthAddr":      ":8082",
+
+	"game.roundDuration":      "3m",
+	"game.minPlayers":         2,
+	"game.houseFee":           5,
+	"game.referralFee":        5,
+	"game.specReferralFee":    15,
+	"game.ticketPrice":        10_000_000,
+	"game.feeExpiry":          "72h",
+	"game.feeCollectInterval": "1m",
+	"game.leaderRetry":        "5s",
+
+	"limit.maxExclusionDays": 365,
+
+	"floor.timeout":             "2m",
+	"floor.maxDeviation":        30,
+	"floor.minSources":          1,
+	"floor.staleAfter":          "24h",
+	"floor.telegram.channelId":  2422226195,
+	"floor.telegram.accessHash": -6696550584382502344,
+	"floor.chain.lookback":      "72h",
+	"floor.chain.sales":         50,
+
+	"valuation.maxPremium": 100,
+	"valuation.rarity": []interface{}{
+		map[string]interface{}{"trait": "model", "maxRarity": 5, "premium": 50},
+		map[string]interface{}{"trait": "model", "maxRarity": 10, "premium": 20},
+		map[string]interface{}{"maxRarity": 5, "premium": 10},
+	},
+
+	"wallet.payloadTtl": "15m",
+	"wallet.proofTtl":   "15m",
+
+	"star.minAmount": 1,
+	"star.maxAmount": 10000,
 }
@@ -0,0 +1,168 @@
+// Package daemon runs periodic checks with backoff and serves their health
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Checker runs Check on Interval, failed runs are retried with exponential backoff
+type Checker struct {
+	Name       string
+	Interval   time.Duration
+	Timeout    time.Duration
+	MaxBackoff time.Duration
+	Check      func(ctx context.Context) error
+
+	mu          sync.RWMutex
+	lastSuccess *time.Time
+	lastError   *string
+	failures    int
+}
+
+type Status struct {
+	LastSuccess *time.Time `json:"lastSuccess"`
+	LastError   *string    `json:"lastError"`
+	Failures    int        `json:"failures"`
+	Healthy     bool       `json:"healthy"`
+}
+
+// Run runs checkers and the /healthz server until SIGINT or SIGTERM
+func Run(mode string, healthAddr string, checkers []*Checker) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	gin.SetMode(mode)
+	srv := newHealthServer(healthAddr, checkers)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("health server: %v", err)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.run(ctx)
+		}()
+	}
+
+	log.Printf("started...")
+
+	<-ctx.Done()
+	log.Printf("shutting down, waiting for running checks...")
+
+	wg.Wait()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shutdown health server: %v", err)
+	}
+
+	log.Printf("completed...")
+}
+
+// run stops scheduling when ctx is done, a running check is not canceled
+// so its transactions are finished within the check timeout
+func (c *Checker) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		timer.Reset(c.runOnce())
+	}
+}
+
+// runOnce returns delay before the next run
+func (c *Checker) runOnce() time.Duration {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	err := c.Check(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.failures++
+		errMsg := err.Error()
+		c.lastError = &errMsg
+
+		delay := c.backoff()
+		log.Printf("%s check failed %d times, retry in %s: %v", c.Name, c.failures, delay, err)
+		return delay
+	}
+
+	now := time.Now()
+	c.lastSuccess = &now
+	c.lastError = nil
+	c.failures = 0
+
+	return c.Interval
+}
+
+func (c *Checker) backoff() time.Duration {
+	delay := c.Interval
+	for i := 1; i < c.failures && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, c.MaxBackoff)
+}
+
+// status is healthy if the last success is recent enough for the current backoff
+func (c *Checker) status() *Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status := &Status{
+		LastSuccess: c.lastSuccess,
+		LastError:   c.lastError,
+		Failures:    c.failures,
+	}
+	if c.lastSuccess != nil {
+		status.Healthy = time.Since(*c.lastSuccess) <= c.MaxBackoff+c.Timeout+c.Interval
+	}
+
+	return status
+}
+
+func newHealthServer(addr string, checkers []*Checker) *http.Server {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	r.GET("/healthz", func(c *gin.Context) {
+		code := http.StatusOK
+		statuses := make(map[string]*Status, len(checkers))
+		for _, ch := range checkers {
+			status := ch.status()
+			if !status.Healthy {
+				code = http.StatusServiceUnavailable
+			}
+			statuses[ch.Name] = status
+		}
+
+		c.JSON(code, statuses)
+	})
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: r,
+	}
+}
@@ -25,7 +25,14 @@ func FromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
 	return db
 }
 
+// RunInTx runs f in a new tx or joins the tx already stored in ctx
 func RunInTx(ctx context.Context, db *gorm.DB, f func(ctx context.Context) error) error {
+	if ctx != nil {
+		if _, ok := ctx.Value(dbKey).(*gorm.DB); ok {
+			return f(ctx)
+		}
+	}
+
 	tx := db.Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("failed to start tx: %v", tx.Error)
@@ -36,7 +43,7 @@ func RunInTx(ctx context.Context, db *gorm.DB, f func(ctx context.Context) error
 		if err1 := tx.Rollback().Error; err1 != nil {
 			return fmt.Errorf("rollback tx: %v", err1)
 		}
-		return fmt.Errorf("invoke function: %v", err)
+		return fmt.Errorf("invoke function: %w", err)
 	}
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("failed to commit tx: %v", err)
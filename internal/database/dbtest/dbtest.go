@@ -0,0 +1,78 @@
+// Package dbtest gives tests a disposable postgres database.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"roulette/internal/database"
+	"roulette/internal/database/migrations"
+)
+
+// DSNEnv names the keyword/value dsn of a postgres server where test databases are created,
+// e.g. "host=localhost user=postgres password=postgres dbname=postgres sslmode=disable"
+const DSNEnv = "TEST_DATABASE_DSN"
+
+// Open creates an empty database dropped after the test, the test is skipped without DSNEnv
+func Open(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", DSNEnv)
+	}
+
+	server, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to test server: %v", err)
+	}
+
+	name := fmt.Sprintf("roulette_test_%d", time.Now().UnixNano())
+	if err = server.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+
+	// the later dbname keyword wins over the one of the server dsn
+	db, err := gorm.Open(postgres.Open(dsn+" dbname="+name), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		if err := server.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)").Error; err != nil {
+			t.Logf("failed to drop test database %s: %v", name, err)
+		}
+		if sqlDB, err := server.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+// OpenMigrated is Open with all migrations applied
+func OpenMigrated(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := Open(t)
+
+	all, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = database.MigrateUp(context.Background(), db, all); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	return db
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RunAsLeader runs f while the process holds the session advisory lock key, a process
+// without the lock tries again every retry. ctx of f is canceled when the lock connection is lost
+func RunAsLeader(ctx context.Context, db *gorm.DB, key int64, retry time.Duration, f func(ctx context.Context)) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err = lead(ctx, sqlDB, key, retry, f); err != nil {
+			log.Printf("leader %d: %v", key, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retry):
+		}
+	}
+}
+
+// lead returns nil without running f when the lock is held by another process
+func lead(ctx context.Context, sqlDB *sql.DB, key int64, retry time.Duration, f func(ctx context.Context)) error {
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get lock connection: %v", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to take lock: %v", err)
+	}
+	if !locked {
+		return nil
+	}
+	// the connection goes back to the pool, so the lock is released explicitly
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("leader %d: failed to release lock: %v", key, err)
+		}
+	}()
+
+	log.Printf("leader %d: acquired", key)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(retry)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			log.Printf("leader %d: released", key)
+			return nil
+		case <-ticker.C:
+			pingCtx, cancelPing := context.WithTimeout(context.Background(), retry)
+			err = conn.PingContext(pingCtx)
+			cancelPing()
+			if err != nil {
+				cancel()
+				<-done
+				return fmt.Errorf("lost lock connection: %v", err)
+			}
+		}
+	}
+}
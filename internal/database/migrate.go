@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationsLock is a pg advisory lock key, prevents concurrent migrate runs
+const migrationsLock = 7_345_001
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	*Migration
+	AppliedAt *time.Time
+}
+
+type SchemaMigrationDB struct {
+	Version   uint      `gorm:"column:version"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (SchemaMigrationDB) TableName() string {
+	return "schema_migrations"
+}
+
+// LoadMigrations reads <version>_<name>.(up|down).sql files sorted by version
+func LoadMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseUint(match[1], 10, 64)
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", entry.Name(), err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s, %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have up and down steps", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrateUp applies all pending migrations, each in its own tx
+func MigrateUp(ctx context.Context, db *gorm.DB, migrations []*Migration) ([]*Migration, error) {
+	if err := createMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	var applied []*Migration
+	for _, m := range migrations {
+		done := false
+		err := RunInTx(ctx, db, func(ctx context.Context) error {
+			tx := FromContext(ctx, db).WithContext(ctx)
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationsLock).Error; err != nil {
+				return err
+			}
+
+			var count int64
+			if err := tx.Model(&SchemaMigrationDB{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return fmt.Errorf("migration %d_%s: %v", m.Version, m.Name, err)
+			}
+			done = true
+
+			return tx.Select("version", "name").Create(&SchemaMigrationDB{Version: m.Version, Name: m.Name}).Error
+		})
+		if err != nil {
+			return applied, err
+		}
+		if done {
+			applied = append(applied, m)
+		}
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the last applied migration, returns nil if nothing is applied
+func MigrateDown(ctx context.Context, db *gorm.DB, migrations []*Migration) (*Migration, error) {
+	if err := createMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	var reverted *Migration
+	err := RunInTx(ctx, db, func(ctx context.Context) error {
+		tx := FromContext(ctx, db).WithContext(ctx)
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationsLock).Error; err != nil {
+			return err
+		}
+
+		var last SchemaMigrationDB
+		if err := tx.Order("version DESC").First(&last).Error; err != nil {
+			if IsRecordNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+
+		for _, m := range migrations {
+			if m.Version == last.Version {
+				reverted = m
+			}
+		}
+		if reverted == nil {
+			return fmt.Errorf("migration %d_%s is unknown", last.Version, last.Name)
+		}
+
+		if err := tx.Exec(reverted.Down).Error; err != nil {
+			return fmt.Errorf("migration %d_%s: %v", reverted.Version, reverted.Name, err)
+		}
+
+		return tx.Where("version = ?", reverted.Version).Delete(&SchemaMigrationDB{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// MigrationsStatus returns all known migrations with applied time if any
+func MigrationsStatus(ctx context.Context, db *gorm.DB, migrations []*Migration) ([]*MigrationStatus, error) {
+	if err := createMigrationsTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	var rows []*SchemaMigrationDB
+	if err := db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	appliedAt := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := &MigrationStatus{Migration: m}
+		if t, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &t
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func createMigrationsTable(ctx context.Context, db *gorm.DB) error {
+	err := db.WithContext(ctx).Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return nil
+}
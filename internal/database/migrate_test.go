@@ -0,0 +1,114 @@
+package database_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/database"
+	"roulette/internal/database/dbtest"
+	"roulette/internal/database/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	all, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 {
+		t.Fatal("no migrations loaded")
+	}
+
+	for i, m := range all {
+		if m.Version != uint(i+1) {
+			t.Fatalf("migration %d_%s has version %d, want %d", m.Version, m.Name, m.Version, i+1)
+		}
+	}
+}
+
+// TestMigrateUpDownUp applies all migrations, reverts them one by one and applies them again,
+// the schema must be the same both times and nothing but schema_migrations is left in between
+func TestMigrateUpDownUp(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+
+	all, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := database.MigrateUp(ctx, db, all)
+	if err != nil {
+		t.Fatalf("first up: %v", err)
+	}
+	if len(applied) != len(all) {
+		t.Fatalf("first up applied %d migrations, want %d", len(applied), len(all))
+	}
+	want := schema(t, db)
+
+	applied, err = database.MigrateUp(ctx, db, all)
+	if err != nil {
+		t.Fatalf("repeated up: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("repeated up applied %d migrations, want 0", len(applied))
+	}
+
+	for i := len(all) - 1; i >= 0; i-- {
+		reverted, err := database.MigrateDown(ctx, db, all)
+		if err != nil {
+			t.Fatalf("down: %v", err)
+		}
+		if reverted == nil || reverted.Version != all[i].Version {
+			t.Fatalf("down reverted %+v, want migration %d", reverted, all[i].Version)
+		}
+	}
+	reverted, err := database.MigrateDown(ctx, db, all)
+	if err != nil || reverted != nil {
+		t.Fatalf("down of empty schema = %+v, %v, want nothing", reverted, err)
+	}
+
+	var tables []string
+	err = db.Raw(`
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_name <> 'schema_migrations'
+	`).Scan(&tables).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) > 0 {
+		t.Fatalf("tables left after down: %v", tables)
+	}
+
+	if _, err = database.MigrateUp(ctx, db, all); err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if got := schema(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("schema after second up differs:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+// schema lists columns and indexes of the public schema
+func schema(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+
+	var rows []string
+	err := db.Raw(`
+		SELECT table_name || '.' || column_name || ' ' || data_type || ' ' || is_nullable || ' ' || COALESCE(column_default, '')
+		FROM information_schema.columns
+		WHERE table_schema = 'public'
+		UNION ALL
+		SELECT indexdef
+		FROM pg_indexes
+		WHERE schemaname = 'public'
+		ORDER BY 1
+	`).Scan(&rows).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rows
+}
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGINT PRIMARY KEY,
+    name       TEXT,
+    photo_url  TEXT,
+    balance    BIGINT      NOT NULL DEFAULT 0,
+    memo       VARCHAR(8)  NOT NULL UNIQUE,
+    is_spec    BOOLEAN,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS referrals (
+    id          BIGSERIAL PRIMARY KEY,
+    referrer_id BIGINT NOT NULL REFERENCES users (id),
+    ref_id      BIGINT NOT NULL UNIQUE REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS referrals_referrer_id_idx ON referrals (referrer_id);
+
+CREATE TABLE IF NOT EXISTS referrals_fees (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    amount     BIGINT      NOT NULL,
+    fee        BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS referrals_fees_user_id_idx ON referrals_fees (user_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS users_gifts;
+DROP TABLE IF EXISTS users_nfts;
+DROP TABLE IF EXISTS gifts;
+DROP TABLE IF EXISTS nfts;
+DROP TABLE IF EXISTS collections;
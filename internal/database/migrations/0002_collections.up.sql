@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id      BIGSERIAL PRIMARY KEY,
+    name    TEXT   NOT NULL UNIQUE,
+    address TEXT UNIQUE,
+    floor   BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS nfts (
+    id             BIGSERIAL PRIMARY KEY,
+    name           TEXT   NOT NULL,
+    collectible_id BIGINT NOT NULL,
+    address        TEXT   NOT NULL UNIQUE,
+    lottie_url     TEXT   NOT NULL DEFAULT '',
+    collection_id  BIGINT NOT NULL REFERENCES collections (id)
+);
+
+CREATE TABLE IF NOT EXISTS gifts (
+    id             BIGINT PRIMARY KEY,
+    msg_id         BIGINT NOT NULL,
+    name           TEXT   NOT NULL,
+    collectible_id BIGINT NOT NULL,
+    lottie_url     TEXT   NOT NULL DEFAULT '',
+    collection_id  BIGINT NOT NULL REFERENCES collections (id)
+);
+
+CREATE TABLE IF NOT EXISTS users_nfts (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    nft_id     BIGINT      NOT NULL UNIQUE REFERENCES nfts (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS users_nfts_user_id_idx ON users_nfts (user_id);
+
+CREATE TABLE IF NOT EXISTS users_gifts (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    gift_id    BIGINT      NOT NULL UNIQUE REFERENCES gifts (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS users_gifts_user_id_idx ON users_gifts (user_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS deposit_time;
+DROP TABLE IF EXISTS gift_deposits;
+DROP TABLE IF EXISTS nft_deposits;
+DROP TABLE IF EXISTS star_deposits;
+DROP TABLE IF EXISTS ton_deposits;
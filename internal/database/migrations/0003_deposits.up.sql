@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS ton_deposits (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    amount     BIGINT      NOT NULL,
+    payload    TEXT,
+    msg_hash   TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS star_deposits (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    amount     BIGINT      NOT NULL,
+    payload    TEXT,
+    payment_id TEXT UNIQUE,
+    paid_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS nft_deposits (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT      NOT NULL REFERENCES users (id),
+    sender       TEXT        NOT NULL,
+    nft_address  TEXT        NOT NULL,
+    trace_id     TEXT UNIQUE,
+    is_confirmed BOOLEAN,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- only one pending deposit per nft
+CREATE UNIQUE INDEX IF NOT EXISTS nft_deposits_pending_idx ON nft_deposits (nft_address) WHERE is_confirmed IS NULL;
+
+CREATE TABLE IF NOT EXISTS gift_deposits (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    gift_id    BIGINT      NOT NULL,
+    msg_id     TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS deposit_time (
+    id    INTEGER PRIMARY KEY,
+    start TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
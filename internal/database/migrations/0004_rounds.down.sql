@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS rounds_winners;
+DROP TABLE IF EXISTS rounds_gifts;
+DROP TABLE IF EXISTS rounds_nfts;
+DROP TABLE IF EXISTS rounds_tickets;
+DROP TABLE IF EXISTS rounds;
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS rounds (
+    id         BIGSERIAL PRIMARY KEY,
+    round      TEXT        NOT NULL DEFAULT '',
+    secret     TEXT        NOT NULL,
+    hash       TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS rounds_created_at_idx ON rounds (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS rounds_tickets (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    round_id   BIGINT      NOT NULL REFERENCES rounds (id),
+    tickets    INTEGER     NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS rounds_tickets_round_id_idx ON rounds_tickets (round_id);
+CREATE INDEX IF NOT EXISTS rounds_tickets_user_id_idx ON rounds_tickets (user_id);
+
+-- a bet outlives the user item, the item leaves a settled round when it is withdrawn
+CREATE TABLE IF NOT EXISTS rounds_nfts (
+    id          BIGSERIAL PRIMARY KEY,
+    round_id    BIGINT NOT NULL REFERENCES rounds (id),
+    user_nft_id BIGINT REFERENCES users_nfts (id) ON DELETE SET NULL,
+    bet         BIGINT NOT NULL,
+    UNIQUE (round_id, user_nft_id)
+);
+
+CREATE TABLE IF NOT EXISTS rounds_gifts (
+    id           BIGSERIAL PRIMARY KEY,
+    round_id     BIGINT NOT NULL REFERENCES rounds (id),
+    user_gift_id BIGINT REFERENCES users_gifts (id) ON DELETE SET NULL,
+    bet          BIGINT NOT NULL,
+    UNIQUE (round_id, user_gift_id)
+);
+
+CREATE TABLE IF NOT EXISTS rounds_winners (
+    id       BIGSERIAL PRIMARY KEY,
+    round_id BIGINT  NOT NULL UNIQUE REFERENCES rounds (id),
+    ticket   INTEGER NOT NULL,
+    user_id  BIGINT  NOT NULL REFERENCES users (id),
+    is_paid  BOOLEAN,
+    fee      BIGINT  NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS rounds_winners_user_id_idx ON rounds_winners (user_id);
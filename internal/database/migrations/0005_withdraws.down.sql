@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS gift_withdraws;
+DROP TABLE IF EXISTS nft_withdraws;
+DROP TABLE IF EXISTS ton_withdraws;
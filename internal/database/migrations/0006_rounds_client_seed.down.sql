@@ -0,0 +1 @@
+ALTER TABLE rounds_tickets DROP COLUMN IF EXISTS client_seed;
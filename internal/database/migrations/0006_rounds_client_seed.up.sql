@@ -0,0 +1 @@
+ALTER TABLE rounds_tickets ADD COLUMN IF NOT EXISTS client_seed VARCHAR(64);
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id              BIGSERIAL PRIMARY KEY,
+    idempotency_key TEXT        NOT NULL,
+    kind            TEXT        NOT NULL,
+    account         TEXT        NOT NULL,
+    user_id         BIGINT REFERENCES users (id),
+    amount          BIGINT      NOT NULL,
+    comment         TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (idempotency_key, account)
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_user_id_idx ON ledger_entries (user_id);
+
+-- entries are append-only
+CREATE OR REPLACE FUNCTION ledger_entries_immutable() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_immutable
+    BEFORE UPDATE OR DELETE
+    ON ledger_entries
+    FOR EACH ROW
+EXECUTE FUNCTION ledger_entries_immutable();
+
+ALTER TABLE users ADD CONSTRAINT users_balance_check CHECK (balance >= 0) NOT VALID;
+
+-- opening balances, so that users.balance equals the sum of user entries
+INSERT INTO ledger_entries (idempotency_key, kind, account, user_id, amount, comment)
+SELECT 'opening:' || id, 'admin_adjust', 'house', NULL, -balance, 'opening balance'
+FROM users
+WHERE balance <> 0;
+
+INSERT INTO ledger_entries (idempotency_key, kind, account, user_id, amount, comment)
+SELECT 'opening:' || id, 'admin_adjust', 'user:' || id, id, balance, 'opening balance'
+FROM users
+WHERE balance <> 0;
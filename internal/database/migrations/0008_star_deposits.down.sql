@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS star_deposits_payload_idx;
+
+ALTER TABLE star_deposits DROP COLUMN IF EXISTS refund_attempts;
+ALTER TABLE star_deposits DROP COLUMN IF EXISTS refunding_at;
+ALTER TABLE star_deposits DROP COLUMN IF EXISTS refunded_at;
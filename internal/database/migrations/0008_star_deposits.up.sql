@@ -0,0 +1,9 @@
+ALTER TABLE star_deposits ADD COLUMN IF NOT EXISTS refunded_at TIMESTAMPTZ;
+
+-- refunding_at is set with the balance debit before the refund is sent to telegram and cleared when
+-- the debit is reversed, refund_attempts keys the debit and the reversal of each attempt
+ALTER TABLE star_deposits ADD COLUMN IF NOT EXISTS refunding_at TIMESTAMPTZ;
+ALTER TABLE star_deposits ADD COLUMN IF NOT EXISTS refund_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- invoice payload identifies the deposit in pre_checkout_query and successful_payment
+CREATE UNIQUE INDEX IF NOT EXISTS star_deposits_payload_idx ON star_deposits (payload);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS gift_deposits_pending_idx;
+
+DELETE FROM gift_deposits WHERE user_id IS NULL;
+
+ALTER TABLE gift_deposits DROP COLUMN IF EXISTS sender_id;
+ALTER TABLE gift_deposits ALTER COLUMN user_id SET NOT NULL;
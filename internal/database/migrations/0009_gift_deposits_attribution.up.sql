@@ -0,0 +1,5 @@
+-- user_id is NULL while the sender is pending attribution
+ALTER TABLE gift_deposits ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE gift_deposits ADD COLUMN IF NOT EXISTS sender_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS gift_deposits_pending_idx ON gift_deposits (id) WHERE user_id IS NULL;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS deposit_time (
+    id    INTEGER PRIMARY KEY,
+    start TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS deposit_cursors;
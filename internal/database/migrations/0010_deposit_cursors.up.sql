@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS deposit_cursors (
+    id         TEXT PRIMARY KEY,
+    lt         BIGINT      NOT NULL,
+    hash       TEXT        NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS deposit_time;
@@ -0,0 +1,26 @@
+-- ton_withdraws, nft_withdraws and gift_withdraws are kept as history of synchronous withdraws
+CREATE TABLE IF NOT EXISTS withdrawals (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT      NOT NULL REFERENCES users (id),
+    type         TEXT        NOT NULL,
+    status       TEXT        NOT NULL,
+    destination  TEXT,
+    amount       BIGINT      NOT NULL DEFAULT 0,
+    fee          BIGINT      NOT NULL DEFAULT 0,
+    nft_id       BIGINT,
+    nft_address  TEXT,
+    gift_id      BIGINT,
+    gift_msg_id  BIGINT,
+    query_id     BIGINT,
+    msg_hash     TEXT UNIQUE,
+    expire_at    TIMESTAMPTZ,
+    error        TEXT,
+    submitted_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS withdrawals_user_id_idx ON withdrawals (user_id);
+CREATE INDEX IF NOT EXISTS withdrawals_active_idx ON withdrawals (status, id)
+    WHERE status IN ('debited', 'submitted', 'failed');
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS withdrawals_batch_id_idx;
+
+ALTER TABLE withdrawals DROP COLUMN IF EXISTS batch_id;
+
+DROP TABLE IF EXISTS withdrawal_batches;
@@ -0,0 +1,19 @@
+-- a batch is one wallet message, its id is the highload query id
+CREATE TABLE IF NOT EXISTS withdrawal_batches (
+    id         BIGSERIAL PRIMARY KEY,
+    status     TEXT        NOT NULL,
+    query_id   BIGINT,
+    msg_hash   TEXT UNIQUE,
+    expire_at  TIMESTAMPTZ,
+    tx_hash    TEXT,
+    tx_lt      BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS withdrawal_batches_submitted_idx ON withdrawal_batches (id) WHERE status = 'submitted';
+
+ALTER TABLE withdrawals ADD COLUMN IF NOT EXISTS batch_id BIGINT REFERENCES withdrawal_batches (id);
+ALTER TABLE withdrawals DROP CONSTRAINT IF EXISTS withdrawals_msg_hash_key;
+
+CREATE INDEX IF NOT EXISTS withdrawals_batch_id_idx ON withdrawals (batch_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS pauses;
+
+DROP INDEX IF EXISTS admin_audit_log_actor_idx;
+
+DROP TABLE IF EXISTS admin_audit_log;
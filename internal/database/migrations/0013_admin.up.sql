@@ -0,0 +1,20 @@
+-- every admin api change, params hold the request
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    actor      TEXT        NOT NULL,
+    role       TEXT        NOT NULL,
+    action     TEXT        NOT NULL,
+    target     TEXT,
+    params     JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_log_actor_idx ON admin_audit_log (actor, id);
+
+-- a row pauses the feature until it is deleted
+CREATE TABLE IF NOT EXISTS pauses (
+    feature    TEXT PRIMARY KEY,
+    reason     TEXT,
+    paused_by  TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
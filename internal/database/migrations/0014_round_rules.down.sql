@@ -0,0 +1,9 @@
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_rules_check;
+
+ALTER TABLE rounds
+    DROP COLUMN IF EXISTS duration_secs,
+    DROP COLUMN IF EXISTS min_players,
+    DROP COLUMN IF EXISTS house_fee,
+    DROP COLUMN IF EXISTS referral_fee,
+    DROP COLUMN IF EXISTS spec_referral_fee,
+    DROP COLUMN IF EXISTS ticket_price;
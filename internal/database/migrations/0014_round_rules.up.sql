@@ -0,0 +1,14 @@
+-- game rules are copied to each round when it is created, defaults are the rules of past rounds
+ALTER TABLE rounds
+    ADD COLUMN IF NOT EXISTS duration_secs     INTEGER NOT NULL DEFAULT 180,
+    ADD COLUMN IF NOT EXISTS min_players       INTEGER NOT NULL DEFAULT 2,
+    ADD COLUMN IF NOT EXISTS house_fee         INTEGER NOT NULL DEFAULT 5,
+    ADD COLUMN IF NOT EXISTS referral_fee      INTEGER NOT NULL DEFAULT 5,
+    ADD COLUMN IF NOT EXISTS spec_referral_fee INTEGER NOT NULL DEFAULT 15,
+    ADD COLUMN IF NOT EXISTS ticket_price      BIGINT  NOT NULL DEFAULT 100000000;
+
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_rules_check;
+ALTER TABLE rounds ADD CONSTRAINT rounds_rules_check CHECK (
+    duration_secs > 0 AND min_players > 0 AND ticket_price > 0 AND
+    house_fee BETWEEN 0 AND 100 AND referral_fee BETWEEN 0 AND 100 AND spec_referral_fee BETWEEN 0 AND 100
+);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS rounds_room_created_at_idx;
+
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_bets_check;
+
+ALTER TABLE rounds
+    DROP COLUMN IF EXISTS room,
+    DROP COLUMN IF EXISTS min_bet,
+    DROP COLUMN IF EXISTS max_bet,
+    DROP COLUMN IF EXISTS gifts_only;
@@ -0,0 +1,13 @@
+-- rooms are defined in config, each round belongs to one room and keeps its bet limits
+ALTER TABLE rounds
+    ADD COLUMN IF NOT EXISTS room       TEXT    NOT NULL DEFAULT 'main',
+    ADD COLUMN IF NOT EXISTS min_bet    BIGINT  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_bet    BIGINT  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS gifts_only BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_bets_check;
+ALTER TABLE rounds ADD CONSTRAINT rounds_bets_check CHECK (
+    min_bet >= 0 AND max_bet >= 0 AND (max_bet = 0 OR max_bet >= min_bet)
+);
+
+CREATE INDEX IF NOT EXISTS rounds_room_created_at_idx ON rounds (room, created_at DESC);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS rounds_ton_bets;
@@ -0,0 +1,11 @@
+-- ton bets are staked from users.balance, the stake sits on the pot:<round id> ledger account until the winner is paid
+CREATE TABLE IF NOT EXISTS rounds_ton_bets (
+    id         BIGSERIAL PRIMARY KEY,
+    round_id   BIGINT      NOT NULL REFERENCES rounds (id),
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    amount     BIGINT      NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS rounds_ton_bets_round_id_idx ON rounds_ton_bets (round_id);
+CREATE INDEX IF NOT EXISTS rounds_ton_bets_user_id_idx ON rounds_ton_bets (user_id);
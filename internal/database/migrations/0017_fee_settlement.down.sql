@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS rounds_winners_pending_idx;
+
+ALTER TABLE rounds_winners
+    DROP COLUMN IF EXISTS settlement,
+    DROP COLUMN IF EXISTS fee_due_at,
+    DROP COLUMN IF EXISTS settled_at;
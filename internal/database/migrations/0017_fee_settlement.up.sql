@@ -0,0 +1,15 @@
+-- winner fees are settled at payout, a fee short of balance stays pending until fee_due_at
+ALTER TABLE rounds_winners
+    ADD COLUMN IF NOT EXISTS settlement TEXT,
+    ADD COLUMN IF NOT EXISTS fee_due_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS settled_at TIMESTAMPTZ;
+
+UPDATE rounds_winners
+SET fee_due_at = CURRENT_TIMESTAMP + INTERVAL '3 days'
+WHERE is_paid IS NULL;
+
+UPDATE rounds_winners
+SET settlement = 'balance'
+WHERE is_paid;
+
+CREATE INDEX IF NOT EXISTS rounds_winners_pending_idx ON rounds_winners (fee_due_at) WHERE is_paid IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS rounds_unsettled_idx;
+
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_status_check;
+
+ALTER TABLE rounds DROP COLUMN IF EXISTS status;
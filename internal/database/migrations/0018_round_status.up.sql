@@ -0,0 +1,21 @@
+-- rounds go open -> countdown -> drawing -> settled, only cmd/game moves them under a row lock
+ALTER TABLE rounds ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'open';
+
+UPDATE rounds r
+SET status = CASE
+    WHEN EXISTS (SELECT 1 FROM rounds_winners rw WHERE rw.round_id = r.id) THEN 'settled'
+    WHEN r.started_at IS NOT NULL THEN 'countdown'
+    ELSE 'open'
+END;
+
+-- rounds left behind by the old loop are superseded by the newest round of their room
+UPDATE rounds r
+SET status = 'settled'
+WHERE status <> 'settled' AND EXISTS (
+    SELECT 1 FROM rounds n WHERE n.room = r.room AND n.created_at > r.created_at
+);
+
+ALTER TABLE rounds DROP CONSTRAINT IF EXISTS rounds_status_check;
+ALTER TABLE rounds ADD CONSTRAINT rounds_status_check CHECK (status IN ('open', 'countdown', 'drawing', 'settled'));
+
+CREATE INDEX IF NOT EXISTS rounds_unsettled_idx ON rounds (room) WHERE status <> 'settled';
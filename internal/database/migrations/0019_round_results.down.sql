@@ -0,0 +1,5 @@
+DROP FUNCTION IF EXISTS stats_period_start(TEXT, TIMESTAMPTZ);
+
+DROP TABLE IF EXISTS players_stats;
+
+DROP TABLE IF EXISTS rounds_results;
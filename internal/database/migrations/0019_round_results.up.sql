@@ -0,0 +1,69 @@
+-- rounds_results keeps what every player bet and won in a settled round, written before the items change owner
+CREATE TABLE IF NOT EXISTS rounds_results (
+    round_id   BIGINT      NOT NULL REFERENCES rounds (id),
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    bet        BIGINT      NOT NULL,
+    tickets    INTEGER     NOT NULL,
+    is_winner  BOOLEAN     NOT NULL DEFAULT false,
+    won        BIGINT      NOT NULL DEFAULT 0,
+    settled_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (round_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS rounds_results_user_id_idx ON rounds_results (user_id, round_id DESC);
+
+-- players_stats aggregates rounds_results per utc day, week and all time, the leaderboard reads only this table
+CREATE TABLE IF NOT EXISTS players_stats (
+    period       TEXT    NOT NULL CHECK (period IN ('day', 'week', 'all')),
+    period_start DATE    NOT NULL,
+    user_id      BIGINT  NOT NULL REFERENCES users (id),
+    volume       BIGINT  NOT NULL DEFAULT 0,
+    rounds       INTEGER NOT NULL DEFAULT 0,
+    wins         INTEGER NOT NULL DEFAULT 0,
+    won          BIGINT  NOT NULL DEFAULT 0,
+    biggest_win  BIGINT  NOT NULL DEFAULT 0,
+    PRIMARY KEY (period, period_start, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS players_stats_volume_idx ON players_stats (period, period_start, volume DESC);
+CREATE INDEX IF NOT EXISTS players_stats_wins_idx ON players_stats (period, period_start, wins DESC);
+CREATE INDEX IF NOT EXISTS players_stats_biggest_win_idx ON players_stats (period, period_start, biggest_win DESC);
+
+CREATE OR REPLACE FUNCTION stats_period_start(period TEXT, at TIMESTAMPTZ) RETURNS DATE AS $$
+    SELECT CASE period
+        WHEN 'day' THEN date_trunc('day', at AT TIME ZONE 'UTC')::DATE
+        WHEN 'week' THEN date_trunc('week', at AT TIME ZONE 'UTC')::DATE
+        ELSE DATE '1970-01-01'
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- items of past rounds already changed owner, so their bets are backfilled from the tickets
+INSERT INTO rounds_results (round_id, user_id, bet, tickets, is_winner, won, settled_at)
+SELECT rt.round_id, rt.user_id, SUM(rt.tickets) * r.ticket_price, SUM(rt.tickets),
+       rw.user_id = rt.user_id,
+       CASE WHEN rw.user_id = rt.user_id THEN pot.total ELSE 0 END,
+       COALESCE(r.started_at + r.duration_secs * INTERVAL '1 second', r.created_at)
+FROM rounds_tickets rt
+    JOIN rounds r ON r.id = rt.round_id
+    JOIN rounds_winners rw ON rw.round_id = r.id
+    CROSS JOIN LATERAL (
+        SELECT COALESCE(SUM(bet), 0) AS total
+        FROM (
+            SELECT bet FROM rounds_nfts WHERE round_id = r.id
+            UNION ALL
+            SELECT bet FROM rounds_gifts WHERE round_id = r.id
+            UNION ALL
+            SELECT amount AS bet FROM rounds_ton_bets WHERE round_id = r.id
+        ) AS bets
+    ) AS pot
+WHERE r.status = 'settled'
+GROUP BY rt.round_id, rt.user_id, r.ticket_price, rw.user_id, pot.total, r.started_at, r.duration_secs, r.created_at
+ON CONFLICT DO NOTHING;
+
+INSERT INTO players_stats (period, period_start, user_id, volume, rounds, wins, won, biggest_win)
+SELECT p.period, stats_period_start(p.period, rr.settled_at), rr.user_id,
+       SUM(rr.bet), COUNT(*), COUNT(*) FILTER (WHERE rr.is_winner), SUM(rr.won), MAX(rr.won)
+FROM rounds_results rr
+    CROSS JOIN (VALUES ('day'), ('week'), ('all')) AS p (period)
+GROUP BY p.period, stats_period_start(p.period, rr.settled_at), rr.user_id
+ON CONFLICT DO NOTHING;
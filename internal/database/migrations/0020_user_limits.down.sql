@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS rounds_tickets_user_id_created_at_idx;
+
+ALTER TABLE rounds_tickets DROP COLUMN IF EXISTS bet;
+
+DROP TABLE IF EXISTS users_limits;
@@ -0,0 +1,21 @@
+-- users_limits overrides the configured bet limits of a user, NULL keeps the default and 0 is unbounded
+CREATE TABLE IF NOT EXISTS users_limits (
+    user_id        BIGINT PRIMARY KEY REFERENCES users (id),
+    round_bets     INTEGER CHECK (round_bets >= 0),
+    round_value    BIGINT CHECK (round_value >= 0),
+    daily_bets     INTEGER CHECK (daily_bets >= 0),
+    daily_value    BIGINT CHECK (daily_value >= 0),
+    excluded_until TIMESTAMPTZ,
+    updated_by     TEXT,
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- bet is the value of the single bet, usage against the limits is summed from it
+ALTER TABLE rounds_tickets ADD COLUMN IF NOT EXISTS bet BIGINT NOT NULL DEFAULT 0;
+
+UPDATE rounds_tickets rt
+SET bet = rt.tickets * r.ticket_price
+FROM rounds r
+WHERE r.id = rt.round_id;
+
+CREATE INDEX IF NOT EXISTS rounds_tickets_user_id_created_at_idx ON rounds_tickets (user_id, created_at DESC);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS collection_floor_history;
+
+ALTER TABLE collections
+    DROP COLUMN IF EXISTS floor_updated_at,
+    DROP COLUMN IF EXISTS is_stale;
@@ -0,0 +1,20 @@
+-- floors are set by the floor oracle, a collection it can not price for too long is stale and takes no bets
+ALTER TABLE collections
+    ADD COLUMN IF NOT EXISTS floor_updated_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS is_stale BOOLEAN NOT NULL DEFAULT false;
+
+-- floors written so far may be the old hardcoded default, they are trusted until the first oracle run
+UPDATE collections
+SET is_stale = floor IS NULL,
+    floor_updated_at = CASE WHEN floor IS NOT NULL THEN CURRENT_TIMESTAMP END;
+
+-- every oracle run of a collection, quotes hold the source floors and whether they were rejected
+CREATE TABLE IF NOT EXISTS collection_floor_history (
+    id            BIGSERIAL PRIMARY KEY,
+    collection_id BIGINT      NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    floor         BIGINT,
+    quotes        JSONB       NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS collection_floor_history_collection_id_idx ON collection_floor_history (collection_id, created_at DESC);
@@ -0,0 +1,13 @@
+ALTER TABLE rounds_gifts
+    DROP COLUMN IF EXISTS gift_id,
+    DROP COLUMN IF EXISTS valuation,
+    DROP COLUMN IF EXISTS user_id;
+ALTER TABLE rounds_nfts
+    DROP COLUMN IF EXISTS nft_id,
+    DROP COLUMN IF EXISTS valuation,
+    DROP COLUMN IF EXISTS user_id;
+
+DROP TABLE IF EXISTS collections_premiums;
+
+ALTER TABLE gifts DROP COLUMN IF EXISTS attributes;
+ALTER TABLE nfts DROP COLUMN IF EXISTS attributes;
@@ -0,0 +1,50 @@
+-- attributes are the traits of the item as [{trait, value, rarity}], rarity is permille of the collection
+-- when the source tells it. NULL is unknown, items deposited before are valued at the floor
+ALTER TABLE nfts ADD COLUMN IF NOT EXISTS attributes JSONB;
+ALTER TABLE gifts ADD COLUMN IF NOT EXISTS attributes JSONB;
+
+-- collections_premiums adds a percent of the floor to items having the trait value
+CREATE TABLE IF NOT EXISTS collections_premiums (
+    collection_id BIGINT      NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    trait         TEXT        NOT NULL,
+    value         TEXT        NOT NULL,
+    premium       BIGINT      NOT NULL CHECK (premium > 0),
+    updated_by    TEXT,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (collection_id, trait, value)
+);
+
+-- valuation is the breakdown of the bet and its tickets, NULL for bets valued at the floor before.
+-- user_id is the bettor, the item owner changes to the winner. It is unknown for settled rounds before.
+-- nft_id and gift_id keep the bet item after the user item is withdrawn
+ALTER TABLE rounds_nfts
+    ADD COLUMN IF NOT EXISTS valuation JSONB,
+    ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id),
+    ADD COLUMN IF NOT EXISTS nft_id BIGINT REFERENCES nfts (id);
+ALTER TABLE rounds_gifts
+    ADD COLUMN IF NOT EXISTS valuation JSONB,
+    ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users (id),
+    ADD COLUMN IF NOT EXISTS gift_id BIGINT REFERENCES gifts (id);
+
+UPDATE rounds_nfts rn
+SET nft_id = un.nft_id
+FROM users_nfts un
+WHERE un.id = rn.user_nft_id;
+
+UPDATE rounds_gifts rg
+SET gift_id = ug.gift_id
+FROM users_gifts ug
+WHERE ug.id = rg.user_gift_id;
+
+ALTER TABLE rounds_nfts ALTER COLUMN nft_id SET NOT NULL;
+ALTER TABLE rounds_gifts ALTER COLUMN gift_id SET NOT NULL;
+
+UPDATE rounds_nfts rn
+SET user_id = un.user_id
+FROM users_nfts un, rounds r
+WHERE un.id = rn.user_nft_id AND r.id = rn.round_id AND r.status <> 'settled';
+
+UPDATE rounds_gifts rg
+SET user_id = ug.user_id
+FROM users_gifts ug, rounds r
+WHERE ug.id = rg.user_gift_id AND r.id = rg.round_id AND r.status <> 'settled';
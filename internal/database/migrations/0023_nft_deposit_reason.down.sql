@@ -0,0 +1 @@
+ALTER TABLE nft_deposits DROP COLUMN IF EXISTS reason;
@@ -0,0 +1,2 @@
+-- reason tells why the deposit was rejected, by the on-chain check or by an operator
+ALTER TABLE nft_deposits ADD COLUMN IF NOT EXISTS reason TEXT;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_payloads;
+DROP TABLE IF EXISTS user_wallets;
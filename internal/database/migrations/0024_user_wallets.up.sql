@@ -0,0 +1,17 @@
+-- user_wallets are wallets proven by TON Connect ton_proof, address is raw and linked to one user
+CREATE TABLE IF NOT EXISTS user_wallets (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    address    TEXT        NOT NULL UNIQUE,
+    public_key TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS user_wallets_user_idx ON user_wallets (user_id);
+
+-- wallet_payloads are issued for ton_proof, a payload is deleted once a proof is checked against it
+CREATE TABLE IF NOT EXISTS wallet_payloads (
+    payload    TEXT PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id),
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS holds versioned migrations named <version>_<name>.(up|down).sql
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type AuditLogDB struct {
+	ID        uint      `gorm:"column:id"`
+	Actor     string    `gorm:"column:actor"`
+	Role      string    `gorm:"column:role"`
+	Action    string    `gorm:"column:action"`
+	Target    *string   `gorm:"column:target"`
+	Params    *string   `gorm:"column:params"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (AuditLogDB) TableName() string {
+	return "admin_audit_log"
+}
+
+type Feature string
+
+const (
+	FeatureBetting     Feature = "betting"
+	FeatureWithdrawals Feature = "withdrawals"
+)
+
+type PauseDB struct {
+	Feature   Feature   `gorm:"column:feature"`
+	Reason    *string   `gorm:"column:reason"`
+	PausedBy  string    `gorm:"column:paused_by"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (PauseDB) TableName() string {
+	return "pauses"
+}
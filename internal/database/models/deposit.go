@@ -4,10 +4,12 @@ import (
 	"time"
 )
 
-type DepositTime uint
+type DepositCursor string
 
 const (
-	TonTime DepositTime = 1
+	TonCursor DepositCursor = "ton"
+	// NftCursor is (lt, trace id) of the last scanned nft transfer
+	NftCursor DepositCursor = "nft"
 )
 
 type TonDepositDB struct {
@@ -24,13 +26,17 @@ func (TonDepositDB) TableName() string {
 }
 
 type StarDepositDB struct {
-	ID        uint      `gorm:"column:id"`
-	UserID    uint      `gorm:"column:user_id"`
-	Amount    uint      `gorm:"column:amount"`
-	Payload   *string   `gorm:"column:payload"`
-	PaymentID string    `gorm:"column:payment_id"`
-	PaidAt    time.Time `gorm:"column:paid_at"`
-	CreatedAt time.Time `gorm:"column:created_at"`
+	ID         uint       `gorm:"column:id"`
+	UserID     uint       `gorm:"column:user_id"`
+	Amount     uint       `gorm:"column:amount"`
+	Payload    *string    `gorm:"column:payload"`
+	PaymentID  *string    `gorm:"column:payment_id"`
+	PaidAt     *time.Time `gorm:"column:paid_at"`
+	RefundedAt *time.Time `gorm:"column:refunded_at"`
+	// RefundingAt is set while the refund is sent to telegram after the balance is debited
+	RefundingAt    *time.Time `gorm:"column:refunding_at"`
+	RefundAttempts int        `gorm:"column:refund_attempts"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
 }
 
 func (StarDepositDB) TableName() string {
@@ -44,6 +50,7 @@ type NftDepositDB struct {
 	NftAddress  string    `gorm:"column:nft_address"`
 	TraceID     *string   `gorm:"column:trace_id"`
 	IsConfirmed *bool     `gorm:"column:is_confirmed"`
+	Reason      *string   `gorm:"column:reason"`
 	CreatedAt   time.Time `gorm:"column:created_at"`
 }
 
@@ -51,10 +58,12 @@ func (NftDepositDB) TableName() string {
 	return "nft_deposits"
 }
 
+// GiftDepositDB has nil UserID while the sender is not attributed to a user
 type GiftDepositDB struct {
 	ID        uint      `gorm:"column:id"`
-	UserID    uint      `gorm:"column:user_id"`
-	GiftID    uint      `gorm:"column:gift_id"`
+	UserID    *uint     `gorm:"column:user_id"`
+	SenderID  *int64    `gorm:"column:sender_id"`
+	GiftID    int64     `gorm:"column:gift_id"`
 	MsgID     string    `gorm:"column:msg_id"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 }
@@ -63,11 +72,14 @@ func (GiftDepositDB) TableName() string {
 	return "gift_deposits"
 }
 
-type DepositTimeDB struct {
-	ID    uint      `gorm:"column:id"`
-	Start time.Time `gorm:"column:start"`
+// DepositCursorDB is (lt, hash) of the last processed message
+type DepositCursorDB struct {
+	ID        DepositCursor `gorm:"column:id"`
+	Lt        int64         `gorm:"column:lt"`
+	Hash      string        `gorm:"column:hash"`
+	UpdatedAt time.Time     `gorm:"column:updated_at"`
 }
 
-func (DepositTimeDB) TableName() string {
-	return "deposit_time"
+func (DepositCursorDB) TableName() string {
+	return "deposit_cursors"
 }
@@ -2,13 +2,38 @@ package models
 
 import "time"
 
+// RoundStatus moves open -> countdown -> drawing -> settled
+type RoundStatus string
+
+const (
+	// RoundOpen takes bets and waits for min players
+	RoundOpen RoundStatus = "open"
+	// RoundCountdown takes bets until the round duration passes
+	RoundCountdown RoundStatus = "countdown"
+	// RoundDrawing takes no bets, the winner is being paid
+	RoundDrawing RoundStatus = "drawing"
+	RoundSettled RoundStatus = "settled"
+)
+
+// RoundDB keeps the game rules the round was created with
 type RoundDB struct {
-	ID          uint      `gorm:"column:id"`
-	RoundNumber string    `gorm:"column:round"`
-	Secret      string    `gorm:"column:secret"`
-	Hash        string    `gorm:"column:hash"`
-	CreatedAt   time.Time `gorm:"column:created_at"`
-	StartedAt   time.Time `gorm:"column:started_at"`
+	ID              uint        `gorm:"column:id"`
+	RoundNumber     string      `gorm:"column:round"`
+	Secret          string      `gorm:"column:secret"`
+	Hash            string      `gorm:"column:hash"`
+	Room            string      `gorm:"column:room"`
+	Status          RoundStatus `gorm:"column:status"`
+	DurationSecs    int         `gorm:"column:duration_secs"`
+	MinPlayers      int         `gorm:"column:min_players"`
+	HouseFee        int64       `gorm:"column:house_fee"`
+	ReferralFee     int64       `gorm:"column:referral_fee"`
+	SpecReferralFee int64       `gorm:"column:spec_referral_fee"`
+	TicketPrice     int64       `gorm:"column:ticket_price"`
+	MinBet          int64       `gorm:"column:min_bet"`
+	MaxBet          int64       `gorm:"column:max_bet"`
+	GiftsOnly       bool        `gorm:"column:gifts_only"`
+	CreatedAt       time.Time   `gorm:"column:created_at"`
+	StartedAt       time.Time   `gorm:"column:started_at"`
 }
 
 func (RoundDB) TableName() string {
@@ -16,11 +41,13 @@ func (RoundDB) TableName() string {
 }
 
 type RoundTicketDB struct {
-	ID        uint      `gorm:"column:id"`
-	UserID    uint      `gorm:"column:user_id"`
-	RoundID   uint      `gorm:"column:round_id"`
-	Tickets   int       `gorm:"column:tickets"`
-	CreatedAt time.Time `gorm:"column:created_at"`
+	ID         uint      `gorm:"column:id"`
+	UserID     uint      `gorm:"column:user_id"`
+	RoundID    uint      `gorm:"column:round_id"`
+	Tickets    int       `gorm:"column:tickets"`
+	Bet        int64     `gorm:"column:bet"`
+	ClientSeed *string   `gorm:"column:client_seed"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
 }
 
 func (RoundTicketDB) TableName() string {
@@ -28,10 +55,15 @@ func (RoundTicketDB) TableName() string {
 }
 
 type RoundNftDB struct {
-	ID        uint  `gorm:"column:id"`
-	RoundID   uint  `gorm:"column:round_id"`
-	UserNftID uint  `gorm:"column:user_nft_id"`
+	ID      uint `gorm:"column:id"`
+	RoundID uint `gorm:"column:round_id"`
+	// UserNftID is nil once the item is withdrawn, NftID keeps the bet item
+	UserNftID *uint `gorm:"column:user_nft_id"`
+	NftID     uint  `gorm:"column:nft_id"`
+	UserID    uint  `gorm:"column:user_id"`
 	Bet       int64 `gorm:"column:bet"`
+	// Valuation is json of the valuation model, nil for bets valued before it
+	Valuation *string `gorm:"column:valuation"`
 }
 
 func (RoundNftDB) TableName() string {
@@ -39,23 +71,53 @@ func (RoundNftDB) TableName() string {
 }
 
 type RoundGiftDB struct {
-	ID         uint  `gorm:"column:id"`
-	RoundID    uint  `gorm:"column:round_id"`
-	UserGiftID uint  `gorm:"column:user_gift_id"`
+	ID      uint `gorm:"column:id"`
+	RoundID uint `gorm:"column:round_id"`
+	// UserGiftID is nil once the item is withdrawn, GiftID keeps the bet item
+	UserGiftID *uint `gorm:"column:user_gift_id"`
+	GiftID     int64 `gorm:"column:gift_id"`
+	UserID     uint  `gorm:"column:user_id"`
 	Bet        int64 `gorm:"column:bet"`
+	// Valuation is json of the valuation model, nil for bets valued before it
+	Valuation *string `gorm:"column:valuation"`
 }
 
 func (RoundGiftDB) TableName() string {
 	return "rounds_gifts"
 }
 
+type RoundTonBetDB struct {
+	ID        uint      `gorm:"column:id"`
+	RoundID   uint      `gorm:"column:round_id"`
+	UserID    uint      `gorm:"column:user_id"`
+	Amount    int64     `gorm:"column:amount"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (RoundTonBetDB) TableName() string {
+	return "rounds_ton_bets"
+}
+
+// FeeSettlement is how the fee of a winner was settled
+type FeeSettlement string
+
+const (
+	FeeSettlementNone    FeeSettlement = "none"
+	FeeSettlementBalance FeeSettlement = "balance"
+	FeeSettlementItem    FeeSettlement = "item"
+	FeeSettlementWaived  FeeSettlement = "waived"
+)
+
 type RoundWinnerDB struct {
-	ID      uint  `gorm:"column:id"`
-	RoundID uint  `gorm:"column:round_id"`
-	Ticket  int   `gorm:"column:ticket"`
-	UserID  uint  `gorm:"column:user_id"`
-	IsPaid  *bool `gorm:"column:is_paid"`
-	Fee     int64 `gorm:"column:fee"`
+	ID         uint           `gorm:"column:id"`
+	RoundID    uint           `gorm:"column:round_id"`
+	Ticket     int            `gorm:"column:ticket"`
+	UserID     uint           `gorm:"column:user_id"`
+	IsPaid     *bool          `gorm:"column:is_paid"`
+	Fee        int64          `gorm:"column:fee"`
+	Settlement *FeeSettlement `gorm:"column:settlement"`
+	FeeDueAt   *time.Time     `gorm:"column:fee_due_at"`
+	SettledAt  *time.Time     `gorm:"column:settled_at"`
 }
 
 func (RoundWinnerDB) TableName() string {
@@ -3,10 +3,12 @@ package models
 import "time"
 
 type CollectionDB struct {
-	ID      uint    `gorm:"column:id"`
-	Name    string  `gorm:"column:name"`
-	Address *string `gorm:"column:address"`
-	Floor   *int64  `gorm:"column:floor"`
+	ID             uint       `gorm:"column:id"`
+	Name           string     `gorm:"column:name"`
+	Address        *string    `gorm:"column:address"`
+	Floor          *int64     `gorm:"column:floor"`
+	FloorUpdatedAt *time.Time `gorm:"column:floor_updated_at"`
+	IsStale        bool       `gorm:"column:is_stale"`
 }
 
 func (CollectionDB) TableName() string {
@@ -20,6 +22,8 @@ type NftDB struct {
 	Address       string `gorm:"column:address"`
 	LottieUrl     string `gorm:"column:lottie_url"`
 	CollectionID  uint   `gorm:"column:collection_id"`
+	// Attributes is json of []models.Attribute, nil when unknown
+	Attributes *string `gorm:"column:attributes"`
 }
 
 func (NftDB) TableName() string {
@@ -33,6 +37,8 @@ type GiftDB struct {
 	CollectibleID int    `gorm:"column:collectible_id"`
 	LottieUrl     string `gorm:"column:lottie_url"`
 	CollectionID  int    `gorm:"column:collection_id"`
+	// Attributes is json of []models.Attribute, nil when unknown
+	Attributes *string `gorm:"column:attributes"`
 }
 
 func (GiftDB) TableName() string {
@@ -60,3 +66,28 @@ type UserGiftDB struct {
 func (UserGiftDB) TableName() string {
 	return "users_gifts"
 }
+
+type CollectionFloorHistoryDB struct {
+	ID           uint      `gorm:"column:id"`
+	CollectionID uint      `gorm:"column:collection_id"`
+	Floor        *int64    `gorm:"column:floor"`
+	Quotes       string    `gorm:"column:quotes"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+}
+
+func (CollectionFloorHistoryDB) TableName() string {
+	return "collection_floor_history"
+}
+
+type CollectionPremiumDB struct {
+	CollectionID uint      `gorm:"column:collection_id"`
+	Trait        string    `gorm:"column:trait"`
+	Value        string    `gorm:"column:value"`
+	Premium      int64     `gorm:"column:premium"`
+	UpdatedBy    *string   `gorm:"column:updated_by"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+}
+
+func (CollectionPremiumDB) TableName() string {
+	return "collections_premiums"
+}
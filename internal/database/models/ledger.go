@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type LedgerEntryDB struct {
+	ID             uint      `gorm:"column:id"`
+	IdempotencyKey string    `gorm:"column:idempotency_key"`
+	Kind           string    `gorm:"column:kind"`
+	Account        string    `gorm:"column:account"`
+	UserID         *uint     `gorm:"column:user_id"`
+	Amount         int64     `gorm:"column:amount"`
+	Comment        *string   `gorm:"column:comment"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+}
+
+func (LedgerEntryDB) TableName() string {
+	return "ledger_entries"
+}
@@ -33,3 +33,46 @@ type ReferralFeeDB struct {
 	Fee       int64     `gorm:"column:fee"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 }
+
+func (ReferralFeeDB) TableName() string {
+	return "referrals_fees"
+}
+
+// UserLimitsDB overrides the default bet limits of the user, nil limits keep the default
+type UserLimitsDB struct {
+	UserID        uint       `gorm:"column:user_id"`
+	RoundBets     *int       `gorm:"column:round_bets"`
+	RoundValue    *int64     `gorm:"column:round_value"`
+	DailyBets     *int       `gorm:"column:daily_bets"`
+	DailyValue    *int64     `gorm:"column:daily_value"`
+	ExcludedUntil *time.Time `gorm:"column:excluded_until"`
+	UpdatedBy     *string    `gorm:"column:updated_by"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at"`
+}
+
+func (UserLimitsDB) TableName() string {
+	return "users_limits"
+}
+
+// UserWalletDB is a wallet linked by ton_proof, Address is raw and PublicKey is hex
+type UserWalletDB struct {
+	ID        uint      `gorm:"column:id"`
+	UserID    uint      `gorm:"column:user_id"`
+	Address   string    `gorm:"column:address"`
+	PublicKey string    `gorm:"column:public_key"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (UserWalletDB) TableName() string {
+	return "user_wallets"
+}
+
+type WalletPayloadDB struct {
+	Payload   string    `gorm:"column:payload"`
+	UserID    uint      `gorm:"column:user_id"`
+	ExpiresAt time.Time `gorm:"column:expires_at"`
+}
+
+func (WalletPayloadDB) TableName() string {
+	return "wallet_payloads"
+}
@@ -2,37 +2,75 @@ package models
 
 import "time"
 
-type TonWithdrawDB struct {
-	ID          uint      `gorm:"column:id"`
-	UserID      uint      `gorm:"column:user_id"`
-	Destination string    `gorm:"column:destination"`
-	Amount      uint      `gorm:"column:amount"`
-	CreatedAt   time.Time `gorm:"column:created_at"`
-}
+type WithdrawalType string
 
-func (TonWithdrawDB) TableName() string {
-	return "ton_withdraws"
-}
+const (
+	WithdrawalTon  WithdrawalType = "ton"
+	WithdrawalNft  WithdrawalType = "nft"
+	WithdrawalGift WithdrawalType = "gift"
+)
+
+// WithdrawalStatus moves requested -> debited -> submitted -> confirmed | failed -> refunded
+type WithdrawalStatus string
 
-type NftWithdrawDB struct {
-	ID          uint      `gorm:"column:id"`
-	UserID      uint      `gorm:"column:user_id"`
-	Destination string    `gorm:"column:destination"`
-	NftAddress  string    `gorm:"column:address"`
-	CreatedAt   time.Time `gorm:"column:created_at"`
+const (
+	WithdrawalRequested WithdrawalStatus = "requested"
+	WithdrawalDebited   WithdrawalStatus = "debited"
+	WithdrawalSubmitted WithdrawalStatus = "submitted"
+	WithdrawalConfirmed WithdrawalStatus = "confirmed"
+	WithdrawalFailed    WithdrawalStatus = "failed"
+	WithdrawalRefunded  WithdrawalStatus = "refunded"
+)
+
+// WithdrawalDB keeps nft and gift ids to give them back on refund
+type WithdrawalDB struct {
+	ID          uint             `gorm:"column:id"`
+	UserID      uint             `gorm:"column:user_id"`
+	Type        WithdrawalType   `gorm:"column:type"`
+	Status      WithdrawalStatus `gorm:"column:status"`
+	Destination *string          `gorm:"column:destination"`
+	Amount      int64            `gorm:"column:amount"`
+	Fee         int64            `gorm:"column:fee"`
+	NftID       *uint            `gorm:"column:nft_id"`
+	NftAddress  *string          `gorm:"column:nft_address"`
+	GiftID      *int64           `gorm:"column:gift_id"`
+	GiftMsgID   *int64           `gorm:"column:gift_msg_id"`
+	BatchID     *uint            `gorm:"column:batch_id"`
+	QueryID     *uint32          `gorm:"column:query_id"`
+	MsgHash     *string          `gorm:"column:msg_hash"`
+	ExpireAt    *time.Time       `gorm:"column:expire_at"`
+	Error       *string          `gorm:"column:error"`
+	SubmittedAt *time.Time       `gorm:"column:submitted_at"`
+	CompletedAt *time.Time       `gorm:"column:completed_at"`
+	CreatedAt   time.Time        `gorm:"column:created_at"`
+	UpdatedAt   time.Time        `gorm:"column:updated_at"`
 }
 
-func (NftWithdrawDB) TableName() string {
-	return "nft_withdraws"
+func (WithdrawalDB) TableName() string {
+	return "withdrawals"
 }
 
-type GiftWithdrawDB struct {
-	ID        uint      `gorm:"column:id"`
-	UserID    uint      `gorm:"column:user_id"`
-	GiftID    uint      `gorm:"column:gift_id"`
-	CreatedAt time.Time `gorm:"column:created_at"`
+type WithdrawalBatchStatus string
+
+const (
+	WithdrawalBatchSubmitted WithdrawalBatchStatus = "submitted"
+	WithdrawalBatchCompleted WithdrawalBatchStatus = "completed"
+	WithdrawalBatchFailed    WithdrawalBatchStatus = "failed"
+)
+
+// WithdrawalBatchDB is one wallet message, TxLt is set once its transaction is found
+type WithdrawalBatchDB struct {
+	ID        uint                  `gorm:"column:id"`
+	Status    WithdrawalBatchStatus `gorm:"column:status"`
+	QueryID   *uint32               `gorm:"column:query_id"`
+	MsgHash   *string               `gorm:"column:msg_hash"`
+	ExpireAt  *time.Time            `gorm:"column:expire_at"`
+	TxHash    *string               `gorm:"column:tx_hash"`
+	TxLt      *int64                `gorm:"column:tx_lt"`
+	CreatedAt time.Time             `gorm:"column:created_at"`
+	UpdatedAt time.Time             `gorm:"column:updated_at"`
 }
 
-func (GiftWithdrawDB) TableName() string {
-	return "gift_withdraws"
+func (WithdrawalBatchDB) TableName() string {
+	return "withdrawal_batches"
 }
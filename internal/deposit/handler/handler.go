@@ -18,5 +18,6 @@ func Router(h *Handler, r *gin.Engine) {
 	router := r.Group("deposit")
 	{
 		router.POST("/nft", h.addNftDeposit)
+		router.POST("/star", h.addStarDeposit)
 	}
 }
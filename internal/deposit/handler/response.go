@@ -0,0 +1,11 @@
+package handler
+
+type StarInvoiceResponse struct {
+	Link string `json:"link"`
+}
+
+func NewStarInvoiceResponse(link string) *StarInvoiceResponse {
+	return &StarInvoiceResponse{
+		Link: link,
+	}
+}
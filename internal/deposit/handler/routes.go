@@ -4,14 +4,21 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	
+
+	"roulette/internal/deposit/service"
 	"roulette/internal/middleware/handler"
+	userService "roulette/internal/user/service"
+	walletService "roulette/internal/wallet/service"
 )
 
 func (h *Handler) addNftDeposit(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
 		type RequestBody struct {
-			UserID     uint   `json:"userId"`
 			Sender     string `json:"sender"`
 			NftAddress string `json:"address"`
 		}
@@ -20,10 +27,46 @@ func (h *Handler) addNftDeposit(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
-		if err := h.service.AddNft(c.Request.Context(), body.UserID, body.Sender, body.NftAddress); err != nil {
+		if err := h.service.AddNft(c.Request.Context(), userID, body.Sender, body.NftAddress); err != nil {
+			if service.IsNftDepositExists(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if walletService.IsWalletNotLinked(err) {
+				return handler.NewErrorResponse(http.StatusForbidden, err.Error())
+			}
 			return handler.NewInternalErrorResponse(err)
 		}
 
 		return handler.NewSuccessResponse(http.StatusCreated, nil)
 	})
 }
+
+func (h *Handler) addStarDeposit(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
+		type RequestBody struct {
+			Amount int `json:"amount"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		link, err := h.service.AddStar(c.Request.Context(), userID, body.Amount)
+		if err != nil {
+			if service.IsStarsDisabled(err) || service.IsInvalidStarAmount(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			}
+			if userService.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusCreated, NewStarInvoiceResponse(link))
+	})
+}
@@ -1,8 +1,20 @@
 package model
 
+import "time"
+
 type NftDeposit struct {
 	ID         uint   `gorm:"id"`
 	UserID     uint   `gorm:"user_id"`
 	Sender     string `gorm:"sender"`
 	NftAddress string `gorm:"nft_address"`
 }
+
+type PendingGiftDeposit struct {
+	ID            uint      `json:"id" gorm:"id"`
+	SenderID      *int64    `json:"senderId" gorm:"sender_id"`
+	GiftID        int64     `json:"giftId" gorm:"gift_id"`
+	MsgID         string    `json:"msgId" gorm:"msg_id"`
+	Name          string    `json:"name" gorm:"name"`
+	CollectibleID int       `json:"collectibleId" gorm:"collectible_id"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"created_at"`
+}
@@ -3,8 +3,10 @@ package repo
 import (
 	"context"
 	"roulette/internal/database"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	dbModels "roulette/internal/database/models"
 	"roulette/internal/deposit/model"
@@ -13,21 +15,43 @@ import (
 type Repo interface {
 	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
 
-	GetDepositTime(ctx context.Context, timeID dbModels.DepositTime) (*int64, error)
+	GetCursor(ctx context.Context, cursorID dbModels.DepositCursor) (*dbModels.DepositCursorDB, error)
 
 	GetNftDeposits(ctx context.Context) ([]*model.NftDeposit, error)
 
+	// GetNft locks the deposit row when called in a tx
+	GetNft(ctx context.Context, depositID uint) (*dbModels.NftDepositDB, error)
+
+	// GetRegisteredNfts locks the pending deposits registered by users when called in a tx
+	GetRegisteredNfts(ctx context.Context) ([]*dbModels.NftDepositDB, error)
+
+	GetGift(ctx context.Context, depositID uint) (*dbModels.GiftDepositDB, error)
+
+	GetPendingGifts(ctx context.Context) ([]*model.PendingGiftDeposit, error)
+
+	GetStarByPayload(ctx context.Context, payload string) (*dbModels.StarDepositDB, error)
+
+	GetStarByPaymentID(ctx context.Context, paymentID string) (*dbModels.StarDepositDB, error)
+
 	AddTon(ctx context.Context, tonDeposit *dbModels.TonDepositDB) error
 
 	AddNft(ctx context.Context, nftDeposit *dbModels.NftDepositDB) error
 
-	AddGift(ctx context.Context, giftDeposit *dbModels.GiftDepositDB) error
+	AddStar(ctx context.Context, starDeposit *dbModels.StarDepositDB) error
 
-	UpdateDepositTime(ctx context.Context, timeID uint) error
+	// AddGift skips already recorded msg id, returns false for it
+	AddGift(ctx context.Context, giftDeposit *dbModels.GiftDepositDB) (bool, error)
 
-	UpdateUserBalance(ctx context.Context, userID uint, amount int) error
+	UpdateCursor(ctx context.Context, cursor *dbModels.DepositCursorDB) error
 
 	UpdateNft(ctx context.Context, depositID uint, deposit *dbModels.NftDepositDB) error
+
+	UpdateStar(ctx context.Context, depositID uint, deposit *dbModels.StarDepositDB) error
+
+	// UpdateStarRefunding sets the refund attempt of the deposit, nil refundingAt ends the attempt
+	UpdateStarRefunding(ctx context.Context, depositID uint, refundingAt *time.Time, attempts int) error
+
+	UpdateGiftUser(ctx context.Context, depositID uint, userID uint) error
 }
 
 type repo struct {
@@ -43,45 +67,19 @@ func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) e
 	return database.RunInTx(ctx, r.db, f)
 }
 
-func (r *repo) GetDepositTime(ctx context.Context, timeID dbModels.DepositTime) (*int64, error) {
+func (r *repo) GetCursor(ctx context.Context, cursorID dbModels.DepositCursor) (*dbModels.DepositCursorDB, error) {
 	db := database.FromContext(ctx, r.db)
 
-	var depositTime *dbModels.DepositTimeDB
-	err := db.WithContext(ctx).First(&depositTime, "id = ?", timeID).Error
+	var cursor *dbModels.DepositCursorDB
+	err := db.WithContext(ctx).First(&cursor, "id = ?", cursorID).Error
 	if err != nil {
 		if database.IsRecordNotFoundErr(err) {
-			err = db.WithContext(ctx).
-				Raw(`
-					INSERT INTO deposit_time (id) VALUES (?)
-					ON CONFLICT (id) DO UPDATE SET start = CURRENT_TIMESTAMP
-					RETURNING start
-				`, timeID).
-				Scan(&depositTime).Error
-			if err != nil {
-				return nil, err
-			}
-
-			unixTime := depositTime.Start.Unix()
-
-			return &unixTime, nil
+			return nil, database.ErrNotFound
 		}
 		return nil, err
 	}
 
-	res := db.WithContext(ctx).
-		Raw(`
-			UPDATE deposit_time 
-			SET start = CURRENT_TIMESTAMP 
-			WHERE id = ?
-		`, timeID).
-		Save(&dbModels.DepositTimeDB{})
-	if res.Error != nil {
-		return nil, err
-	}
-
-	unixTime := depositTime.Start.Unix()
-
-	return &unixTime, nil
+	return cursor, nil
 }
 
 func (r *repo) GetNftDeposits(ctx context.Context) ([]*model.NftDeposit, error) {
@@ -105,6 +103,111 @@ func (r *repo) GetNftDeposits(ctx context.Context) ([]*model.NftDeposit, error)
 	return deps, nil
 }
 
+func (r *repo) GetNft(ctx context.Context, depositID uint) (*dbModels.NftDepositDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var deposit *dbModels.NftDepositDB
+	err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&deposit, "id = ?", depositID).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+func (r *repo) GetRegisteredNfts(ctx context.Context) ([]*dbModels.NftDepositDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var deps []*dbModels.NftDepositDB
+	err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&deps, "is_confirmed IS NULL AND trace_id IS NULL").Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deps, nil
+}
+
+// GetGift locks the deposit row when called in a tx
+func (r *repo) GetGift(ctx context.Context, depositID uint) (*dbModels.GiftDepositDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var deposit *dbModels.GiftDepositDB
+	err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&deposit, "id = ?", depositID).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+func (r *repo) GetPendingGifts(ctx context.Context) ([]*model.PendingGiftDeposit, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var deps []*model.PendingGiftDeposit
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT gd.id, gd.sender_id, gd.gift_id, gd.msg_id, g.name, g.collectible_id, gd.created_at
+			FROM gift_deposits gd
+				JOIN gifts g ON g.id = gd.gift_id
+			WHERE gd.user_id IS NULL
+			ORDER BY gd.id
+		`).
+		Scan(&deps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deps, nil
+}
+
+// GetStarByPayload locks the deposit row when called in a tx
+func (r *repo) GetStarByPayload(ctx context.Context, payload string) (*dbModels.StarDepositDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var deposit *dbModels.StarDepositDB
+	err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&deposit, "payload = ?", payload).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+// GetStarByPaymentID locks the deposit row when called in a tx
+func (r *repo) GetStarByPaymentID(ctx context.Context, paymentID string) (*dbModels.StarDepositDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var deposit *dbModels.StarDepositDB
+	err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&deposit, "payment_id = ?", paymentID).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
 func (r *repo) AddTon(ctx context.Context, tonDeposit *dbModels.TonDepositDB) error {
 	db := database.FromContext(ctx, r.db)
 
@@ -142,15 +245,16 @@ func (r *repo) AddNft(ctx context.Context, nftDeposit *dbModels.NftDepositDB) er
 	return nil
 }
 
-func (r *repo) AddGift(ctx context.Context, giftDeposit *dbModels.GiftDepositDB) error {
+func (r *repo) AddStar(ctx context.Context, starDeposit *dbModels.StarDepositDB) error {
 	db := database.FromContext(ctx, r.db)
 
 	err := db.WithContext(ctx).
-		Model(&dbModels.GiftDepositDB{}).
-		Create(&giftDeposit).Error
+		Model(&dbModels.StarDepositDB{}).
+		Select("user_id", "amount", "payload").
+		Create(&starDeposit).Error
 	if err != nil {
-		if database.IsKeyConflictErr(err) {
-			return database.ErrKeyConflict
+		if database.IsFKeyConflictError(err) {
+			return database.ErrFKeyConflict
 		}
 		return err
 	}
@@ -158,13 +262,45 @@ func (r *repo) AddGift(ctx context.Context, giftDeposit *dbModels.GiftDepositDB)
 	return nil
 }
 
-func (r *repo) UpdateDepositTime(ctx context.Context, timeID uint) error {
+func (r *repo) AddGift(ctx context.Context, giftDeposit *dbModels.GiftDepositDB) (bool, error) {
 	db := database.FromContext(ctx, r.db)
 
 	res := db.WithContext(ctx).
-		Model(&dbModels.DepositTimeDB{}).
-		Where("id = ?", timeID).
-		Update("id", timeID)
+		Model(&dbModels.GiftDepositDB{}).
+		Select("user_id", "sender_id", "gift_id", "msg_id").
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "msg_id"}}, DoNothing: true}).
+		Create(&giftDeposit)
+	if res.Error != nil {
+		if database.IsFKeyConflictError(res.Error) {
+			return false, database.ErrFKeyConflict
+		}
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (r *repo) UpdateCursor(ctx context.Context, cursor *dbModels.DepositCursorDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Exec(`
+			INSERT INTO deposit_cursors (id, lt, hash) VALUES (?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET lt = excluded.lt, hash = excluded.hash, updated_at = CURRENT_TIMESTAMP
+		`, cursor.ID, cursor.Lt, cursor.Hash).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) UpdateNft(ctx context.Context, depositID uint, deposit *dbModels.NftDepositDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Where("id = ?", depositID).
+		Updates(deposit)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -175,26 +311,35 @@ func (r *repo) UpdateDepositTime(ctx context.Context, timeID uint) error {
 	return nil
 }
 
-func (r *repo) UpdateUserBalance(ctx context.Context, userID uint, amount int) error {
+func (r *repo) UpdateStar(ctx context.Context, depositID uint, deposit *dbModels.StarDepositDB) error {
 	db := database.FromContext(ctx, r.db)
 
-	var user *dbModels.UserDB
-	err := db.WithContext(ctx).
-		Model(&dbModels.UserDB{}).
-		Where("id = ?", userID).
-		First(&user).Error
-	if err != nil {
-		if database.IsRecordNotFoundErr(err) {
-			return database.ErrNotFound
+	res := db.WithContext(ctx).
+		Where("id = ?", depositID).
+		Updates(deposit)
+	if res.Error != nil {
+		if database.IsKeyConflictErr(res.Error) {
+			return database.ErrKeyConflict
 		}
-		return err
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
 	}
 
-	balance := user.Balance + amount
+	return nil
+}
+
+func (r *repo) UpdateStarRefunding(ctx context.Context, depositID uint, refundingAt *time.Time, attempts int) error {
+	db := database.FromContext(ctx, r.db)
+
 	res := db.WithContext(ctx).
-		Model(&dbModels.UserDB{}).
-		Where("id = ?", userID).
-		UpdateColumn("balance", balance)
+		Model(&dbModels.StarDepositDB{}).
+		Where("id = ?", depositID).
+		Updates(map[string]interface{}{
+			"refunding_at":    refundingAt,
+			"refund_attempts": attempts,
+		})
 	if res.Error != nil {
 		return res.Error
 	}
@@ -205,13 +350,17 @@ func (r *repo) UpdateUserBalance(ctx context.Context, userID uint, amount int) e
 	return nil
 }
 
-func (r *repo) UpdateNft(ctx context.Context, depositID uint, deposit *dbModels.NftDepositDB) error {
+func (r *repo) UpdateGiftUser(ctx context.Context, depositID uint, userID uint) error {
 	db := database.FromContext(ctx, r.db)
 
 	res := db.WithContext(ctx).
-		Where("id = ?", depositID).
-		Updates(deposit)
+		Model(&dbModels.GiftDepositDB{}).
+		Where("id = ? AND user_id IS NULL", depositID).
+		Update("user_id", userID)
 	if res.Error != nil {
+		if database.IsFKeyConflictError(res.Error) {
+			return database.ErrFKeyConflict
+		}
 		return res.Error
 	}
 	if res.RowsAffected == 0 {
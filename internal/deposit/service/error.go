@@ -0,0 +1,82 @@
+package service
+
+import "errors"
+
+var (
+	ErrTonDepositExists    = errors.New("ton deposit already exists")
+	ErrNftDepositNotFound  = errors.New("nft deposit not found")
+	ErrNftDepositExists    = errors.New("nft deposit already exists")
+	ErrNftDepositResolved  = errors.New("nft deposit is already resolved")
+	ErrNftNotReceived      = errors.New("nft is not owned by the admin wallet")
+	ErrNotNft              = errors.New("address is not an nft item")
+	ErrUnknownCollection   = errors.New("nft collection is not accepted")
+	ErrSenderMismatch      = errors.New("nft was sent by another wallet")
+	ErrTransferCredited    = errors.New("nft transfer is already credited")
+	ErrNftHeld             = errors.New("nft is held by a user")
+	ErrGiftDepositNotFound = errors.New("gift deposit not found")
+	ErrGiftDepositResolved = errors.New("gift deposit is already attributed")
+	ErrStarsDisabled       = errors.New("stars deposits are disabled")
+	ErrInvalidStarAmount   = errors.New("invalid stars amount")
+	ErrStarDepositNotFound = errors.New("stars deposit not found")
+	ErrInvalidStarPayment  = errors.New("stars payment does not match deposit")
+	ErrStarDepositPaid     = errors.New("stars deposit is already paid")
+	ErrStarDepositRefunded = errors.New("stars deposit is already refunded")
+)
+
+func IsTonDepositExists(err error) bool {
+	return errors.Is(err, ErrTonDepositExists)
+}
+
+func IsNftDepositNotFound(err error) bool {
+	return errors.Is(err, ErrNftDepositNotFound)
+}
+
+func IsNftDepositExists(err error) bool {
+	return errors.Is(err, ErrNftDepositExists)
+}
+
+func IsNftDepositResolved(err error) bool {
+	return errors.Is(err, ErrNftDepositResolved)
+}
+
+func IsNftNotReceived(err error) bool {
+	return errors.Is(err, ErrNftNotReceived)
+}
+
+// IsNftRejected reports whether the nft can never be credited to the deposit
+func IsNftRejected(err error) bool {
+	return errors.Is(err, ErrNotNft) || errors.Is(err, ErrUnknownCollection) || errors.Is(err, ErrSenderMismatch) ||
+		errors.Is(err, ErrTransferCredited) || errors.Is(err, ErrNftHeld)
+}
+
+func IsGiftDepositNotFound(err error) bool {
+	return errors.Is(err, ErrGiftDepositNotFound)
+}
+
+func IsGiftDepositResolved(err error) bool {
+	return errors.Is(err, ErrGiftDepositResolved)
+}
+
+func IsStarsDisabled(err error) bool {
+	return errors.Is(err, ErrStarsDisabled)
+}
+
+func IsInvalidStarAmount(err error) bool {
+	return errors.Is(err, ErrInvalidStarAmount)
+}
+
+func IsStarDepositNotFound(err error) bool {
+	return errors.Is(err, ErrStarDepositNotFound)
+}
+
+func IsInvalidStarPayment(err error) bool {
+	return errors.Is(err, ErrInvalidStarPayment)
+}
+
+func IsStarDepositPaid(err error) bool {
+	return errors.Is(err, ErrStarDepositPaid)
+}
+
+func IsStarDepositRefunded(err error) bool {
+	return errors.Is(err, ErrStarDepositRefunded)
+}
@@ -2,84 +2,187 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"roulette/internal/config"
+
 	"roulette/internal/database"
 	dbModels "roulette/internal/database/models"
+	"roulette/internal/deposit/model"
 	"roulette/internal/deposit/repo"
 	giftService "roulette/internal/gift/service"
+	ledgerModel "roulette/internal/ledger/model"
+	ledgerService "roulette/internal/ledger/service"
+	"roulette/internal/models"
+	"roulette/internal/tg/bot"
+	tonModel "roulette/internal/ton/model"
 	tonService "roulette/internal/ton/service"
 	userService "roulette/internal/user/service"
 	"roulette/internal/utils"
-	"strconv"
+	walletService "roulette/internal/wallet/service"
 )
 
+const tonPageSize = 256
+
 type Service interface {
 	CheckTonDeposit(ctx context.Context) error
 
+	// CheckNftDeposit credits nfts sent with the user memo as the forward payload comment
+	// or from a linked wallet, then confirms the pre-registered deposits by the last transfer of the item
 	CheckNftDeposit(ctx context.Context) error
 
+	// AddNft registers a pending deposit of the nft sent from a wallet linked by the user
 	AddNft(ctx context.Context, userID uint, sender string, nftAddress string) error
 
-	addTon(ctx context.Context, userID uint, userBalance int, amount int, msgHash string, payload *string) error
+	// ConfirmNft gives the nft of the pending deposit to the user without checking the transfer,
+	// the admin wallet must own the item of an accepted collection
+	ConfirmNft(ctx context.Context, depositID uint) error
+
+	// RejectNft closes the pending deposit without giving the nft, the reason is shown to admins
+	RejectNft(ctx context.Context, depositID uint, reason string) error
+
+	// AddGift records received telegram gift, the deposit is pending if the sender is not a user.
+	// Duplicate msg ids are ignored
+	AddGift(ctx context.Context, senderID int64, gift *dbModels.GiftDB) error
+
+	GetPendingGifts(ctx context.Context) ([]*model.PendingGiftDeposit, error)
+
+	// ResolveGift attributes pending gift deposit to the user
+	ResolveGift(ctx context.Context, depositID uint, userID uint) error
+
+	// AddStar creates a pending deposit and returns Stars invoice link
+	AddStar(ctx context.Context, userID uint, amount int) (string, error)
+
+	// CheckStar validates pre_checkout_query against the pending deposit
+	CheckStar(ctx context.Context, userID uint, payload string, amount int) error
+
+	// ConfirmStar records successful_payment and credits the balance, idempotent on paymentID
+	ConfirmStar(ctx context.Context, userID uint, payload string, paymentID string, amount int) error
+
+	// RefundStar debits the balance and refunds the payment via Bot API, the debit is reversed
+	// when telegram rejects the refund. A refund left pending by an unknown answer is sent again
+	RefundStar(ctx context.Context, paymentID string) error
+
+	addTon(ctx context.Context, userID uint, amount int, msgHash string, payload *string) error
 }
 
 type service struct {
-	repo        repo.Repo
-	tonService  tonService.Service
-	userService userService.Service
-	giftService giftService.Service
+	repo          repo.Repo
+	tonService    tonService.Service
+	userService   userService.Service
+	giftService   giftService.Service
+	ledgerService ledgerService.Service
+	walletService walletService.Service
+	botClient     bot.Client
+	starConfig    config.StarConfig
+	tonBackfill   bool
+	nftBackfill   bool
 }
 
-func NewService(repo repo.Repo, tonService tonService.Service, userService userService.Service, giftService giftService.Service) Service {
+func NewService(
+	repo repo.Repo,
+	tonService tonService.Service,
+	userService userService.Service,
+	giftService giftService.Service,
+	ledgerService ledgerService.Service,
+	walletService walletService.Service,
+	botClient bot.Client,
+	cfg *config.Config,
+) Service {
 	return &service{
-		repo:        repo,
-		tonService:  tonService,
-		userService: userService,
-		giftService: giftService,
+		repo:          repo,
+		tonService:    tonService,
+		userService:   userService,
+		giftService:   giftService,
+		ledgerService: ledgerService,
+		walletService: walletService,
+		botClient:     botClient,
+		starConfig:    cfg.StarConfig,
+		tonBackfill:   cfg.DepositConfig.TonBackfill,
+		nftBackfill:   cfg.DepositConfig.NftBackfill,
 	}
 }
 
+// CheckTonDeposit pages through incoming messages from the stored cursor.
+// The cursor is moved only after credits are committed, the cursor message read again
+// is skipped by its hash and other replays by msg hash of the deposit
 func (s *service) CheckTonDeposit(ctx context.Context) error {
-	start, err := s.repo.GetDepositTime(ctx, dbModels.TonTime)
-	if err != nil {
-		return fmt.Errorf("failed to get deposit time: %v", err)
-	}
-
-	msgs, err := s.tonService.GetTonTransfers(ctx, start)
+	cursor, err := s.repo.GetCursor(ctx, dbModels.TonCursor)
 	if err != nil {
-		return err
-	}
-	if len(msgs) == 0 {
-		return nil
+		if !database.IsRecordNotFoundErr(err) {
+			return fmt.Errorf("failed to get ton cursor: %v", err)
+		}
+		if cursor, err = s.seedCursor(ctx, dbModels.TonCursor, s.tonBackfill); err != nil {
+			return err
+		}
 	}
 
-	for _, msg := range msgs {
-		if msg.MsgContent.Decoded.Comment == nil {
-			continue
+	startLt, startHash := cursor.Lt, cursor.Hash
+	for offset := 0; ; offset += tonPageSize {
+		msgs, err := s.tonService.GetTonTransfers(ctx, startLt, tonPageSize, offset)
+		if err != nil {
+			return err
 		}
 
-		if len(*msg.MsgContent.Decoded.Comment) != 8 {
-			continue
-		}
+		for _, msg := range msgs {
+			lt, err := strconv.ParseInt(msg.CreatedLt, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid lt %s of message %s", msg.CreatedLt, msg.Hash)
+			}
+			if lt == startLt && msg.Hash == startHash {
+				continue
+			}
 
-		memo := *msg.MsgContent.Decoded.Comment
-		user, _ := s.userService.GetUserByMemo(ctx, memo)
-		if user == nil {
-			continue
+			if err = s.processTonMessage(ctx, msg); err != nil {
+				return fmt.Errorf("failed to process message %s: %w", msg.Hash, err)
+			}
+
+			cursor.Lt, cursor.Hash = lt, msg.Hash
 		}
 
-		amount, _ := strconv.Atoi(msg.Value)
-		if err = s.addTon(ctx, user.ID, user.Balance, amount, msg.Hash, nil); err != nil {
-			log.Printf("failed to add ton deposit for user: %d", user.ID)
-			continue
+		if len(msgs) > 0 {
+			if err = s.repo.UpdateCursor(ctx, cursor); err != nil {
+				return fmt.Errorf("failed to update ton cursor: %v", err)
+			}
 		}
+		if len(msgs) < tonPageSize {
+			return nil
+		}
+	}
+}
+
+// seedCursor starts the first run at the newest admin wallet transaction,
+// the history before it is read only with backfill
+func (s *service) seedCursor(ctx context.Context, cursorID dbModels.DepositCursor, backfill bool) (*dbModels.DepositCursorDB, error) {
+	cursor := &dbModels.DepositCursorDB{ID: cursorID}
+	if backfill {
+		return cursor, nil
 	}
 
-	return nil
+	lt, err := s.tonService.GetLastLt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed %s cursor: %w", cursorID, err)
+	}
+	cursor.Lt = lt
+	if err = s.repo.UpdateCursor(ctx, cursor); err != nil {
+		return nil, fmt.Errorf("failed to seed %s cursor: %v", cursorID, err)
+	}
+
+	log.Printf("%s cursor is seeded at lt %d", cursorID, lt)
+	return cursor, nil
 }
 
 func (s *service) CheckNftDeposit(ctx context.Context) error {
+	// a failed scan must not hold the pre-registered deposits
+	errScan := s.checkNftTransfers(ctx)
+
 	deps, errDep := s.repo.GetNftDeposits(ctx)
 	if errDep != nil {
 		return errDep
@@ -87,61 +190,77 @@ func (s *service) CheckNftDeposit(ctx context.Context) error {
 
 	for _, dep := range deps {
 		transfer, errNft := s.tonService.GetNftTransfer(ctx, dep.NftAddress)
-
-		if errNft != nil {
-			continue
-		}
-		if transfer == nil {
+		if errNft != nil || transfer == nil {
 			continue
 		}
 
-		transferSender, err := utils.GetAddress(transfer.Sender)
-		if err != nil {
-			continue
+		// deposits resolved by admin are still in the recent window
+		err := s.confirmNft(ctx, dep.ID, transfer)
+		switch {
+		case err == nil, IsNftDepositResolved(err), IsNftNotReceived(err):
+		case IsNftRejected(err):
+			if err = s.rejectNft(ctx, dep.ID, err.Error()); err != nil && !IsNftDepositResolved(err) {
+				log.Printf("nft deposit %d: %v", dep.ID, err)
+			}
+		default:
+			log.Printf("nft deposit %d: %v", dep.ID, err)
 		}
-		depSender, err := utils.GetAddress(dep.Sender)
-		if err != nil {
-			continue
+	}
+
+	return errScan
+}
+
+// checkNftTransfers pages through incoming nft transfers from the stored cursor like CheckTonDeposit.
+// The cursor is saved after each transfer, the cursor transfer read again is skipped by its trace id
+func (s *service) checkNftTransfers(ctx context.Context) error {
+	cursor, err := s.repo.GetCursor(ctx, dbModels.NftCursor)
+	if err != nil {
+		if !database.IsRecordNotFoundErr(err) {
+			return fmt.Errorf("failed to get nft cursor: %v", err)
 		}
-		transferSenderRaw, depSenderRaw := transferSender.StringRaw(), depSender.StringRaw()
-		if transferSenderRaw != depSenderRaw {
-			continue
+		if cursor, err = s.seedCursor(ctx, dbModels.NftCursor, s.nftBackfill); err != nil {
+			return err
 		}
+	}
 
-		nft, err := s.tonService.GetNft(ctx, dep.NftAddress)
+	for {
+		transfers, err := s.tonService.GetNftTransfers(ctx, cursor.Lt, tonPageSize)
 		if err != nil {
-			return fmt.Errorf("failed to get nft: %v", dep.NftAddress)
+			return err
 		}
 
-		errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-			isConfirmed := true
-			deposit := &dbModels.NftDepositDB{
-				TraceID:     &transfer.TraceID,
-				IsConfirmed: &isConfirmed,
+		startLt, startTrace := cursor.Lt, cursor.Hash
+		for _, transfer := range transfers {
+			lt, err := strconv.ParseInt(transfer.Lt, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid lt %s of nft transfer %s", transfer.Lt, transfer.TraceID)
 			}
-			if err = s.repo.UpdateNft(ctx, dep.ID, deposit); err != nil {
-				if database.IsRecordNotFoundErr(err) {
-					return fmt.Errorf("nft deposit %d not found", dep.ID)
-				}
-				return err
+			if lt == startLt && transfer.TraceID == startTrace {
+				continue
 			}
 
-			err = s.giftService.AddUserNft(ctx, dep.UserID, nft.Name, uint(nft.CollectibleID), nft.Address, nft.LottieUrl, 1)
-			if err != nil {
-				if database.IsKeyConflictErr(err) {
-					return fmt.Errorf("nft %s exists", nft.Address)
-				}
-				return err
+			if err = s.processNftTransfer(ctx, transfer); err != nil {
+				return fmt.Errorf("failed to process nft transfer %s: %w", transfer.TraceID, err)
+			}
+
+			cursor.Lt, cursor.Hash = lt, transfer.TraceID
+			if err = s.repo.UpdateCursor(ctx, cursor); err != nil {
+				return fmt.Errorf("failed to update nft cursor: %v", err)
 			}
+		}
 
+		if len(transfers) < tonPageSize || cursor.Lt == startLt {
 			return nil
-		})
-		if errTx != nil {
-			continue
 		}
 	}
+}
 
-	return nil
+func (s *service) ConfirmNft(ctx context.Context, depositID uint) error {
+	return s.confirmNft(ctx, depositID, nil)
+}
+
+func (s *service) RejectNft(ctx context.Context, depositID uint, reason string) error {
+	return s.rejectNft(ctx, depositID, reason)
 }
 
 func (s *service) AddNft(ctx context.Context, userID uint, sender string, nftAddress string) error {
@@ -151,6 +270,9 @@ func (s *service) AddNft(ctx context.Context, userID uint, sender string, nftAdd
 	if _, err := utils.GetAddress(nftAddress); err != nil {
 		return fmt.Errorf("invalid nft address: %s", nftAddress)
 	}
+	if err := s.walletService.CheckLinked(ctx, userID, sender); err != nil {
+		return err
+	}
 
 	nftDeposit := &dbModels.NftDepositDB{
 		UserID:     userID,
@@ -159,7 +281,352 @@ func (s *service) AddNft(ctx context.Context, userID uint, sender string, nftAdd
 	}
 	if err := s.repo.AddNft(ctx, nftDeposit); err != nil {
 		if database.IsKeyConflictErr(err) {
-			return fmt.Errorf("nft deposit %s exists")
+			return fmt.Errorf("%w: %s", ErrNftDepositExists, nftAddress)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) AddGift(ctx context.Context, senderID int64, gift *dbModels.GiftDB) error {
+	giftDeposit := &dbModels.GiftDepositDB{
+		GiftID: gift.ID,
+		MsgID:  strconv.FormatInt(gift.MsgID, 10),
+	}
+	if senderID != 0 {
+		giftDeposit.SenderID = &senderID
+
+		user, err := s.userService.GetUser(ctx, uint(senderID))
+		if err != nil && !userService.IsUserNotFound(err) {
+			return err
+		}
+		if user != nil {
+			giftDeposit.UserID = &user.ID
+		}
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		inserted, err := s.repo.AddGift(ctx, giftDeposit)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			return nil
+		}
+
+		if err = s.giftService.AddGift(ctx, gift); err != nil {
+			return fmt.Errorf("failed to add gift %d: %w", gift.ID, err)
+		}
+
+		if giftDeposit.UserID == nil {
+			log.Printf("gift deposit %s from %d is pending attribution", giftDeposit.MsgID, senderID)
+			return nil
+		}
+
+		if err = s.giftService.AddUserGift(ctx, *giftDeposit.UserID, gift.ID); err != nil {
+			return fmt.Errorf("failed to add user gift %d: %w", gift.ID, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *service) GetPendingGifts(ctx context.Context) ([]*model.PendingGiftDeposit, error) {
+	return s.repo.GetPendingGifts(ctx)
+}
+
+func (s *service) ResolveGift(ctx context.Context, depositID uint, userID uint) error {
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		deposit, err := s.repo.GetGift(ctx, depositID)
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return ErrGiftDepositNotFound
+			}
+			return err
+		}
+		if deposit.UserID != nil {
+			return ErrGiftDepositResolved
+		}
+
+		if err = s.repo.UpdateGiftUser(ctx, depositID, userID); err != nil {
+			if database.IsFKeyConflictError(err) {
+				return userService.ErrUserNotFound
+			}
+			return err
+		}
+
+		if err = s.giftService.AddUserGift(ctx, userID, deposit.GiftID); err != nil {
+			return fmt.Errorf("failed to add user gift %d: %w", deposit.GiftID, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *service) AddStar(ctx context.Context, userID uint, amount int) (string, error) {
+	if err := s.checkStarAmount(amount); err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate payload: %v", err)
+	}
+	payload := fmt.Sprintf("star:%d:%s", userID, hex.EncodeToString(nonce))
+
+	starDeposit := &dbModels.StarDepositDB{
+		UserID:  userID,
+		Amount:  uint(amount),
+		Payload: &payload,
+	}
+	if err := s.repo.AddStar(ctx, starDeposit); err != nil {
+		if database.IsFKeyConflictError(err) {
+			return "", userService.ErrUserNotFound
+		}
+		return "", err
+	}
+
+	invoice := &bot.Invoice{
+		Title:       "Balance top up",
+		Description: fmt.Sprintf("%d stars deposit", amount),
+		Payload:     payload,
+		Amount:      amount,
+	}
+	link, err := s.botClient.CreateInvoiceLink(ctx, invoice)
+	if err != nil {
+		return "", fmt.Errorf("failed to create invoice link: %v", err)
+	}
+
+	return link, nil
+}
+
+func (s *service) CheckStar(ctx context.Context, userID uint, payload string, amount int) error {
+	if err := s.checkStarAmount(amount); err != nil {
+		return err
+	}
+
+	deposit, err := s.repo.GetStarByPayload(ctx, payload)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return ErrStarDepositNotFound
+		}
+		return err
+	}
+
+	return s.checkStarDeposit(deposit, userID, amount)
+}
+
+func (s *service) ConfirmStar(ctx context.Context, userID uint, payload string, paymentID string, amount int) error {
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		deposit, err := s.repo.GetStarByPayload(ctx, payload)
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return ErrStarDepositNotFound
+			}
+			return err
+		}
+
+		if deposit.PaymentID != nil {
+			if *deposit.PaymentID == paymentID {
+				return nil
+			}
+			return ErrStarDepositPaid
+		}
+		if err = s.checkStarDeposit(deposit, userID, amount); err != nil {
+			return err
+		}
+
+		paidAt := time.Now()
+		update := &dbModels.StarDepositDB{
+			PaymentID: &paymentID,
+			PaidAt:    &paidAt,
+		}
+		if err = s.repo.UpdateStar(ctx, deposit.ID, update); err != nil {
+			if database.IsKeyConflictErr(err) {
+				return ErrStarDepositPaid
+			}
+			return err
+		}
+
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.StarDeposit, paymentID),
+			Kind:   ledgerModel.StarDeposit,
+			Debit:  ledgerModel.ExternalAccount,
+			Credit: ledgerModel.UserAccount(deposit.UserID),
+			Amount: int64(deposit.Amount) * s.starConfig.Rate,
+		}
+		return s.ledgerService.Post(ctx, posting)
+	})
+}
+
+func (s *service) RefundStar(ctx context.Context, paymentID string) error {
+	deposit, err := s.startStarRefund(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	// telegram is called out of the tx, the refund can't be rolled back with it
+	err = s.botClient.RefundStarPayment(ctx, int64(deposit.UserID), paymentID)
+	if err != nil && !isChargeRefunded(err) {
+		if !bot.IsApiError(err) {
+			// the refund may have been done, the next refund of the payment sends it again
+			return fmt.Errorf("star refund is left pending: %w", err)
+		}
+		if errCancel := s.cancelStarRefund(ctx, paymentID); errCancel != nil {
+			return fmt.Errorf("failed to reverse star refund after %v: %w", err, errCancel)
+		}
+		return fmt.Errorf("failed to refund star payment: %w", err)
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		deposit, err := s.lockStarRefund(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+
+		refundedAt := time.Now()
+		return s.repo.UpdateStar(ctx, deposit.ID, &dbModels.StarDepositDB{RefundedAt: &refundedAt})
+	})
+}
+
+// startStarRefund debits what was credited for the payment and marks the deposit refunding.
+// A deposit left refunding by an unknown telegram answer is returned as is, so its refund is sent again
+func (s *service) startStarRefund(ctx context.Context, paymentID string) (*dbModels.StarDepositDB, error) {
+	var deposit *dbModels.StarDepositDB
+	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		deposit, err = s.lockStarRefund(ctx, paymentID)
+		if err != nil || deposit.RefundingAt != nil {
+			return err
+		}
+
+		// refund what was credited, the rate may have changed since
+		credited, err := s.ledgerService.GetPostedAmount(ctx, ledgerModel.Key(ledgerModel.StarDeposit, paymentID))
+		if err != nil {
+			return err
+		}
+
+		attempt := deposit.RefundAttempts + 1
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.StarDeposit, "refund", paymentID, attempt),
+			Kind:   ledgerModel.StarDeposit,
+			Debit:  ledgerModel.UserAccount(deposit.UserID),
+			Credit: ledgerModel.ExternalAccount,
+			Amount: credited,
+		}
+		if err = s.ledgerService.Post(ctx, posting); err != nil {
+			return err
+		}
+
+		refundingAt := time.Now()
+		return s.repo.UpdateStarRefunding(ctx, deposit.ID, &refundingAt, attempt)
+	})
+	if errTx != nil {
+		return nil, errTx
+	}
+
+	return deposit, nil
+}
+
+// cancelStarRefund reverses the debit of the refund rejected by telegram, so the refund may be tried again
+func (s *service) cancelStarRefund(ctx context.Context, paymentID string) error {
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		deposit, err := s.lockStarRefund(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+		if deposit.RefundingAt == nil {
+			return nil
+		}
+
+		debitKey := ledgerModel.Key(ledgerModel.StarDeposit, "refund", paymentID, deposit.RefundAttempts)
+		debited, err := s.ledgerService.GetPostedAmount(ctx, debitKey)
+		if err != nil {
+			return err
+		}
+
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.StarDeposit, "refund_reversal", paymentID, deposit.RefundAttempts),
+			Kind:   ledgerModel.StarDeposit,
+			Debit:  ledgerModel.ExternalAccount,
+			Credit: ledgerModel.UserAccount(deposit.UserID),
+			Amount: debited,
+		}
+		if err = s.ledgerService.Post(ctx, posting); err != nil {
+			return err
+		}
+
+		return s.repo.UpdateStarRefunding(ctx, deposit.ID, nil, deposit.RefundAttempts)
+	})
+}
+
+// lockStarRefund returns the paid deposit locked in the tx, ErrStarDepositRefunded once the refund is done
+func (s *service) lockStarRefund(ctx context.Context, paymentID string) (*dbModels.StarDepositDB, error) {
+	deposit, err := s.repo.GetStarByPaymentID(ctx, paymentID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrStarDepositNotFound
+		}
+		return nil, err
+	}
+	if deposit.RefundedAt != nil {
+		return nil, ErrStarDepositRefunded
+	}
+
+	return deposit, nil
+}
+
+// confirmNft gives the nft to the user once it is verified on chain. The transfer is nil
+// for deposits confirmed by admin, then the sender is not checked
+func (s *service) confirmNft(ctx context.Context, depositID uint, transfer *tonModel.NftTransfer) error {
+	// the deposit is read out of the tx to verify its item, then locked again by creditNft
+	dep, err := s.lockNft(ctx, depositID)
+	if err != nil {
+		return err
+	}
+
+	nft, collectionID, err := s.verifyNft(ctx, dep.NftAddress)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		return s.creditNft(ctx, depositID, transfer, nft, collectionID)
+	})
+}
+
+// creditNft closes the pending deposit and gives the verified nft to the user, it must be called in a tx
+func (s *service) creditNft(ctx context.Context, depositID uint, transfer *tonModel.NftTransfer, nft *models.Nft, collectionID uint) error {
+	dep, err := s.lockNft(ctx, depositID)
+	if err != nil {
+		return err
+	}
+	if transfer != nil && !isSameAddress(transfer.Sender, dep.Sender) {
+		return ErrSenderMismatch
+	}
+
+	isConfirmed := true
+	deposit := &dbModels.NftDepositDB{
+		IsConfirmed: &isConfirmed,
+	}
+	if transfer != nil {
+		deposit.TraceID = &transfer.TraceID
+	}
+	if err = s.repo.UpdateNft(ctx, depositID, deposit); err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return fmt.Errorf("nft deposit %d not found", depositID)
+		}
+		if database.IsKeyConflictErr(err) {
+			return ErrTransferCredited
+		}
+		return err
+	}
+
+	err = s.giftService.AddUserNft(ctx, dep.UserID, nft, collectionID)
+	if err != nil {
+		if database.IsKeyConflictErr(err) {
+			return fmt.Errorf("%w: %s", ErrNftHeld, nft.Address)
 		}
 		return err
 	}
@@ -167,7 +634,226 @@ func (s *service) AddNft(ctx context.Context, userID uint, sender string, nftAdd
 	return nil
 }
 
-func (s *service) addTon(ctx context.Context, userID uint, userBalance int, amount int, msgHash string, payload *string) error {
+// verifyNft checks by the get_nft_data get-method that the admin wallet owns the item now
+// and that its collection is accepted. It reads the chain, so it is called out of a tx.
+// It returns the item with the id of its collection
+func (s *service) verifyNft(ctx context.Context, nftAddress string) (*models.Nft, uint, error) {
+	data, err := s.tonService.GetNftData(ctx, nftAddress)
+	if err != nil {
+		if tonService.IsNftNotFound(err) {
+			return nil, 0, ErrNotNft
+		}
+		return nil, 0, fmt.Errorf("failed to get nft data %s: %w", nftAddress, err)
+	}
+	if !data.Initialized {
+		return nil, 0, ErrNotNft
+	}
+	if !s.tonService.IsAdminWallet(data.Owner) {
+		return nil, 0, ErrNftNotReceived
+	}
+
+	collectionID, ok, err := s.getCollectionID(ctx, data.Collection)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return nil, 0, ErrUnknownCollection
+	}
+
+	nft, err := s.tonService.GetNft(ctx, nftAddress)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get nft %s: %w", nftAddress, err)
+	}
+
+	return nft, collectionID, nil
+}
+
+// getCollectionID finds the collection by its raw address among the accepted collections
+func (s *service) getCollectionID(ctx context.Context, collectionAddress *string) (uint, bool, error) {
+	if collectionAddress == nil {
+		return 0, false, nil
+	}
+
+	collections, err := s.giftService.GetCollections(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get collections: %w", err)
+	}
+	for _, collection := range collections {
+		if collection.Address != nil && isSameAddress(*collection.Address, *collectionAddress) {
+			return uint(collection.ID), true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// rejectNft closes the pending deposit without giving the nft and stores the reason
+func (s *service) rejectNft(ctx context.Context, depositID uint, reason string) error {
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if _, err := s.lockNft(ctx, depositID); err != nil {
+			return err
+		}
+
+		isConfirmed := false
+		deposit := &dbModels.NftDepositDB{IsConfirmed: &isConfirmed}
+		if reason = strings.TrimSpace(reason); reason != "" {
+			deposit.Reason = &reason
+		}
+		return s.repo.UpdateNft(ctx, depositID, deposit)
+	})
+}
+
+// lockNft returns the pending deposit locked in the tx
+func (s *service) lockNft(ctx context.Context, depositID uint) (*dbModels.NftDepositDB, error) {
+	dep, err := s.repo.GetNft(ctx, depositID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrNftDepositNotFound
+		}
+		return nil, err
+	}
+	if dep.IsConfirmed != nil {
+		return nil, ErrNftDepositResolved
+	}
+
+	return dep, nil
+}
+
+func (s *service) processTonMessage(ctx context.Context, msg *tonModel.Message) error {
+	comment := msg.MsgContent.Decoded.Comment
+	if comment == nil || len(*comment) != 8 {
+		return nil
+	}
+
+	user, err := s.userService.GetUserByMemo(ctx, *comment)
+	if err != nil {
+		if userService.IsUserNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	amount, err := strconv.Atoi(msg.Value)
+	if err != nil || amount <= 0 {
+		log.Printf("skip ton deposit %s with value %s", msg.Hash, msg.Value)
+		return nil
+	}
+
+	if err = s.addTon(ctx, user.ID, amount, msg.Hash, nil); err != nil {
+		if IsTonDepositExists(err) {
+			return nil
+		}
+		if userService.IsUserNotFound(err) || ledgerService.IsUserNotFound(err) {
+			log.Printf("skip ton deposit %s, user %d not found", msg.Hash, user.ID)
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// processNftTransfer credits the item to the user whose memo is the forward payload comment,
+// or to the user who linked the sender wallet. Other transfers are left for the pre-registered deposits
+func (s *service) processNftTransfer(ctx context.Context, transfer *tonModel.NftTransfer) error {
+	userID, ok, err := s.getTransferUser(ctx, transfer)
+	if err != nil || !ok {
+		return err
+	}
+
+	// the item is read from chain before the tx, the tx holds only db locks
+	nft, collectionID, err := s.verifyNft(ctx, transfer.NftAddress)
+	if err == nil {
+		err = s.repo.RunInTx(ctx, func(ctx context.Context) error {
+			depositID, err := s.addNftTransfer(ctx, userID, transfer)
+			if err != nil {
+				return err
+			}
+			return s.creditNft(ctx, depositID, transfer, nft, collectionID)
+		})
+	}
+	switch {
+	case err == nil, IsNftDepositExists(err):
+		return nil
+	case IsNftNotReceived(err):
+		// the item has already left the admin wallet
+		log.Printf("skip nft transfer %s, %v", transfer.TraceID, err)
+		return nil
+	case IsNftRejected(err):
+		reason := err.Error()
+		return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+			depositID, err := s.addNftTransfer(ctx, userID, transfer)
+			if err != nil {
+				if IsNftDepositExists(err) {
+					return nil
+				}
+				return err
+			}
+			return s.rejectNft(ctx, depositID, reason)
+		})
+	default:
+		return err
+	}
+}
+
+// getTransferUser finds the user by the memo first, the memo of another user wins over a linked sender
+func (s *service) getTransferUser(ctx context.Context, transfer *tonModel.NftTransfer) (uint, bool, error) {
+	if comment := transfer.Comment; comment != nil && len(*comment) == 8 {
+		user, err := s.userService.GetUserByMemo(ctx, *comment)
+		if err == nil {
+			return user.ID, true, nil
+		}
+		if !userService.IsUserNotFound(err) {
+			return 0, false, err
+		}
+	}
+
+	userID, err := s.walletService.GetWalletUser(ctx, transfer.Sender)
+	if err != nil {
+		if walletService.IsWalletNotFound(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return userID, true, nil
+}
+
+// addNftTransfer records the pending deposit of the transfer, ErrNftDepositExists is returned
+// for a known trace id. The transfer wins over a deposit of the item registered by hand,
+// that one is rejected, so it must be called in a tx
+func (s *service) addNftTransfer(ctx context.Context, userID uint, transfer *tonModel.NftTransfer) (uint, error) {
+	registered, err := s.repo.GetRegisteredNfts(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, dep := range registered {
+		if !isSameAddress(dep.NftAddress, transfer.NftAddress) {
+			continue
+		}
+		reason := fmt.Sprintf("superseded by nft transfer %s", transfer.TraceID)
+		if err = s.rejectNft(ctx, dep.ID, reason); err != nil {
+			return 0, err
+		}
+	}
+
+	deposit := &dbModels.NftDepositDB{
+		UserID:     userID,
+		Sender:     transfer.Sender,
+		NftAddress: transfer.NftAddress,
+		TraceID:    &transfer.TraceID,
+	}
+	if err := s.repo.AddNft(ctx, deposit); err != nil {
+		if database.IsKeyConflictErr(err) {
+			return 0, ErrNftDepositExists
+		}
+		return 0, err
+	}
+
+	return deposit.ID, nil
+}
+
+func (s *service) addTon(ctx context.Context, userID uint, amount int, msgHash string, payload *string) error {
 	tonDeposit := &dbModels.TonDepositDB{
 		UserID:  userID,
 		Amount:  amount,
@@ -178,24 +864,70 @@ func (s *service) addTon(ctx context.Context, userID uint, userBalance int, amou
 	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
 		if err := s.repo.AddTon(ctx, tonDeposit); err != nil {
 			if database.IsKeyConflictErr(err) {
-				return fmt.Errorf("deposit %s is exists", msgHash)
+				return ErrTonDepositExists
 			}
 			if database.IsFKeyConflictError(err) {
-				return fmt.Errorf("user %s not found", userID)
+				return userService.ErrUserNotFound
 			}
 			return err
 		}
 
-		newBalance := userBalance + amount
-		if err := s.userService.UpdateUser(ctx, userID, nil, nil, &newBalance); err != nil {
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.TonDeposit, msgHash),
+			Kind:   ledgerModel.TonDeposit,
+			Debit:  ledgerModel.ExternalAccount,
+			Credit: ledgerModel.UserAccount(userID),
+			Amount: int64(amount),
+		}
+		if err := s.ledgerService.Post(ctx, posting); err != nil {
 			return err
 		}
 
 		return nil
 	})
 	if errTx != nil {
-		return fmt.Errorf("failed to execute ton tx: %s", errTx)
+		return fmt.Errorf("failed to execute ton tx: %w", errTx)
 	}
 
 	return nil
 }
+
+func (s *service) checkStarAmount(amount int) error {
+	if s.starConfig.Rate <= 0 {
+		return ErrStarsDisabled
+	}
+	if amount < s.starConfig.MinAmount || amount > s.starConfig.MaxAmount {
+		return ErrInvalidStarAmount
+	}
+	return nil
+}
+
+func (s *service) checkStarDeposit(deposit *dbModels.StarDepositDB, userID uint, amount int) error {
+	if deposit.PaymentID != nil {
+		return ErrStarDepositPaid
+	}
+	if deposit.UserID != userID || deposit.Amount != uint(amount) {
+		return ErrInvalidStarPayment
+	}
+	return nil
+}
+
+// isSameAddress compares addresses in any form by their raw form
+// isChargeRefunded reports whether telegram has refunded the payment before,
+// then the refund sent again after an unknown answer is done
+func isChargeRefunded(err error) bool {
+	var apiErr *bot.ApiError
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.Description, "CHARGE_ALREADY_REFUNDED")
+}
+
+func isSameAddress(a, b string) bool {
+	addrA, err := utils.GetAddress(a)
+	if err != nil {
+		return false
+	}
+	addrB, err := utils.GetAddress(b)
+	if err != nil {
+		return false
+	}
+	return addrA.StringRaw() == addrB.StringRaw()
+}
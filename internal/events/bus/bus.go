@@ -0,0 +1,36 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/config"
+	"roulette/internal/database"
+	"roulette/internal/events/model"
+)
+
+const (
+	Postgres = "postgres"
+	Memory   = "memory"
+)
+
+// Bus delivers game events between cmd/game and the API processes
+type Bus interface {
+	Publish(ctx context.Context, event *model.Event) error
+
+	// Listen blocks and calls f for every event until ctx is done
+	Listen(ctx context.Context, f func(event *model.Event)) error
+}
+
+func NewBus(cfg *config.Config, db *gorm.DB) (Bus, error) {
+	switch cfg.EventsConfig.Bus {
+	case Postgres, "":
+		return newPostgresBus(db, database.DSN(cfg), cfg.EventsConfig.Channel), nil
+	case Memory:
+		return NewMemoryBus(), nil
+	default:
+		return nil, fmt.Errorf("unknown events bus: %s", cfg.EventsConfig.Bus)
+	}
+}
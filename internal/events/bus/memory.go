@@ -0,0 +1,45 @@
+package bus
+
+import (
+	"context"
+	"sync"
+
+	"roulette/internal/events/model"
+)
+
+type memoryBus struct {
+	mu        sync.RWMutex
+	listeners map[int]func(event *model.Event)
+	nextID    int
+}
+
+// NewMemoryBus returns in-process bus, events don't leave the process
+func NewMemoryBus() Bus {
+	return &memoryBus{listeners: make(map[int]func(event *model.Event))}
+}
+
+func (b *memoryBus) Publish(ctx context.Context, event *model.Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, f := range b.listeners {
+		f(event)
+	}
+	return nil
+}
+
+func (b *memoryBus) Listen(ctx context.Context, f func(event *model.Event)) error {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.listeners[id] = f
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	delete(b.listeners, id)
+	b.mu.Unlock()
+
+	return ctx.Err()
+}
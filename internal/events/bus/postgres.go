@@ -0,0 +1,83 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+
+	"roulette/internal/database"
+	"roulette/internal/events/model"
+)
+
+const reconnectDelay = 3 * time.Second
+
+type postgresBus struct {
+	db      *gorm.DB
+	dsn     string
+	channel string
+}
+
+func newPostgresBus(db *gorm.DB, dsn string, channel string) Bus {
+	return &postgresBus{db: db, dsn: dsn, channel: channel}
+}
+
+func (b *postgresBus) Publish(ctx context.Context, event *model.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	db := database.FromContext(ctx, b.db)
+	if err = db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", b.channel, string(payload)).Error; err != nil {
+		return fmt.Errorf("failed to notify: %v", err)
+	}
+
+	return nil
+}
+
+func (b *postgresBus) Listen(ctx context.Context, f func(event *model.Event)) error {
+	for {
+		err := b.listen(ctx, f)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("events listener stopped: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *postgresBus) listen(ctx context.Context, f func(event *model.Event)) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen %s: %v", b.channel, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event model.Event
+		if err = json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Printf("failed to decode event: %v", err)
+			continue
+		}
+		f(&event)
+	}
+}
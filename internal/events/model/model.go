@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	RoundCreated EventType = "round_created"
+	BetPlaced    EventType = "bet_placed"
+	RoundStarted EventType = "round_started"
+	// RoundDrawing closes bets of the round
+	RoundDrawing EventType = "round_drawing"
+	WinnerPicked EventType = "winner_picked"
+	// RoundCanceled is settled without a winner when no tickets were bet, ton bets are refunded
+	RoundCanceled EventType = "round_canceled"
+)
+
+type Event struct {
+	Type    EventType       `json:"type"`
+	RoundID uint            `json:"roundId"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func NewEvent(eventType EventType, roundID uint, data interface{}) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Type: eventType, RoundID: roundID, Data: raw}, nil
+}
+
+type RoundCreatedData struct {
+	Hash string `json:"hash"`
+	Room string `json:"room"`
+}
+
+type BetPlacedData struct {
+	UserID  uint  `json:"userId"`
+	Tickets int   `json:"tickets"`
+	Bet     int64 `json:"bet"`
+}
+
+type RoundStartedData struct {
+	StartedAt time.Time `json:"startedAt"`
+}
+
+type WinnerPickedData struct {
+	UserID uint `json:"userId"`
+	Ticket int  `json:"ticket"`
+}
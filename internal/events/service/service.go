@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"roulette/internal/events/bus"
+	"roulette/internal/events/model"
+)
+
+const subscriberBuffer = 16
+
+type Service interface {
+	// Publish sends event to the bus, failures are only logged
+	Publish(ctx context.Context, eventType model.EventType, roundID uint, data interface{})
+
+	// Subscribe returns channel with events and func to unsubscribe
+	Subscribe() (<-chan *model.Event, func())
+
+	// Run fans out bus events to subscribers until ctx is done, then closes their channels
+	Run(ctx context.Context) error
+}
+
+type service struct {
+	bus bus.Bus
+
+	mu          sync.Mutex
+	subscribers map[chan *model.Event]struct{}
+	stopped     bool
+}
+
+func NewService(bus bus.Bus) Service {
+	return &service{
+		bus:         bus,
+		subscribers: make(map[chan *model.Event]struct{}),
+	}
+}
+
+func (s *service) Publish(ctx context.Context, eventType model.EventType, roundID uint, data interface{}) {
+	event, err := model.NewEvent(eventType, roundID, data)
+	if err != nil {
+		log.Printf("failed to create event %s: %v", eventType, err)
+		return
+	}
+
+	if err = s.bus.Publish(ctx, event); err != nil {
+		log.Printf("failed to publish event %s: %v", eventType, err)
+	}
+}
+
+func (s *service) Subscribe() (<-chan *model.Event, func()) {
+	ch := make(chan *model.Event, subscriberBuffer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		close(ch)
+		return ch, func() {}
+	}
+	s.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// the channel is already closed if Run is stopped
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *service) Run(ctx context.Context) error {
+	defer s.stop()
+	return s.bus.Listen(ctx, s.broadcast)
+}
+
+// stop ends the streams of subscribers, so they don't hold the server shutdown
+func (s *service) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (s *service) broadcast(event *model.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// slow subscriber, client will resync with GET /game/rooms/:room/round
+		}
+	}
+}
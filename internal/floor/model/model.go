@@ -0,0 +1,15 @@
+package model
+
+// Collection is a collection priced by the oracle, Address is nil for telegram only gifts
+type Collection struct {
+	ID      uint    `json:"id"`
+	Name    string  `json:"name"`
+	Address *string `json:"address"`
+}
+
+// Quote is the floor of one source in nanotons, Rejected is set for outliers left out of the floor
+type Quote struct {
+	Source   string `json:"source"`
+	Floor    int64  `json:"floor"`
+	Rejected bool   `json:"rejected,omitempty"`
+}
@@ -0,0 +1,104 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/floor/model"
+)
+
+type Repo interface {
+	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
+
+	GetCollections(ctx context.Context) ([]*model.Collection, error)
+
+	AddFloorHistory(ctx context.Context, history *dbModels.CollectionFloorHistoryDB) error
+
+	// UpdateFloor sets the floor of the collection as fresh
+	UpdateFloor(ctx context.Context, collectionID uint, floor int64) error
+
+	// MarkStale marks the collection stale when its floor is older than staleAfter by the db clock
+	MarkStale(ctx context.Context, collectionID uint, staleAfter time.Duration) error
+}
+
+type repo struct {
+	db *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repo {
+	return &repo{db: db}
+}
+
+func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, r.db, f)
+}
+
+func (r *repo) GetCollections(ctx context.Context) ([]*model.Collection, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var collections []*model.Collection
+	err := db.WithContext(ctx).
+		Model(&dbModels.CollectionDB{}).
+		Select("id", "name", "address").
+		Order("id").
+		Find(&collections).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+func (r *repo) AddFloorHistory(ctx context.Context, history *dbModels.CollectionFloorHistoryDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Select("collection_id", "floor", "quotes").
+		Create(history).Error
+	if err != nil {
+		if database.IsFKeyConflictError(err) {
+			return database.ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) UpdateFloor(ctx context.Context, collectionID uint, floor int64) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Model(&dbModels.CollectionDB{}).
+		Where("id = ?", collectionID).
+		Updates(map[string]interface{}{
+			"floor":            floor,
+			"floor_updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+			"is_stale":         false,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repo) MarkStale(ctx context.Context, collectionID uint, staleAfter time.Duration) error {
+	db := database.FromContext(ctx, r.db)
+
+	return db.WithContext(ctx).
+		Exec(`
+			UPDATE collections
+			SET is_stale = true
+			WHERE id = ?
+			  AND NOT is_stale
+			  AND (floor_updated_at IS NULL OR floor_updated_at < CURRENT_TIMESTAMP - make_interval(secs => ?))
+		`, collectionID, staleAfter.Seconds()).Error
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"roulette/internal/config"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/floor/model"
+	"roulette/internal/floor/repo"
+	"roulette/internal/floor/source"
+)
+
+type Service interface {
+	// Update prices every collection from all sources and writes the floor history.
+	// A collection without an agreed floor keeps its floor until it is older than staleAfter, then it is marked stale.
+	// Source errors are returned after the floors of the other sources are written
+	Update(ctx context.Context) error
+
+	// quotes runs the sources concurrently and returns their floors by source name
+	quotes(ctx context.Context, collections []*model.Collection) (map[string]map[uint]int64, error)
+
+	// aggregate rejects quotes further than maxDeviation from their median,
+	// the floor is the median of the rest when at least minSources are left
+	aggregate(quotes []*model.Quote) *int64
+
+	update(ctx context.Context, collection *model.Collection, quotes []*model.Quote) error
+}
+
+type service struct {
+	repo    repo.Repo
+	sources []source.Source
+
+	maxDeviation int64
+	minSources   int
+	staleAfter   time.Duration
+}
+
+func NewService(repo repo.Repo, sources []source.Source, cfg *config.Config) Service {
+	return &service{
+		repo:         repo,
+		sources:      sources,
+		maxDeviation: cfg.FloorConfig.MaxDeviation,
+		minSources:   max(cfg.FloorConfig.MinSources, 1),
+		staleAfter:   cfg.FloorConfig.StaleAfter,
+	}
+}
+
+func (s *service) Update(ctx context.Context) error {
+	collections, err := s.repo.GetCollections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get collections: %v", err)
+	}
+
+	floors, sourcesErr := s.quotes(ctx, collections)
+
+	var errs []error
+	if sourcesErr != nil {
+		errs = append(errs, sourcesErr)
+	}
+	for _, collection := range collections {
+		var quotes []*model.Quote
+		for _, src := range s.sources {
+			if floor, ok := floors[src.Name()][collection.ID]; ok {
+				quotes = append(quotes, &model.Quote{Source: src.Name(), Floor: floor})
+			}
+		}
+
+		if err = s.update(ctx, collection, quotes); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			errs = append(errs, fmt.Errorf("failed to update collection %d: %v", collection.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *service) quotes(ctx context.Context, collections []*model.Collection) (map[string]map[uint]int64, error) {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		floors = make(map[string]map[uint]int64, len(s.sources))
+		errs   []error
+	)
+	for _, src := range s.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// a failing source may still price part of the collections
+			res, err := src.Floors(ctx, collections)
+
+			mu.Lock()
+			defer mu.Unlock()
+			floors[src.Name()] = res
+			if err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %v", src.Name(), err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	return floors, errors.Join(errs...)
+}
+
+func (s *service) aggregate(quotes []*model.Quote) *int64 {
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	all := make([]int64, 0, len(quotes))
+	for _, quote := range quotes {
+		all = append(all, quote.Floor)
+	}
+	med := median(all)
+
+	accepted := make([]int64, 0, len(quotes))
+	for _, quote := range quotes {
+		diff := quote.Floor - med
+		if diff < 0 {
+			diff = -diff
+		}
+		// two far apart quotes reject each other, there is no way to tell the right one
+		if diff*100 > med*s.maxDeviation {
+			quote.Rejected = true
+			continue
+		}
+		accepted = append(accepted, quote.Floor)
+	}
+
+	if len(accepted) < s.minSources {
+		return nil
+	}
+
+	floor := median(accepted)
+	return &floor
+}
+
+func (s *service) update(ctx context.Context, collection *model.Collection, quotes []*model.Quote) error {
+	floor := s.aggregate(quotes)
+
+	if quotes == nil {
+		quotes = []*model.Quote{}
+	}
+	b, err := json.Marshal(quotes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quotes: %v", err)
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		err := s.repo.AddFloorHistory(ctx, &dbModels.CollectionFloorHistoryDB{
+			CollectionID: collection.ID,
+			Floor:        floor,
+			Quotes:       string(b),
+		})
+		if err != nil {
+			return err
+		}
+
+		if floor == nil {
+			return s.repo.MarkStale(ctx, collection.ID, s.staleAfter)
+		}
+		return s.repo.UpdateFloor(ctx, collection.ID, *floor)
+	})
+}
+
+// median of the floors, the mean of the middle two for an even count
+func median(floors []int64) int64 {
+	sorted := slices.Clone(floors)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return sorted[mid-1] + (sorted[mid]-sorted[mid-1])/2
+	}
+	return sorted[mid]
+}
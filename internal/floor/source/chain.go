@@ -0,0 +1,76 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"roulette/internal/config"
+	"roulette/internal/floor/model"
+	"roulette/internal/ton/provider"
+	tonService "roulette/internal/ton/service"
+)
+
+const (
+	ChainName = "chain"
+
+	// chainCheapest is how many of the cheapest sales the floor is the median of, one cheap sale is often a mistake
+	chainCheapest = 3
+)
+
+// chain prices collections by marketplace purchases of their items on chain
+type chain struct {
+	tonService tonService.Service
+	lookback   time.Duration
+	sales      int
+}
+
+func NewChain(tonService tonService.Service, cfg config.ChainFloorConfig) Source {
+	return &chain{
+		tonService: tonService,
+		lookback:   cfg.Lookback,
+		sales:      cfg.Sales,
+	}
+}
+
+func (s *chain) Name() string {
+	return ChainName
+}
+
+// Floors is the median of the cheapest sales within lookback, collections without an address are skipped
+func (s *chain) Floors(ctx context.Context, collections []*model.Collection) (map[uint]int64, error) {
+	since := time.Now().Add(-s.lookback)
+
+	res := make(map[uint]int64)
+	var errs []error
+	for _, collection := range collections {
+		if collection.Address == nil {
+			continue
+		}
+
+		sales, err := s.tonService.GetNftSales(ctx, *collection.Address, since, s.sales)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, provider.ErrNotSupported) {
+				return res, err
+			}
+			errs = append(errs, fmt.Errorf("collection %d: %v", collection.ID, err))
+			continue
+		}
+		if len(sales) == 0 {
+			continue
+		}
+
+		prices := make([]int64, 0, len(sales))
+		for _, sale := range sales {
+			prices = append(prices, sale.Price)
+		}
+		slices.Sort(prices)
+
+		cheapest := prices[:min(chainCheapest, len(prices))]
+		res[collection.ID] = cheapest[len(cheapest)/2]
+	}
+
+	return res, errors.Join(errs...)
+}
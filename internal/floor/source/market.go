@@ -0,0 +1,139 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xssnick/tonutils-go/tlb"
+
+	"roulette/internal/config"
+	"roulette/internal/floor/model"
+	"roulette/internal/ton/provider"
+)
+
+// market reads the floor of each collection from a marketplace http api,
+// requests are limited by the ton rate limit of the market name
+type market struct {
+	client    *provider.Client
+	name      string
+	url       string
+	apiKey    string
+	floorPath []string
+	inTon     bool
+}
+
+func NewMarket(client *provider.Client, cfg config.MarketFloorConfig) Source {
+	return &market{
+		client:    client,
+		name:      cfg.Name,
+		url:       cfg.Url,
+		apiKey:    cfg.ApiKey,
+		floorPath: strings.Split(cfg.FloorPath, "."),
+		inTon:     cfg.InTon,
+	}
+}
+
+func (s *market) Name() string {
+	return s.name
+}
+
+// Floors skips collections the market has no floor for, other failures are returned with the found floors
+func (s *market) Floors(ctx context.Context, collections []*model.Collection) (map[uint]int64, error) {
+	header := http.Header{}
+	if s.apiKey != "" {
+		header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	res := make(map[uint]int64)
+	var errs []error
+	for _, collection := range collections {
+		rawUrl, ok := s.collectionUrl(collection)
+		if !ok {
+			continue
+		}
+
+		var result interface{}
+		if err := s.client.GetJSON(ctx, s.name, rawUrl, nil, header, &result); err != nil {
+			if errors.Is(err, provider.ErrNotFound) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return res, err
+			}
+			errs = append(errs, fmt.Errorf("collection %d: %v", collection.ID, err))
+			continue
+		}
+
+		floor, err := s.floor(result)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("collection %d: %v", collection.ID, err))
+			continue
+		}
+		if floor > 0 {
+			res[collection.ID] = floor
+		}
+	}
+
+	return res, errors.Join(errs...)
+}
+
+// collectionUrl fills the url placeholders, a url with {address} is not used for collections without one
+func (s *market) collectionUrl(collection *model.Collection) (string, bool) {
+	rawUrl := s.url
+	if strings.Contains(rawUrl, "{address}") {
+		if collection.Address == nil {
+			return "", false
+		}
+		rawUrl = strings.ReplaceAll(rawUrl, "{address}", url.PathEscape(*collection.Address))
+	}
+	rawUrl = strings.ReplaceAll(rawUrl, "{name}", url.PathEscape(collection.Name))
+
+	return rawUrl, true
+}
+
+// floor follows floorPath in the response, a missing or null floor is zero
+func (s *market) floor(result interface{}) (int64, error) {
+	value := result
+	for _, key := range s.floorPath {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return 0, nil
+		}
+		value = obj[key]
+	}
+
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		raw = strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		raw = v
+	default:
+		return 0, fmt.Errorf("unexpected floor type %T", value)
+	}
+
+	if !s.inTon {
+		floor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse floor %q: %v", raw, err)
+		}
+		return floor, nil
+	}
+
+	floor, err := tlb.FromTON(raw)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert floor %q to nano: %v", raw, err)
+	}
+	if !floor.Nano().IsInt64() {
+		return 0, fmt.Errorf("floor %q is out of range", raw)
+	}
+
+	return floor.Nano().Int64(), nil
+}
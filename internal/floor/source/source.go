@@ -0,0 +1,15 @@
+package source
+
+import (
+	"context"
+
+	"roulette/internal/floor/model"
+)
+
+// Source prices collections, a collection the source can't price is left out of the result
+type Source interface {
+	Name() string
+
+	// Floors returns floors in nanotons by collection id
+	Floors(ctx context.Context, collections []*model.Collection) (map[uint]int64, error)
+}
@@ -0,0 +1,53 @@
+package source
+
+import (
+	"context"
+
+	"roulette/internal/config"
+	"roulette/internal/floor/model"
+	tgService "roulette/internal/tg/service"
+)
+
+const TelegramName = "telegram"
+
+// telegram parses the floors posted to a channel, collections are matched by name
+type telegram struct {
+	tgService  tgService.Service
+	channelID  int64
+	accessHash int64
+}
+
+func NewTelegram(tgService tgService.Service, cfg config.TelegramFloorConfig) Source {
+	return &telegram{
+		tgService:  tgService,
+		channelID:  cfg.ChannelID,
+		accessHash: cfg.AccessHash,
+	}
+}
+
+func (s *telegram) Name() string {
+	return TelegramName
+}
+
+func (s *telegram) Floors(ctx context.Context, collections []*model.Collection) (map[uint]int64, error) {
+	floors, err := s.tgService.GetFloorsLow(ctx, s.channelID, s.accessHash)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]int64, len(floors))
+	for _, floor := range floors {
+		if floor.Floor.IsInt64() {
+			byName[floor.Name] = floor.Floor.Int64()
+		}
+	}
+
+	res := make(map[uint]int64)
+	for _, collection := range collections {
+		if floor, ok := byName[collection.Name]; ok {
+			res[collection.ID] = floor
+		}
+	}
+
+	return res, nil
+}
@@ -3,27 +3,35 @@ package handler
 import (
 	"github.com/gin-gonic/gin"
 
+	eventsService "roulette/internal/events/service"
 	"roulette/internal/game/service"
 )
 
 type Handler struct {
-	service service.Service
+	service       service.Service
+	eventsService eventsService.Service
 }
 
-func NewHandler(service service.Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service service.Service, eventsService eventsService.Service) *Handler {
+	return &Handler{service: service, eventsService: eventsService}
 }
 
 func Router(h *Handler, r *gin.Engine) {
 	router := r.Group("game")
 	{
-		router.GET("/round", h.getCurrentRound)
-		//router.GET("/round/:round_id", h.getRound)
+		router.GET("/rooms", h.getRooms)
+		router.GET("/rooms/:room/round", h.getCurrentRound)
+		router.GET("/rounds", h.getRounds)
+		router.GET("/round/:round_id", h.getRound)
+		router.GET("/round/:round_id/proof", h.getRoundProof)
+		router.GET("/round/:round_id/bets", h.getRoundBets)
 		router.GET("/winner/:round_id", h.getWinner)
+		router.GET("/user/:user_id/history", h.getUserHistory)
+		router.GET("/leaderboard", h.getLeaderboard)
+		router.GET("/stream", h.stream)
 
 		router.POST("/nft", h.addUserNft)
 		router.POST("/gift", h.addUserGift)
-
-		router.PUT("/fee/:user_id", h.updateFee)
+		router.POST("/ton", h.addUserTon)
 	}
 }
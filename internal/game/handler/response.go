@@ -2,8 +2,19 @@ package handler
 
 import (
 	"roulette/internal/game/model"
+	"roulette/pkg/fair"
 )
 
+type RoomsResponse struct {
+	Rooms []*model.Room `json:"rooms"`
+}
+
+func NewRoomsResponse(rooms []*model.Room) *RoomsResponse {
+	return &RoomsResponse{
+		Rooms: rooms,
+	}
+}
+
 type RoundResponse struct {
 	*model.RoundWithPlayers
 }
@@ -23,3 +34,66 @@ func NewWinnerResponse(winner *model.Winner) *WinnerResponse {
 		Winner: winner,
 	}
 }
+
+type ProofResponse struct {
+	*fair.Proof
+}
+
+func NewProofResponse(proof *fair.Proof) *ProofResponse {
+	return &ProofResponse{
+		Proof: proof,
+	}
+}
+
+type BetsResponse struct {
+	Bets []*model.Bet `json:"bets"`
+}
+
+func NewBetsResponse(bets []*model.Bet) *BetsResponse {
+	return &BetsResponse{
+		Bets: bets,
+	}
+}
+
+type RoundsResponse struct {
+	Rounds []*model.RoundResult `json:"rounds"`
+	// NextCursor is the cursor of the next page, nil on the last page
+	NextCursor *uint `json:"nextCursor"`
+}
+
+func NewRoundsResponse(rounds []*model.RoundResult, limit int) *RoundsResponse {
+	res := &RoundsResponse{
+		Rounds: rounds,
+	}
+	if len(rounds) == limit {
+		res.NextCursor = &rounds[len(rounds)-1].ID
+	}
+	return res
+}
+
+type HistoryResponse struct {
+	Rounds     []*model.PlayerResult `json:"rounds"`
+	NextCursor *uint                 `json:"nextCursor"`
+}
+
+func NewHistoryResponse(results []*model.PlayerResult, limit int) *HistoryResponse {
+	res := &HistoryResponse{
+		Rounds: results,
+	}
+	if len(results) == limit {
+		res.NextCursor = &results[len(results)-1].RoundID
+	}
+	return res
+}
+
+type LeaderboardResponse struct {
+	Period  model.Period              `json:"period"`
+	Players []*model.LeaderboardEntry `json:"players"`
+}
+
+func NewLeaderboardResponse(period model.Period, entries []*model.LeaderboardEntry) *LeaderboardResponse {
+	return &LeaderboardResponse{
+		Period:  period,
+		Players: entries,
+	}
+}
@@ -7,14 +7,53 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"roulette/internal/database"
+	"roulette/internal/game/model"
 	"roulette/internal/game/service"
+	limitService "roulette/internal/limit/service"
 	"roulette/internal/middleware/handler"
+	pauseService "roulette/internal/pause/service"
 )
 
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// RequestPage pages by the id of the last round of the previous page
+type RequestPage struct {
+	Cursor uint `form:"cursor"`
+	Limit  int  `form:"limit"`
+}
+
+func (p *RequestPage) normalize() {
+	if p.Limit <= 0 || p.Limit > maxLimit {
+		p.Limit = defaultLimit
+	}
+}
+
+func (h *Handler) getRooms(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		rooms := h.service.GetRooms(c.Request.Context())
+
+		return handler.NewSuccessResponse(http.StatusOK, NewRoomsResponse(rooms))
+	})
+}
+
 func (h *Handler) getCurrentRound(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		round, err := h.service.GetCurrentRoundWithPlayers(c.Request.Context())
+		type RequestUri struct {
+			Room string `uri:"room"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		round, err := h.service.GetCurrentRoundWithPlayers(c.Request.Context(), uri.Room)
 		if err != nil {
+			if service.IsRoomNotFound(err) || service.IsRoundNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
 			if database.IsRecordNotFoundErr(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
 			}
@@ -37,7 +76,7 @@ func (h *Handler) getRound(c *gin.Context) {
 
 		round, err := h.service.GetRoundWithPlayers(c.Request.Context(), uri.RoundID)
 		if err != nil {
-			if database.IsRecordNotFoundErr(err) {
+			if service.IsRoundNotFound(err) || database.IsRecordNotFoundErr(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
 			}
 			return handler.NewInternalErrorResponse(err)
@@ -47,6 +86,83 @@ func (h *Handler) getRound(c *gin.Context) {
 	})
 }
 
+func (h *Handler) getRounds(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestQuery struct {
+			RequestPage
+			Room string `form:"room"`
+		}
+		var query RequestQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		query.normalize()
+
+		rounds, err := h.service.GetRoundResults(c.Request.Context(), query.Room, query.Cursor, query.Limit)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewRoundsResponse(rounds, query.Limit))
+	})
+}
+
+func (h *Handler) getUserHistory(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			UserID uint `uri:"user_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		var query RequestPage
+		if err := c.ShouldBindQuery(&query); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		query.normalize()
+
+		if res := handler.CheckCaller(c, uri.UserID); res != nil {
+			return res
+		}
+
+		results, err := h.service.GetUserResults(c.Request.Context(), uri.UserID, query.Cursor, query.Limit)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewHistoryResponse(results, query.Limit))
+	})
+}
+
+func (h *Handler) getLeaderboard(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestQuery struct {
+			Period  model.Period  `form:"period"`
+			Ranking model.Ranking `form:"ranking"`
+			Limit   int           `form:"limit"`
+		}
+		query := RequestQuery{Period: model.PeriodAll}
+		if err := c.ShouldBindQuery(&query); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+		if query.Limit <= 0 || query.Limit > maxLimit {
+			query.Limit = defaultLimit
+		}
+
+		entries, err := h.service.GetLeaderboard(c.Request.Context(), query.Period, query.Ranking, query.Limit)
+		if err != nil {
+			if service.IsInvalidLeaderboard(err) {
+				return handler.NewUnprocessableErrorResponse(err)
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewLeaderboardResponse(query.Period, entries))
+	})
+}
+
 func (h *Handler) getWinner(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
 		type RequestUri struct {
@@ -66,25 +182,94 @@ func (h *Handler) getWinner(c *gin.Context) {
 	})
 }
 
+func (h *Handler) getRoundProof(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			RoundID uint `uri:"round_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		proof, err := h.service.GetRoundProof(c.Request.Context(), uri.RoundID)
+		if err != nil {
+			if service.IsRoundNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, fmt.Sprintf("round %d not found", uri.RoundID))
+			}
+			if service.IsRoundNotFinished(err) {
+				return handler.NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("round %d is not finished yet", uri.RoundID))
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewProofResponse(proof))
+	})
+}
+
+func (h *Handler) getRoundBets(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			RoundID uint `uri:"round_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		bets, err := h.service.GetRoundBets(c.Request.Context(), uri.RoundID)
+		if err != nil {
+			if service.IsRoundNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, fmt.Sprintf("round %d not found", uri.RoundID))
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewBetsResponse(bets))
+	})
+}
+
 func (h *Handler) addUserNft(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
 		type RequestBody struct {
-			UserNftID uint `json:"userNftId"`
+			Room       string  `json:"room"`
+			UserNftID  uint    `json:"userNftId"`
+			ClientSeed *string `json:"clientSeed"`
 		}
 		var body RequestBody
 		if err := c.ShouldBindJSON(&body); err != nil {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
-		if err := h.service.AddUserNft(c.Request.Context(), body.UserNftID); err != nil {
-			if database.IsRecordNotFoundErr(err) {
+		if err := h.service.AddUserNft(c.Request.Context(), userID, body.Room, body.UserNftID, body.ClientSeed); err != nil {
+			if database.IsRecordNotFoundErr(err) || service.IsRoomNotFound(err) || limitService.IsUserNotFound(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
 			}
+			if limitService.IsExcluded(err) {
+				return handler.NewErrorResponse(http.StatusForbidden, err.Error())
+			}
+			if limitService.IsLimitReached(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if service.IsBetNotAllowed(err) || service.IsAlreadyBet(err) || service.IsStaleFloor(err) || service.IsFeePending(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if pauseService.IsPaused(err) {
+				return handler.NewErrorResponse(http.StatusServiceUnavailable, "betting is paused")
+			}
 			if service.IsRoundNotFound(err) {
 				return handler.NewInternalErrorResponse(err)
 			}
 			if service.IsRoundFinished(err) {
-				return handler.NewInternalErrorResponse(err)
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if service.IsInvalidClientSeed(err) {
+				return handler.NewUnprocessableErrorResponse(err)
 			}
 			return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
 		}
@@ -95,23 +280,45 @@ func (h *Handler) addUserNft(c *gin.Context) {
 
 func (h *Handler) addUserGift(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
 		type RequestBody struct {
-			UserGiftID uint `json:"userGiftId"`
+			Room       string  `json:"room"`
+			UserGiftID uint    `json:"userGiftId"`
+			ClientSeed *string `json:"clientSeed"`
 		}
 		var body RequestBody
 		if err := c.ShouldBindJSON(&body); err != nil {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
-		if err := h.service.AddUserGift(c.Request.Context(), body.UserGiftID); err != nil {
-			if database.IsRecordNotFoundErr(err) {
+		if err := h.service.AddUserGift(c.Request.Context(), userID, body.Room, body.UserGiftID, body.ClientSeed); err != nil {
+			if database.IsRecordNotFoundErr(err) || service.IsRoomNotFound(err) || limitService.IsUserNotFound(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
 			}
+			if limitService.IsExcluded(err) {
+				return handler.NewErrorResponse(http.StatusForbidden, err.Error())
+			}
+			if limitService.IsLimitReached(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if service.IsBetNotAllowed(err) || service.IsAlreadyBet(err) || service.IsStaleFloor(err) || service.IsFeePending(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if pauseService.IsPaused(err) {
+				return handler.NewErrorResponse(http.StatusServiceUnavailable, "betting is paused")
+			}
 			if service.IsRoundNotFound(err) {
 				return handler.NewInternalErrorResponse(err)
 			}
 			if service.IsRoundFinished(err) {
-				return handler.NewInternalErrorResponse(err)
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if service.IsInvalidClientSeed(err) {
+				return handler.NewUnprocessableErrorResponse(err)
 			}
 			return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
 		}
@@ -120,26 +327,54 @@ func (h *Handler) addUserGift(c *gin.Context) {
 	})
 }
 
-func (h *Handler) updateFee(c *gin.Context) {
+func (h *Handler) addUserTon(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
-		type RequestUri struct {
-			UserID uint `uri:"user_id"`
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
 		}
-		var uri RequestUri
-		if err := c.ShouldBindUri(&uri); err != nil {
+
+		type RequestBody struct {
+			Room       string  `json:"room"`
+			Amount     int64   `json:"amount"`
+			ClientSeed *string `json:"clientSeed"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
-		if err := h.service.UpdateFee(c.Request.Context(), uri.UserID); err != nil {
+		if err := h.service.AddUserTon(c.Request.Context(), userID, body.Room, body.Amount, body.ClientSeed); err != nil {
+			if database.IsRecordNotFoundErr(err) || service.IsRoomNotFound(err) || limitService.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			if pauseService.IsPaused(err) {
+				return handler.NewErrorResponse(http.StatusServiceUnavailable, "betting is paused")
+			}
 			if service.IsNotEnoughBalance(err) {
-				return handler.NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("user %d has insufficient balance", uri.UserID))
+				return handler.NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("user %d has insufficient balance", userID))
 			}
-			if database.IsRecordNotFoundErr(err) {
-				return handler.NewErrorResponse(http.StatusNotFound, fmt.Sprintf("user %d not found", uri.UserID))
+			if limitService.IsExcluded(err) {
+				return handler.NewErrorResponse(http.StatusForbidden, err.Error())
 			}
-			return handler.NewInternalErrorResponse(err)
+			if limitService.IsLimitReached(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if service.IsBetNotAllowed(err) || service.IsFeePending(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if service.IsRoundNotFound(err) {
+				return handler.NewInternalErrorResponse(err)
+			}
+			if service.IsRoundFinished(err) {
+				return handler.NewErrorResponse(http.StatusConflict, err.Error())
+			}
+			if service.IsInvalidClientSeed(err) || service.IsInvalidAmount(err) {
+				return handler.NewUnprocessableErrorResponse(err)
+			}
+			return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
 		}
 
-		return handler.NewSuccessResponse(http.StatusNoContent, nil)
+		return handler.NewSuccessResponse(http.StatusCreated, nil)
 	})
 }
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/game/service"
+	"roulette/pkg/fair"
+)
+
+// proofService serves a proof made like a finished round, the other methods are not used
+type proofService struct {
+	service.Service
+
+	proof *fair.Proof
+}
+
+func (s *proofService) GetRoundProof(_ context.Context, roundID uint) (*fair.Proof, error) {
+	if roundID != s.proof.RoundID {
+		return nil, service.ErrRoundNotFound
+	}
+	return s.proof, nil
+}
+
+func newProof(t *testing.T, roundID uint) *fair.Proof {
+	t.Helper()
+
+	serverSeed, err := fair.NewSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := "a:b", "c"
+	ranges := fair.Ranges([]uint{1, 2, 3}, []int{3, 1, 6}, []*string{&first, nil, &second})
+	clientSeed := fair.RangesClientSeed(ranges)
+	roundNumber := fair.RoundNumber(serverSeed, roundID, clientSeed)
+	ticket := fair.WinningTicket(roundNumber, 10)
+	winnerID, ok := fair.Winner(ranges, ticket)
+	if !ok {
+		t.Fatalf("no winner of ticket %d", ticket)
+	}
+
+	return &fair.Proof{
+		RoundID:      roundID,
+		ServerSeed:   serverSeed,
+		Hash:         fair.Commit(serverSeed),
+		ClientSeed:   clientSeed,
+		RoundNumber:  fair.FormatRoundNumber(roundNumber),
+		TotalTickets: 10,
+		Ranges:       ranges,
+		WinnerID:     winnerID,
+		Ticket:       ticket,
+	}
+}
+
+// TestGetRoundProofVerify checks that the proof endpoint payload is verified as a client decodes it
+func TestGetRoundProofVerify(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	Router(NewHandler(&proofService{proof: newProof(t, 7)}, nil), r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/game/round/7/proof", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var proof fair.Proof
+	if err := json.Unmarshal(w.Body.Bytes(), &proof); err != nil {
+		t.Fatal(err)
+	}
+	if err := fair.Verify(&proof); err != nil {
+		t.Fatalf("Verify() = %v for payload %s", err, w.Body)
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// stream pushes round events as SSE, see events/model for event types.
+// EventSource sends init data in the middleware.InitDataQuery param
+func (h *Handler) stream(c *gin.Context) {
+	events, unsubscribe := h.eventsService.Subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(streamHeartbeat)
+	defer ticker.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+			return true
+		case <-ticker.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
@@ -1,29 +1,80 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	dbModels "roulette/internal/database/models"
+	valuationModel "roulette/internal/valuation/model"
+)
 
 type Round struct {
-	ID          uint       `json:"id"`
-	RoundNumber string     `json:"-"`
-	Secret      string     `json:"-"`
-	Hash        string     `json:"hash"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	StartedAt   *time.Time `json:"startedAt"`
-	IsFinished  bool       `json:"-"`
+	ID          uint                 `json:"id"`
+	RoundNumber string               `json:"-"`
+	Secret      string               `json:"-"`
+	Hash        string               `json:"hash"`
+	Room        string               `json:"room"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	StartedAt   *time.Time           `json:"startedAt"`
+	Status      dbModels.RoundStatus `json:"status"`
+	// EndsAt is set from the countdown start, IsExpired and RemainingSecs are by the db clock
+	EndsAt        *time.Time `json:"endsAt"`
+	IsExpired     bool       `json:"-"`
+	RemainingSecs float64    `json:"-"`
+	Rules
+}
+
+// Rules are the game config snapshotted on the round
+type Rules struct {
+	DurationSecs    int   `json:"durationSecs"`
+	MinPlayers      int   `json:"minPlayers"`
+	HouseFee        int64 `json:"houseFee"`
+	ReferralFee     int64 `json:"-"`
+	SpecReferralFee int64 `json:"-"`
+	TicketPrice     int64 `json:"ticketPrice"`
+	// MinBet and MaxBet bound the value of one bet, zero is unbounded
+	MinBet    int64 `json:"minBet"`
+	MaxBet    int64 `json:"maxBet"`
+	GiftsOnly bool  `json:"giftsOnly"`
+}
+
+// IsBetting reports whether the round takes bets
+func (r *Round) IsBetting() bool {
+	return r.Status == dbModels.RoundOpen || (r.Status == dbModels.RoundCountdown && !r.IsExpired)
+}
+
+// Remaining is the countdown left by the db clock
+func (r *Round) Remaining() time.Duration {
+	return time.Duration(r.RemainingSecs * float64(time.Second))
+}
+
+func (r Rules) Duration() time.Duration {
+	return time.Duration(r.DurationSecs) * time.Second
+}
+
+// DefaultRoom is the only room when none are configured, rounds before rooms belong to it
+const DefaultRoom = "main"
+
+// Room runs its own rounds with its own rules
+type Room struct {
+	Name string `json:"name"`
+	Rules
 }
 
 type RoundStats struct {
-	TotalGifts   int   `json:"totalGifts"`
-	TotalBet     int64 `json:"totalBet"`
+	TotalGifts int   `json:"totalGifts"`
+	TotalBet   int64 `json:"totalBet"`
+	// TotalTon is the part of TotalBet bet in ton
+	TotalTon     int64 `json:"totalTon"`
 	TotalTickets int   `json:"totalTickets"`
 }
 
 type Player struct {
-	ID        uint      `json:"id"`
-	UserID    uint      `json:"userId"`
-	RoundID   uint      `json:"roundId"`
-	Tickets   int       `json:"tickets"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID         uint      `json:"id"`
+	UserID     uint      `json:"userId"`
+	RoundID    uint      `json:"roundId"`
+	Tickets    int       `json:"tickets"`
+	ClientSeed *string   `json:"clientSeed"`
+	CreatedAt  time.Time `json:"createdAt"`
 }
 
 type UniquePlayer struct {
@@ -50,11 +101,105 @@ type Winner struct {
 type Gift struct {
 	ID     uint
 	UserID uint
+	// ItemID is the nft or gift held by the user item
+	ItemID int64
 	Floor  int64
+	// IsBet is set while the item is in a round without a winner
+	IsBet bool
+	// IsStale is set when the floor oracle can't price the collection, an item without a collection is stale
+	IsStale      bool
+	CollectionID *uint
+	// Attributes is json of []models.Attribute, nil when unknown
+	Attributes *string
+}
+
+type UnpaidWinner struct {
+	ID              uint
+	UserID          uint
+	RoundID         uint
+	Fee             int64
+	ReferralFee     int64
+	SpecReferralFee int64
+	FeeDueAt        *time.Time
+}
+
+// Item is an nft or a gift valued at its floor
+type Item struct {
+	ID    uint
+	IsNft bool
+	Floor int64
+}
+
+// Bet is an nft or gift bet of a round. UserID is nil and Valuation is nil for bets of older rounds,
+// ItemID is nil once the item is withdrawn
+type Bet struct {
+	UserID        *uint                     `json:"userId"`
+	ItemID        *uint                     `json:"itemId"`
+	IsNft         bool                      `json:"isNft"`
+	Name          string                    `json:"name"`
+	CollectibleID uint                      `json:"collectibleId"`
+	Bet           int64                     `json:"bet"`
+	RawValuation  *string                   `json:"-" gorm:"column:valuation"`
+	Valuation     *valuationModel.Valuation `json:"valuation" gorm:"-"`
 }
 
 type Referrer struct {
-	ID      uint  `json:"id"`
-	Balance int64 `json:"balance"`
-	IsSpec  bool  `json:"is_spec"`
+	ID     uint `json:"id"`
+	IsSpec bool `json:"is_spec"`
+}
+
+// RoundResult is a settled round with its winner, Chance is the share of tickets of the winner
+type RoundResult struct {
+	ID             uint      `json:"id"`
+	Room           string    `json:"room"`
+	Hash           string    `json:"hash"`
+	Pot            int64     `json:"pot"`
+	TotalTickets   int       `json:"totalTickets"`
+	WinnerID       uint      `json:"winnerId"`
+	WinnerName     *string   `json:"winnerName"`
+	WinnerPhotoUrl *string   `json:"winnerPhotoUrl"`
+	WinnerTickets  int       `json:"winnerTickets"`
+	Chance         float64   `json:"chance"`
+	SettledAt      time.Time `json:"settledAt"`
+}
+
+// PlayerResult is a settled round of one player
+type PlayerResult struct {
+	RoundID   uint      `json:"roundId"`
+	Room      string    `json:"room"`
+	Bet       int64     `json:"bet"`
+	Tickets   int       `json:"tickets"`
+	Chance    float64   `json:"chance"`
+	IsWinner  bool      `json:"isWinner"`
+	Won       int64     `json:"won"`
+	SettledAt time.Time `json:"settledAt"`
+}
+
+// Period of the leaderboard, days and weeks start at midnight utc
+type Period string
+
+const (
+	PeriodDay  Period = "day"
+	PeriodWeek Period = "week"
+	PeriodAll  Period = "all"
+)
+
+// Ranking is the stat the leaderboard is ordered by
+type Ranking string
+
+const (
+	RankingVolume     Ranking = "volume"
+	RankingWins       Ranking = "wins"
+	RankingBiggestWin Ranking = "biggestWin"
+)
+
+type LeaderboardEntry struct {
+	Rank       int     `json:"rank"`
+	UserID     uint    `json:"userId"`
+	Name       *string `json:"name"`
+	PhotoUrl   *string `json:"photoUrl"`
+	Volume     int64   `json:"volume"`
+	Rounds     int     `json:"rounds"`
+	Wins       int     `json:"wins"`
+	BiggestWin int64   `json:"biggestWin"`
 }
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"fmt"
 
 	"roulette/internal/database"
 	dbModels "roulette/internal/database/models"
@@ -13,17 +14,56 @@ func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) e
 	return database.RunInTx(ctx, r.db, f)
 }
 
-func (r *repo) GetCurrentRound(ctx context.Context) (*model.Round, error) {
+func (r *repo) GetCurrentRound(ctx context.Context, room string) (*model.Round, error) {
 	db := database.FromContext(ctx, r.db)
 
 	var round *model.Round
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT id, round AS round_number, secret, hash, created_at, started_at, CURRENT_TIMESTAMP > started_at + INTERVAL '3 minutes' AS is_finished
+			SELECT id, round AS round_number, secret, hash, room, status, created_at, started_at,
+				   duration_secs, min_players, house_fee, referral_fee, spec_referral_fee, ticket_price,
+				   min_bet, max_bet, gifts_only,
+				   started_at + duration_secs * INTERVAL '1 second' AS ends_at,
+				   COALESCE(CURRENT_TIMESTAMP >= started_at + duration_secs * INTERVAL '1 second', false) AS is_expired,
+				   COALESCE(GREATEST(EXTRACT(EPOCH FROM started_at + duration_secs * INTERVAL '1 second' - CURRENT_TIMESTAMP), 0), 0) AS remaining_secs
 			FROM rounds
+			WHERE room = $1
 			ORDER BY created_at DESC
 			LIMIT 1
-		`).
+		`, room).
+		First(&round).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return round, nil
+}
+
+func (r *repo) LockCurrentRound(ctx context.Context, room string, exclusive bool) (*model.Round, error) {
+	db := database.FromContext(ctx, r.db)
+
+	lock := "FOR SHARE"
+	if exclusive {
+		lock = "FOR UPDATE"
+	}
+
+	var round *model.Round
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT id, round AS round_number, secret, hash, room, status, created_at, started_at,
+				   duration_secs, min_players, house_fee, referral_fee, spec_referral_fee, ticket_price,
+				   min_bet, max_bet, gifts_only,
+				   started_at + duration_secs * INTERVAL '1 second' AS ends_at,
+				   COALESCE(CURRENT_TIMESTAMP >= started_at + duration_secs * INTERVAL '1 second', false) AS is_expired,
+				   COALESCE(GREATEST(EXTRACT(EPOCH FROM started_at + duration_secs * INTERVAL '1 second' - CURRENT_TIMESTAMP), 0), 0) AS remaining_secs
+			FROM rounds
+			WHERE room = $1
+			ORDER BY created_at DESC
+			LIMIT 1
+		`+lock, room).
 		First(&round).Error
 	if err != nil {
 		if database.IsRecordNotFoundErr(err) {
@@ -41,7 +81,12 @@ func (r *repo) GetRound(ctx context.Context, roundID uint) (*model.Round, error)
 	var round *model.Round
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT id, round AS round_number, secret, hash, created_at, started_at, CURRENT_TIMESTAMP > started_at + INTERVAL '3 minutes' AS is_finished
+			SELECT id, round AS round_number, secret, hash, room, status, created_at, started_at,
+				   duration_secs, min_players, house_fee, referral_fee, spec_referral_fee, ticket_price,
+				   min_bet, max_bet, gifts_only,
+				   started_at + duration_secs * INTERVAL '1 second' AS ends_at,
+				   COALESCE(CURRENT_TIMESTAMP >= started_at + duration_secs * INTERVAL '1 second', false) AS is_expired,
+				   COALESCE(GREATEST(EXTRACT(EPOCH FROM started_at + duration_secs * INTERVAL '1 second' - CURRENT_TIMESTAMP), 0), 0) AS remaining_secs
 			FROM rounds
 			WHERE id = $1
 		`, roundID).
@@ -63,17 +108,20 @@ func (r *repo) GetRoundStats(ctx context.Context, roundID uint) (*model.RoundSta
 	err := db.WithContext(ctx).
 		Raw(`
 			SELECT 
-                COALESCE(COUNT(bet), 0) AS total_gifts,
+                COALESCE(COUNT(bet) FILTER (WHERE NOT is_ton), 0) AS total_gifts,
                 COALESCE(SUM(bet), 0) AS total_bet,
+                COALESCE(SUM(bet) FILTER (WHERE is_ton), 0) AS total_ton,
                 COALESCE((
                     SELECT SUM(tickets) 
                     FROM rounds_tickets 
                     WHERE round_id = $1
                 ), 0) AS total_tickets
             FROM (
-                SELECT bet FROM rounds_nfts WHERE round_id = $1
+                SELECT bet, false AS is_ton FROM rounds_nfts WHERE round_id = $1
                 UNION ALL
-                SELECT bet FROM rounds_gifts WHERE round_id = $1
+                SELECT bet, false AS is_ton FROM rounds_gifts WHERE round_id = $1
+                UNION ALL
+                SELECT amount AS bet, true AS is_ton FROM rounds_ton_bets WHERE round_id = $1
             ) AS bets
 		`, roundID).
 		Scan(&stats).Error
@@ -90,10 +138,10 @@ func (r *repo) GetPlayers(ctx context.Context, roundID uint) ([]*model.Player, e
 	var players []*model.Player
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT rt.id AS id, rt.user_id AS user_id, rt.round_id AS round_id, rt.tickets AS tickets, rt.created_at AS created_at
+			SELECT rt.id AS id, rt.user_id AS user_id, rt.round_id AS round_id, rt.tickets AS tickets, rt.client_seed AS client_seed, rt.created_at AS created_at
 			FROM rounds_tickets rt
 			WHERE round_id = $1
-			ORDER BY tickets DESC
+			ORDER BY rt.id
 		`, roundID).
 		Scan(&players).Error
 	if len(players) == 0 {
@@ -138,7 +186,7 @@ func (r *repo) GetRoundNftIDs(ctx context.Context, roundID uint) ([]uint, error)
 		Raw(`
 			SELECT rn.user_nft_id AS id
 			FROM rounds_nfts rn
-			WHERE rn.round_id = $1
+			WHERE rn.round_id = $1 AND rn.user_nft_id IS NOT NULL
 		`, roundID).
 		Scan(&nftIDs).Error
 	if len(nftIDs) == 0 {
@@ -159,7 +207,7 @@ func (r *repo) GetRoundGiftIDs(ctx context.Context, roundID uint) ([]uint, error
 		Raw(`
 			SELECT rg.user_gift_id AS id
 			FROM rounds_gifts rg
-			WHERE rg.round_id = $1
+			WHERE rg.round_id = $1 AND rg.user_gift_id IS NOT NULL
 		`, roundID).
 		Scan(&giftIDs).Error
 	if len(giftIDs) == 0 {
@@ -172,17 +220,43 @@ func (r *repo) GetRoundGiftIDs(ctx context.Context, roundID uint) ([]uint, error
 	return giftIDs, nil
 }
 
+func (r *repo) GetRoundTonBet(ctx context.Context, roundID uint) (int64, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var amount int64
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT COALESCE(SUM(amount), 0)
+			FROM rounds_ton_bets
+			WHERE round_id = $1
+		`, roundID).
+		Scan(&amount).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return amount, nil
+}
+
 func (r *repo) GetUserNft(ctx context.Context, userNftID uint) (*model.Gift, error) {
 	db := database.FromContext(ctx, r.db)
 
 	var userNft *model.Gift
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT un.id AS id, un.user_id AS user_id, c.floor AS floor
+			SELECT un.id AS id, un.user_id AS user_id, un.nft_id AS item_id, COALESCE(c.floor, 0) AS floor,
+				   COALESCE(c.is_stale, true) AS is_stale, c.id AS collection_id, n.attributes AS attributes,
+				   EXISTS (
+				       SELECT 1
+				       FROM rounds_nfts rn
+				           JOIN rounds r ON r.id = rn.round_id
+				       WHERE rn.user_nft_id = un.id AND r.status <> 'settled'
+				   ) AS is_bet
 			FROM users_nfts un
 				LEFT OUTER JOIN nfts n ON n.id = un.nft_id
 				LEFT OUTER JOIN collections c ON n.collection_id = c.id
 			WHERE un.id = $1
+			FOR UPDATE OF un
 		`, userNftID).
 		First(&userNft).Error
 	if err != nil {
@@ -201,13 +275,21 @@ func (r *repo) GetUserGift(ctx context.Context, userGiftID uint) (*model.Gift, e
 	var userGift *model.Gift
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT ug.id AS id, ug.user_id AS user_id, c.floor AS floor
+			SELECT ug.id AS id, ug.user_id AS user_id, ug.gift_id AS item_id, COALESCE(c.floor, 0) AS floor,
+				   COALESCE(c.is_stale, true) AS is_stale, c.id AS collection_id, g.attributes AS attributes,
+				   EXISTS (
+				       SELECT 1
+				       FROM rounds_gifts rg
+				           JOIN rounds r ON r.id = rg.round_id
+				       WHERE rg.user_gift_id = ug.id AND r.status <> 'settled'
+				   ) AS is_bet
 			FROM users_gifts ug
 				LEFT OUTER JOIN gifts g ON g.id = ug.gift_id
 				LEFT OUTER JOIN collections c ON g.collection_id = c.id
 			WHERE ug.id = $1
+			FOR UPDATE OF ug
 		`, userGiftID).
-		Scan(&userGift).Error
+		First(&userGift).Error
 	if err != nil {
 		if database.IsRecordNotFoundErr(err) {
 			return nil, database.ErrNotFound
@@ -235,41 +317,176 @@ func (r *repo) GetWinner(ctx context.Context, roundID uint) (*model.Winner, erro
 	return winner, nil
 }
 
-func (r *repo) GetWinnerFee(ctx context.Context, userID uint) (int64, error) {
+func (r *repo) GetPendingFee(ctx context.Context, userID uint) (int64, error) {
 	db := database.FromContext(ctx, r.db)
 
 	var fee int64
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT COALESCE(SUM(fee), 0) AS fee
-			FROM rounds_winners rw
-			WHERE rw.user_id = $1 AND rw.is_paid IS NULL
-			GROUP BY rw.user_id
+			SELECT COALESCE(SUM(fee), 0)
+			FROM rounds_winners
+			WHERE user_id = $1 AND is_paid IS NULL
 		`, userID).
 		Scan(&fee).Error
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 
 	return fee, nil
 }
 
+const pendingWinnersQuery = `
+	SELECT rw.id AS id, rw.user_id AS user_id, rw.round_id AS round_id, rw.fee AS fee, rw.fee_due_at AS fee_due_at,
+		   r.referral_fee AS referral_fee, r.spec_referral_fee AS spec_referral_fee
+	FROM rounds_winners rw
+		JOIN rounds r ON r.id = rw.round_id
+	WHERE rw.is_paid IS NULL
+`
+
+func (r *repo) GetPendingWinners(ctx context.Context, limit int) ([]*model.UnpaidWinner, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var winners []*model.UnpaidWinner
+	err := db.WithContext(ctx).
+		Raw(pendingWinnersQuery+`
+			ORDER BY rw.fee_due_at NULLS FIRST, rw.id
+			LIMIT $1
+		`, limit).
+		Scan(&winners).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return winners, nil
+}
+
+func (r *repo) LockPendingWinner(ctx context.Context, winnerID uint) (*model.UnpaidWinner, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var winners []*model.UnpaidWinner
+	err := db.WithContext(ctx).
+		Raw(pendingWinnersQuery+`
+			AND rw.id = $1
+			FOR UPDATE OF rw SKIP LOCKED
+		`, winnerID).
+		Scan(&winners).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(winners) == 0 {
+		return nil, database.ErrNotFound
+	}
+
+	return winners[0], nil
+}
+
+func (r *repo) GetRoundBets(ctx context.Context, roundID uint) ([]*model.Bet, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var bets []*model.Bet
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT rn.user_id, rn.user_nft_id AS item_id, true AS is_nft, n.name, n.collectible_id,
+				   rn.bet, rn.valuation, rn.id AS bet_id
+			FROM rounds_nfts rn
+				JOIN nfts n ON n.id = rn.nft_id
+			WHERE rn.round_id = $1
+			UNION ALL
+			SELECT rg.user_id, rg.user_gift_id AS item_id, false AS is_nft, g.name, g.collectible_id,
+				   rg.bet, rg.valuation, rg.id AS bet_id
+			FROM rounds_gifts rg
+				JOIN gifts g ON g.id = rg.gift_id
+			WHERE rg.round_id = $1
+			ORDER BY is_nft DESC, bet_id
+		`, roundID).
+		Scan(&bets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return bets, nil
+}
+
+func (r *repo) GetRoundItems(ctx context.Context, roundID uint) ([]*model.Item, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var items []*model.Item
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT user_nft_id AS id, true AS is_nft, bet AS floor
+			FROM rounds_nfts
+			WHERE round_id = $1 AND user_nft_id IS NOT NULL
+			UNION ALL
+			SELECT user_gift_id AS id, false AS is_nft, bet AS floor
+			FROM rounds_gifts
+			WHERE round_id = $1 AND user_gift_id IS NOT NULL
+			ORDER BY floor, id
+		`, roundID).
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *repo) GetUserItems(ctx context.Context, userID uint) ([]*model.Item, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var items []*model.Item
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT un.id AS id, true AS is_nft, COALESCE(c.floor, 0) AS floor
+			FROM users_nfts un
+				LEFT OUTER JOIN nfts n ON n.id = un.nft_id
+				LEFT OUTER JOIN collections c ON n.collection_id = c.id
+			WHERE un.user_id = $1 AND NOT EXISTS (
+				SELECT 1
+				FROM rounds_nfts rn
+					JOIN rounds r ON r.id = rn.round_id
+				WHERE rn.user_nft_id = un.id AND r.status <> 'settled'
+			)
+			UNION ALL
+			SELECT ug.id AS id, false AS is_nft, COALESCE(c.floor, 0) AS floor
+			FROM users_gifts ug
+				LEFT OUTER JOIN gifts g ON g.id = ug.gift_id
+				LEFT OUTER JOIN collections c ON g.collection_id = c.id
+			WHERE ug.user_id = $1 AND NOT EXISTS (
+				SELECT 1
+				FROM rounds_gifts rg
+					JOIN rounds r ON r.id = rg.round_id
+				WHERE rg.user_gift_id = ug.id AND r.status <> 'settled'
+			)
+			ORDER BY floor, id
+		`, userID).
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
 func (r *repo) GetUserBalance(ctx context.Context, userID uint) (int64, error) {
 	db := database.FromContext(ctx, r.db)
 
-	var balance int64
+	var balance *int64
 	err := db.WithContext(ctx).
 		Raw(`
 			SELECT balance
 			FROM users
 			WHERE id = $1
+			FOR UPDATE
 		`, userID).
-		First(&balance).Error
+		Scan(&balance).Error
 	if err != nil {
 		return 0, err
 	}
+	if balance == nil {
+		return 0, database.ErrNotFound
+	}
 
-	return balance, nil
+	return *balance, nil
 }
 
 func (r *repo) GetReferrer(ctx context.Context, refID uint) (*model.Referrer, error) {
@@ -278,7 +495,7 @@ func (r *repo) GetReferrer(ctx context.Context, refID uint) (*model.Referrer, er
 	var ref *model.Referrer
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT u.id AS id, u.balance AS balance, u.is_spec AS is_spec
+			SELECT u.id AS id, COALESCE(u.is_spec, false) AS is_spec
 			FROM referrals r
 				LEFT OUTER JOIN users u ON r.referrer_id = u.id
 			WHERE r.ref_id = $1
@@ -297,7 +514,8 @@ func (r *repo) GetReferrer(ctx context.Context, refID uint) (*model.Referrer, er
 func (r *repo) AddRound(ctx context.Context, round *dbModels.RoundDB) error {
 	db := database.FromContext(ctx, r.db)
 	err := db.WithContext(ctx).
-		Select("round", "secret", "hash").
+		Select("round", "secret", "hash", "room", "status", "duration_secs", "min_players", "house_fee", "referral_fee", "spec_referral_fee", "ticket_price",
+			"min_bet", "max_bet", "gifts_only").
 		Create(round).Error
 	if err != nil {
 		return err
@@ -308,7 +526,7 @@ func (r *repo) AddRound(ctx context.Context, round *dbModels.RoundDB) error {
 func (r *repo) AddUserRoundNft(ctx context.Context, roundNft *dbModels.RoundNftDB) error {
 	db := database.FromContext(ctx, r.db)
 	err := db.WithContext(ctx).
-		Select("round_id", "user_nft_id", "bet").
+		Select("round_id", "user_nft_id", "nft_id", "user_id", "bet", "valuation").
 		Create(roundNft).Error
 	if err != nil {
 		return err
@@ -319,7 +537,7 @@ func (r *repo) AddUserRoundNft(ctx context.Context, roundNft *dbModels.RoundNftD
 func (r *repo) AddUserRoundGift(ctx context.Context, roundGift *dbModels.RoundGiftDB) error {
 	db := database.FromContext(ctx, r.db)
 	err := db.WithContext(ctx).
-		Select("round_id", "user_gift_id", "bet").
+		Select("round_id", "user_gift_id", "gift_id", "user_id", "bet", "valuation").
 		Create(roundGift).Error
 	if err != nil {
 		return err
@@ -327,10 +545,39 @@ func (r *repo) AddUserRoundGift(ctx context.Context, roundGift *dbModels.RoundGi
 	return nil
 }
 
+func (r *repo) GetRoundTonBets(ctx context.Context, roundID uint) ([]*dbModels.RoundTonBetDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var bets []*dbModels.RoundTonBetDB
+	err := db.WithContext(ctx).
+		Where("round_id = ?", roundID).
+		Order("id").
+		Find(&bets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return bets, nil
+}
+
+func (r *repo) AddUserRoundTonBet(ctx context.Context, tonBet *dbModels.RoundTonBetDB) error {
+	db := database.FromContext(ctx, r.db)
+	err := db.WithContext(ctx).
+		Select("round_id", "user_id", "amount").
+		Create(tonBet).Error
+	if err != nil {
+		if database.IsFKeyConflictError(err) {
+			return database.ErrFKeyConflict
+		}
+		return err
+	}
+	return nil
+}
+
 func (r *repo) AddUserRoundTicket(ctx context.Context, roundTicket *dbModels.RoundTicketDB) error {
 	db := database.FromContext(ctx, r.db)
 	err := db.WithContext(ctx).
-		Select("user_id", "round_id", "tickets").
+		Select("user_id", "round_id", "tickets", "bet", "client_seed").
 		Create(roundTicket).Error
 	if err != nil {
 		return err
@@ -359,16 +606,33 @@ func (r *repo) AddReferralFee(ctx context.Context, fee *dbModels.ReferralFeeDB)
 	return nil
 }
 
-func (r *repo) UpdateRoundStart(ctx context.Context, roundID uint) error {
+func (r *repo) UpdateRoundStatus(ctx context.Context, roundID uint, from, to dbModels.RoundStatus) error {
 	db := database.FromContext(ctx, r.db)
 
 	res := db.WithContext(ctx).
-		Raw(`
+		Exec(`
 			UPDATE rounds
-			SET started_at = CURRENT_TIMESTAMP
-			WHERE id = $1
-		`, roundID).
-		Save(&dbModels.RoundDB{})
+			SET status = $3,
+				started_at = CASE WHEN $3 = 'countdown' THEN CURRENT_TIMESTAMP ELSE started_at END
+			WHERE id = $1 AND status = $2
+		`, roundID, string(from), string(to))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repo) UpdateRoundNumber(ctx context.Context, roundID uint, roundNumber string) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Model(&dbModels.RoundDB{}).
+		Where("id = ?", roundID).
+		UpdateColumn("round", roundNumber)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -430,34 +694,161 @@ func (r *repo) UpdateWinner(ctx context.Context, winnerID uint, winner *dbModels
 	return nil
 }
 
-func (r *repo) UpdateFee(ctx context.Context, userID uint) error {
+func (r *repo) AddRoundResults(ctx context.Context, roundID uint, winnerID uint, pot int64) error {
 	db := database.FromContext(ctx, r.db)
 
-	res := db.WithContext(ctx).
-		Raw(`
-			UPDATE rounds_winners
-			SET is_paid = true
-			WHERE user_id = $1 AND is_paid IS NULL
-		`, userID).
-		Save(&dbModels.RoundWinnerDB{})
-	if res.Error != nil {
-		return res.Error
+	err := db.WithContext(ctx).
+		Exec(`
+			WITH bets AS (
+				SELECT user_id, bet
+				FROM rounds_nfts
+				WHERE round_id = $1
+				UNION ALL
+				SELECT user_id, bet
+				FROM rounds_gifts
+				WHERE round_id = $1
+				UNION ALL
+				SELECT user_id, amount AS bet
+				FROM rounds_ton_bets
+				WHERE round_id = $1
+			), tickets AS (
+				SELECT user_id, SUM(tickets) AS tickets
+				FROM rounds_tickets
+				WHERE round_id = $1
+				GROUP BY user_id
+			)
+			INSERT INTO rounds_results (round_id, user_id, bet, tickets, is_winner, won)
+			SELECT $1, t.user_id, COALESCE(SUM(b.bet), 0), t.tickets, t.user_id = $2,
+				   CASE WHEN t.user_id = $2 THEN $3 ELSE 0 END
+			FROM tickets t
+				LEFT OUTER JOIN bets b ON b.user_id = t.user_id
+			GROUP BY t.user_id, t.tickets
+		`, roundID, winnerID, pot).Error
+	if err != nil {
+		if database.IsKeyConflictErr(err) {
+			return database.ErrKeyConflict
+		}
+		return err
 	}
+
 	return nil
 }
 
-func (r *repo) UpdateUserBalance(ctx context.Context, userID uint, balance int64) error {
+func (r *repo) UpdatePlayersStats(ctx context.Context, roundID uint) error {
 	db := database.FromContext(ctx, r.db)
 
-	res := db.WithContext(ctx).
-		Raw(`
-			UPDATE users
-			SET balance = $2
-			WHERE id = $1
-		`, userID, balance).
-		Save(&dbModels.UserDB{})
-	if res.Error != nil {
-		return res.Error
+	err := db.WithContext(ctx).
+		Exec(`
+			INSERT INTO players_stats (period, period_start, user_id, volume, rounds, wins, won, biggest_win)
+			SELECT p.period, stats_period_start(p.period, rr.settled_at), rr.user_id,
+				   rr.bet, 1, CASE WHEN rr.is_winner THEN 1 ELSE 0 END, rr.won, rr.won
+			FROM rounds_results rr
+				CROSS JOIN (VALUES ('day'), ('week'), ('all')) AS p (period)
+			WHERE rr.round_id = $1
+			ON CONFLICT (period, period_start, user_id) DO UPDATE
+			SET volume = players_stats.volume + EXCLUDED.volume,
+				rounds = players_stats.rounds + EXCLUDED.rounds,
+				wins = players_stats.wins + EXCLUDED.wins,
+				won = players_stats.won + EXCLUDED.won,
+				biggest_win = GREATEST(players_stats.biggest_win, EXCLUDED.biggest_win)
+		`, roundID).Error
+	if err != nil {
+		return err
 	}
+
 	return nil
 }
+
+func (r *repo) GetRoundResults(ctx context.Context, room string, cursor uint, limit int) ([]*model.RoundResult, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var results []*model.RoundResult
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT r.id AS id, r.room AS room, r.hash AS hash, rw.user_id AS winner_id,
+				   u.name AS winner_name, u.photo_url AS winner_photo_url,
+				   total.pot AS pot, total.tickets AS total_tickets, w.tickets AS winner_tickets,
+				   w.tickets::FLOAT8 / NULLIF(total.tickets, 0) AS chance, w.settled_at AS settled_at
+			FROM rounds r
+				JOIN rounds_winners rw ON rw.round_id = r.id
+				JOIN rounds_results w ON w.round_id = r.id AND w.user_id = rw.user_id
+				CROSS JOIN LATERAL (
+					SELECT SUM(bet) AS pot, SUM(tickets) AS tickets
+					FROM rounds_results
+					WHERE round_id = r.id
+				) AS total
+				LEFT OUTER JOIN users u ON u.id = rw.user_id
+			WHERE r.status = 'settled' AND ($1 = '' OR r.room = $1) AND ($2 = 0 OR r.id < $2)
+			ORDER BY r.id DESC
+			LIMIT $3
+		`, room, cursor, limit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *repo) GetUserResults(ctx context.Context, userID uint, cursor uint, limit int) ([]*model.PlayerResult, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var results []*model.PlayerResult
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT rr.round_id AS round_id, r.room AS room, rr.bet AS bet, rr.tickets AS tickets,
+				   rr.tickets::FLOAT8 / NULLIF(total.tickets, 0) AS chance,
+				   rr.is_winner AS is_winner, rr.won AS won, rr.settled_at AS settled_at
+			FROM rounds_results rr
+				JOIN rounds r ON r.id = rr.round_id
+				CROSS JOIN LATERAL (
+					SELECT SUM(tickets) AS tickets
+					FROM rounds_results
+					WHERE round_id = rr.round_id
+				) AS total
+			WHERE rr.user_id = $1 AND ($2 = 0 OR rr.round_id < $2)
+			ORDER BY rr.round_id DESC
+			LIMIT $3
+		`, userID, cursor, limit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// rankingColumns keeps the leaderboard order out of the query params
+var rankingColumns = map[model.Ranking]string{
+	model.RankingVolume:     "volume",
+	model.RankingWins:       "wins",
+	model.RankingBiggestWin: "biggest_win",
+}
+
+func (r *repo) GetLeaderboard(ctx context.Context, period model.Period, ranking model.Ranking, limit int) ([]*model.LeaderboardEntry, error) {
+	db := database.FromContext(ctx, r.db)
+
+	column, ok := rankingColumns[ranking]
+	if !ok {
+		return nil, fmt.Errorf("unknown ranking %q", ranking)
+	}
+
+	var entries []*model.LeaderboardEntry
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT ROW_NUMBER() OVER (ORDER BY ps.`+column+` DESC, ps.user_id) AS rank,
+				   ps.user_id AS user_id, u.name AS name, u.photo_url AS photo_url,
+				   ps.volume AS volume, ps.rounds AS rounds, ps.wins AS wins, ps.biggest_win AS biggest_win
+			FROM players_stats ps
+				LEFT OUTER JOIN users u ON u.id = ps.user_id
+			WHERE ps.period = $1 AND ps.period_start = stats_period_start($1, CURRENT_TIMESTAMP)
+			ORDER BY ps.`+column+` DESC, ps.user_id
+			LIMIT $2
+		`, period, limit).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -12,7 +12,11 @@ import (
 type Repo interface {
 	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
 
-	GetCurrentRound(ctx context.Context) (*model.Round, error)
+	// GetCurrentRound returns the newest round of the room
+	GetCurrentRound(ctx context.Context, room string) (*model.Round, error)
+
+	// LockCurrentRound locks the newest round of the room, bets share the lock and transitions take it exclusive
+	LockCurrentRound(ctx context.Context, room string, exclusive bool) (*model.Round, error)
 
 	GetRound(ctx context.Context, roundID uint) (*model.Round, error)
 
@@ -26,41 +30,82 @@ type Repo interface {
 
 	GetRoundGiftIDs(ctx context.Context, roundID uint) ([]uint, error)
 
+	// GetRoundTonBet returns the sum of ton bets of the round
+	GetRoundTonBet(ctx context.Context, roundID uint) (int64, error)
+
+	// GetUserNft locks the user nft until the end of tx
 	GetUserNft(ctx context.Context, userNftID uint) (*model.Gift, error)
 
+	// GetUserGift locks the user gift until the end of tx
 	GetUserGift(ctx context.Context, userGiftID uint) (*model.Gift, error)
 
 	GetWinner(ctx context.Context, roundID uint) (*model.Winner, error)
 
-	GetWinnerFee(ctx context.Context, userID uint) (int64, error)
+	// GetPendingFee returns the sum of unsettled fees of the rounds won by the user
+	GetPendingFee(ctx context.Context, userID uint) (int64, error)
+
+	// GetPendingWinners returns winners with unsettled fees, the oldest due first
+	GetPendingWinners(ctx context.Context, limit int) ([]*model.UnpaidWinner, error)
+
+	// LockPendingWinner locks the winner while the fee is unsettled, ErrNotFound is returned
+	// for a settled winner or one locked by another tx
+	LockPendingWinner(ctx context.Context, winnerID uint) (*model.UnpaidWinner, error)
+
+	// GetRoundBets returns the nft bets then the gift bets of the round, each in bet order
+	GetRoundBets(ctx context.Context, roundID uint) ([]*model.Bet, error)
 
+	// GetRoundItems returns the bet items of the round valued at their bet, cheapest first
+	GetRoundItems(ctx context.Context, roundID uint) ([]*model.Item, error)
+
+	// GetUserItems returns items of the user not bet in an unsettled round, cheapest first
+	GetUserItems(ctx context.Context, userID uint) ([]*model.Item, error)
+
+	// GetUserBalance locks the user row until the end of tx
 	GetUserBalance(ctx context.Context, userID uint) (int64, error)
 
 	GetReferrer(ctx context.Context, refID uint) (*model.Referrer, error)
 
+	// GetRoundResults returns settled rounds with a winner before the cursor round, newest first. Empty room is any room
+	GetRoundResults(ctx context.Context, room string, cursor uint, limit int) ([]*model.RoundResult, error)
+
+	// GetUserResults returns settled rounds of the user before the cursor round, newest first
+	GetUserResults(ctx context.Context, userID uint, cursor uint, limit int) ([]*model.PlayerResult, error)
+
+	// GetLeaderboard ranks players in the current period by the db clock
+	GetLeaderboard(ctx context.Context, period model.Period, ranking model.Ranking, limit int) ([]*model.LeaderboardEntry, error)
+
 	AddRound(ctx context.Context, round *dbModels.RoundDB) error
 
 	AddUserRoundNft(ctx context.Context, roundNft *dbModels.RoundNftDB) error
 
 	AddUserRoundGift(ctx context.Context, roundGift *dbModels.RoundGiftDB) error
 
+	GetRoundTonBets(ctx context.Context, roundID uint) ([]*dbModels.RoundTonBetDB, error)
+
+	AddUserRoundTonBet(ctx context.Context, tonBet *dbModels.RoundTonBetDB) error
+
 	AddUserRoundTicket(ctx context.Context, roundTicket *dbModels.RoundTicketDB) error
 
 	AddWinner(ctx context.Context, winner *dbModels.RoundWinnerDB) error
 
 	AddReferralFee(ctx context.Context, fee *dbModels.ReferralFeeDB) error
 
-	UpdateRoundStart(ctx context.Context, roundID uint) error
+	// AddRoundResults records the bet and tickets of every player of the round
+	AddRoundResults(ctx context.Context, roundID uint, winnerID uint, pot int64) error
+
+	// UpdatePlayersStats adds the results of the round to the day, week and all time stats
+	UpdatePlayersStats(ctx context.Context, roundID uint) error
+
+	// UpdateRoundStatus moves the round from status, the countdown start is set by the db clock
+	UpdateRoundStatus(ctx context.Context, roundID uint, from, to dbModels.RoundStatus) error
+
+	UpdateRoundNumber(ctx context.Context, roundID uint, roundNumber string) error
 
 	UpdateUserNftOwner(ctx context.Context, ownerID uint, userNftID ...uint) error
 
 	UpdateUserGiftOwner(ctx context.Context, ownerID uint, userGiftID ...uint) error
 
 	UpdateWinner(ctx context.Context, winnerID uint, winner *dbModels.RoundWinnerDB) error
-
-	UpdateFee(ctx context.Context, userID uint) error
-
-	UpdateUserBalance(ctx context.Context, userID uint, balance int64) error
 }
 
 type repo struct {
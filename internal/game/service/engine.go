@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	eventsModel "roulette/internal/events/model"
+	"roulette/internal/game/model"
+	ledgerModel "roulette/internal/ledger/model"
+)
+
+// openRoundWait is how long an open round waits between checks, bets wake the engine earlier
+const openRoundWait = 30 * time.Second
+
+// event is published after the tx of the transition is committed
+type event struct {
+	eventType eventsModel.EventType
+	roundID   uint
+	data      interface{}
+}
+
+func (s *service) Advance(ctx context.Context, room string) (time.Duration, error) {
+	if _, err := s.getRoom(room); err != nil {
+		return 0, err
+	}
+
+	var delay time.Duration
+	var e *event
+	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		round, err := s.repo.LockCurrentRound(ctx, room, true)
+		if err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				e, err = s.addRound(ctx, room)
+			}
+			return err
+		}
+
+		switch round.Status {
+		case dbModels.RoundOpen:
+			players, err := s.repo.GetUniquePlayers(ctx, round.ID)
+			if err != nil && !database.IsRecordNotFoundErr(err) {
+				return err
+			}
+			if len(players) < round.MinPlayers {
+				delay = openRoundWait
+				return nil
+			}
+			e, err = s.startRound(ctx, round)
+			delay = round.Duration()
+			return err
+		case dbModels.RoundCountdown:
+			if !round.IsExpired {
+				delay = round.Remaining()
+				return nil
+			}
+			if err = s.repo.UpdateRoundStatus(ctx, round.ID, dbModels.RoundCountdown, dbModels.RoundDrawing); err != nil {
+				return err
+			}
+			e = &event{eventType: eventsModel.RoundDrawing, roundID: round.ID}
+			return nil
+		case dbModels.RoundDrawing:
+			e, err = s.drawRound(ctx, round)
+			return err
+		case dbModels.RoundSettled:
+			e, err = s.addRound(ctx, room)
+			return err
+		default:
+			return fmt.Errorf("round %d has unknown status %q", round.ID, round.Status)
+		}
+	})
+	if errTx != nil {
+		return 0, errTx
+	}
+
+	if e != nil {
+		s.eventsService.Publish(ctx, e.eventType, e.roundID, e.data)
+	}
+
+	return delay, nil
+}
+
+func (s *service) addRound(ctx context.Context, room string) (*event, error) {
+	gameRoom, err := s.getRoom(room)
+	if err != nil {
+		return nil, err
+	}
+
+	round, err := s.generateRound()
+	if err != nil {
+		return nil, err
+	}
+
+	roundDB := &dbModels.RoundDB{
+		RoundNumber:     round.RoundNumber,
+		Secret:          round.Secret,
+		Hash:            round.Hash,
+		Room:            gameRoom.Name,
+		Status:          dbModels.RoundOpen,
+		DurationSecs:    gameRoom.DurationSecs,
+		MinPlayers:      gameRoom.MinPlayers,
+		HouseFee:        gameRoom.HouseFee,
+		ReferralFee:     gameRoom.ReferralFee,
+		SpecReferralFee: gameRoom.SpecReferralFee,
+		TicketPrice:     gameRoom.TicketPrice,
+		MinBet:          gameRoom.MinBet,
+		MaxBet:          gameRoom.MaxBet,
+		GiftsOnly:       gameRoom.GiftsOnly,
+	}
+	if err = s.repo.AddRound(ctx, roundDB); err != nil {
+		return nil, err
+	}
+
+	return &event{
+		eventType: eventsModel.RoundCreated,
+		roundID:   roundDB.ID,
+		data: &eventsModel.RoundCreatedData{
+			Hash: roundDB.Hash,
+			Room: roundDB.Room,
+		},
+	}, nil
+}
+
+func (s *service) startRound(ctx context.Context, round *model.Round) (*event, error) {
+	if err := s.repo.UpdateRoundStatus(ctx, round.ID, dbModels.RoundOpen, dbModels.RoundCountdown); err != nil {
+		return nil, err
+	}
+
+	started, err := s.repo.GetRound(ctx, round.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event{
+		eventType: eventsModel.RoundStarted,
+		roundID:   round.ID,
+		data: &eventsModel.RoundStartedData{
+			StartedAt: *started.StartedAt,
+		},
+	}, nil
+}
+
+func (s *service) drawRound(ctx context.Context, round *model.Round) (*event, error) {
+	players, err := s.repo.GetPlayers(ctx, round.ID)
+	if err != nil && !database.IsRecordNotFoundErr(err) {
+		return nil, err
+	}
+
+	stats, err := s.repo.GetRoundStats(ctx, round.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	roundWithPlayers := &model.RoundWithPlayers{
+		Round:      round,
+		RoundStats: stats,
+		Players:    players,
+	}
+
+	e := &event{eventType: eventsModel.RoundCanceled, roundID: round.ID}
+	userID, ticket, roundNumber := s.getWinner(roundWithPlayers)
+	if ticket < 0 {
+		// no tickets to draw, items stay with their owners
+		if err = s.refundTonBets(ctx, round.ID); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = s.addWinner(ctx, roundWithPlayers, userID, ticket, roundNumber); err != nil {
+			return nil, err
+		}
+		e = &event{
+			eventType: eventsModel.WinnerPicked,
+			roundID:   round.ID,
+			data: &eventsModel.WinnerPickedData{
+				UserID: userID,
+				Ticket: ticket,
+			},
+		}
+	}
+
+	if err = s.repo.UpdateRoundStatus(ctx, round.ID, dbModels.RoundDrawing, dbModels.RoundSettled); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (s *service) addWinner(ctx context.Context, roundWithPlayers *model.RoundWithPlayers, userID uint, ticket int, roundNumber string) error {
+	// the ton part is paid from the pot, the items fee is settled separately
+	fee := (roundWithPlayers.TotalBet - roundWithPlayers.TotalTon) / 100 * roundWithPlayers.HouseFee
+
+	if err := s.repo.UpdateRoundNumber(ctx, roundWithPlayers.ID, roundNumber); err != nil {
+		return err
+	}
+
+	if err := s.repo.AddRoundResults(ctx, roundWithPlayers.ID, userID, roundWithPlayers.TotalBet); err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePlayersStats(ctx, roundWithPlayers.ID); err != nil {
+		return err
+	}
+
+	nfts, err := s.repo.GetRoundNftIDs(ctx, roundWithPlayers.ID)
+	if err == nil {
+		if err = s.repo.UpdateUserNftOwner(ctx, userID, nfts...); err != nil {
+			return err
+		}
+	}
+
+	gifts, err := s.repo.GetRoundGiftIDs(ctx, roundWithPlayers.ID)
+	if err == nil {
+		if err = s.repo.UpdateUserGiftOwner(ctx, userID, gifts...); err != nil {
+			return err
+		}
+	}
+
+	dueAt := time.Now().Add(s.feeExpiry)
+	winnerRound := &dbModels.RoundWinnerDB{
+		RoundID:  roundWithPlayers.ID,
+		Ticket:   ticket,
+		UserID:   userID,
+		Fee:      fee,
+		FeeDueAt: &dueAt,
+	}
+	if err = s.repo.AddWinner(ctx, winnerRound); err != nil {
+		return err
+	}
+
+	if err = s.payTonPot(ctx, roundWithPlayers.Round, userID); err != nil {
+		return err
+	}
+
+	items, err := s.repo.GetRoundItems(ctx, roundWithPlayers.ID)
+	if err != nil {
+		return err
+	}
+
+	winner := &model.UnpaidWinner{
+		ID:              winnerRound.ID,
+		UserID:          userID,
+		RoundID:         roundWithPlayers.ID,
+		Fee:             fee,
+		ReferralFee:     roundWithPlayers.ReferralFee,
+		SpecReferralFee: roundWithPlayers.SpecReferralFee,
+		FeeDueAt:        &dueAt,
+	}
+	return s.settleFee(ctx, winner, items)
+}
+
+func (s *service) refundTonBets(ctx context.Context, roundID uint) error {
+	bets, err := s.repo.GetRoundTonBets(ctx, roundID)
+	if err != nil {
+		return err
+	}
+
+	for _, bet := range bets {
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.TonRefund, bet.ID),
+			Kind:   ledgerModel.TonRefund,
+			Debit:  ledgerModel.PotAccount(roundID),
+			Credit: ledgerModel.UserAccount(bet.UserID),
+			Amount: bet.Amount,
+		}
+		if err = s.ledgerService.Post(ctx, posting); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,9 +3,22 @@ package service
 import "errors"
 
 var (
-	ErrRoundNotFound    = errors.New("round not found")
-	ErrRoundFinished    = errors.New("round is already finished")
-	ErrNotEnoughBalance = errors.New("not enough balance")
+	ErrRoundNotFound     = errors.New("round not found")
+	ErrRoundFinished     = errors.New("round is already finished")
+	ErrRoundNotFinished  = errors.New("round is not finished yet")
+	ErrNotEnoughBalance  = errors.New("not enough balance")
+	ErrInvalidClientSeed = errors.New("invalid client seed")
+	ErrRoomNotFound      = errors.New("room not found")
+	ErrBetTooLow         = errors.New("bet is below the room minimum")
+	ErrBetTooHigh        = errors.New("bet is above the room maximum")
+	ErrGiftsOnly         = errors.New("room takes only gifts")
+	ErrNoTickets         = errors.New("item is worth less than one ticket")
+	ErrInvalidAmount     = errors.New("amount is less than the ticket price")
+	ErrAlreadyBet        = errors.New("item is already bet")
+	ErrStaleFloor        = errors.New("collection has no current floor price")
+	ErrFeePending        = errors.New("fee of a won round is not paid yet")
+	ErrInvalidPeriod     = errors.New("period must be day, week or all")
+	ErrInvalidRanking    = errors.New("ranking must be volume, wins or biggestWin")
 )
 
 func IsRoundNotFound(err error) bool {
@@ -19,3 +32,42 @@ func IsRoundFinished(err error) bool {
 func IsNotEnoughBalance(err error) bool {
 	return errors.Is(err, ErrNotEnoughBalance)
 }
+
+func IsRoundNotFinished(err error) bool {
+	return errors.Is(err, ErrRoundNotFinished)
+}
+
+func IsInvalidClientSeed(err error) bool {
+	return errors.Is(err, ErrInvalidClientSeed)
+}
+
+func IsRoomNotFound(err error) bool {
+	return errors.Is(err, ErrRoomNotFound)
+}
+
+// IsBetNotAllowed reports whether the room rules reject the bet
+func IsBetNotAllowed(err error) bool {
+	return errors.Is(err, ErrBetTooLow) || errors.Is(err, ErrBetTooHigh) || errors.Is(err, ErrGiftsOnly) ||
+		errors.Is(err, ErrNoTickets)
+}
+
+func IsAlreadyBet(err error) bool {
+	return errors.Is(err, ErrAlreadyBet)
+}
+
+func IsStaleFloor(err error) bool {
+	return errors.Is(err, ErrStaleFloor)
+}
+
+func IsInvalidAmount(err error) bool {
+	return errors.Is(err, ErrInvalidAmount)
+}
+
+func IsFeePending(err error) bool {
+	return errors.Is(err, ErrFeePending)
+}
+
+// IsInvalidLeaderboard reports whether the leaderboard period or ranking is unknown
+func IsInvalidLeaderboard(err error) bool {
+	return errors.Is(err, ErrInvalidPeriod) || errors.Is(err, ErrInvalidRanking)
+}
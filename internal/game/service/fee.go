@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/game/model"
+	ledgerModel "roulette/internal/ledger/model"
+)
+
+const pendingFeesBatch = 100
+
+// CollectFees settles each winner in its own tx, so one failing winner doesn't hold the others
+func (s *service) CollectFees(ctx context.Context) error {
+	winners, err := s.repo.GetPendingWinners(ctx, pendingFeesBatch)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, pending := range winners {
+		if ctx.Err() != nil {
+			break
+		}
+
+		errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+			winner, err := s.repo.LockPendingWinner(ctx, pending.ID)
+			if err != nil {
+				if database.IsRecordNotFoundErr(err) {
+					return nil
+				}
+				return err
+			}
+			return s.settleFee(ctx, winner, nil)
+		})
+		if errTx != nil {
+			errs = append(errs, fmt.Errorf("winner %d: %w", pending.ID, errTx))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *service) settleFee(ctx context.Context, winner *model.UnpaidWinner, wonItems []*model.Item) error {
+	if winner.Fee <= 0 {
+		return s.updateSettlement(ctx, winner.ID, dbModels.FeeSettlementNone)
+	}
+
+	if s.feeInItems {
+		if item := coveringItem(wonItems, winner.Fee); item != nil {
+			return s.keepItem(ctx, winner, item)
+		}
+	}
+
+	// the balance is locked, so a short balance never reaches the ledger and aborts the tx
+	balance, err := s.repo.GetUserBalance(ctx, winner.UserID)
+	if err != nil {
+		return err
+	}
+	if balance >= winner.Fee {
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.WinFee, winner.ID),
+			Kind:   ledgerModel.WinFee,
+			Debit:  ledgerModel.UserAccount(winner.UserID),
+			Credit: ledgerModel.HouseAccount,
+			Amount: winner.Fee,
+		}
+		if err = s.ledgerService.Post(ctx, posting); err != nil {
+			return err
+		}
+
+		err = s.payReferralFee(ctx, winner.UserID, ledgerModel.Key(ledgerModel.ReferralReward, winner.ID), winner.Fee, winner.ReferralFee, winner.SpecReferralFee)
+		if err != nil {
+			return err
+		}
+
+		return s.updateSettlement(ctx, winner.ID, dbModels.FeeSettlementBalance)
+	}
+
+	if winner.FeeDueAt != nil && time.Now().Before(*winner.FeeDueAt) {
+		return nil
+	}
+
+	if s.houseUserID != 0 {
+		items, err := s.repo.GetUserItems(ctx, winner.UserID)
+		if err != nil {
+			return err
+		}
+		if item := coveringItem(items, winner.Fee); item != nil {
+			return s.keepItem(ctx, winner, item)
+		}
+	}
+
+	return s.updateSettlement(ctx, winner.ID, dbModels.FeeSettlementWaived)
+}
+
+func (s *service) checkPendingFee(ctx context.Context, userID uint) error {
+	fee, err := s.repo.GetPendingFee(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if fee > 0 {
+		return fmt.Errorf("user %d: %w", userID, ErrFeePending)
+	}
+	return nil
+}
+
+func (s *service) keepItem(ctx context.Context, winner *model.UnpaidWinner, item *model.Item) error {
+	var err error
+	if item.IsNft {
+		err = s.repo.UpdateUserNftOwner(ctx, s.houseUserID, item.ID)
+	} else {
+		err = s.repo.UpdateUserGiftOwner(ctx, s.houseUserID, item.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.payReferralFee(ctx, winner.UserID, ledgerModel.Key(ledgerModel.ReferralReward, winner.ID), winner.Fee, winner.ReferralFee, winner.SpecReferralFee)
+	if err != nil {
+		return err
+	}
+
+	return s.updateSettlement(ctx, winner.ID, dbModels.FeeSettlementItem)
+}
+
+func (s *service) updateSettlement(ctx context.Context, winnerID uint, settlement dbModels.FeeSettlement) error {
+	isPaid := settlement != dbModels.FeeSettlementWaived
+	settledAt := time.Now()
+
+	return s.repo.UpdateWinner(ctx, winnerID, &dbModels.RoundWinnerDB{
+		IsPaid:     &isPaid,
+		Settlement: &settlement,
+		SettledAt:  &settledAt,
+	})
+}
+
+// coveringItem returns the cheapest item worth at least fee, items are sorted by floor
+func coveringItem(items []*model.Item, fee int64) *model.Item {
+	for _, item := range items {
+		if item.Floor >= fee {
+			return item
+		}
+	}
+	return nil
+}
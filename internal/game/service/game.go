@@ -2,31 +2,46 @@ package service
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"math"
-	"math/rand/v2"
-	"strconv"
-	"time"
 
 	"roulette/internal/database"
 	dbModels "roulette/internal/database/models"
+	eventsModel "roulette/internal/events/model"
 	"roulette/internal/game/model"
+	ledgerModel "roulette/internal/ledger/model"
+	ledgerService "roulette/internal/ledger/service"
+	"roulette/internal/models"
+	valuationModel "roulette/internal/valuation/model"
+	"roulette/pkg/fair"
 )
 
-func (s *service) GetCurrentRound(ctx context.Context) (*model.Round, error) {
+const maxClientSeedLength = 64
+
+func (s *service) GetRooms(ctx context.Context) []*model.Room {
+	return s.rooms
+}
+
+func (s *service) GetCurrentRound(ctx context.Context, room string) (*model.Round, error) {
+	if _, err := s.getRoom(room); err != nil {
+		return nil, err
+	}
+
 	// TODO: cash
-	round, err := s.repo.GetCurrentRound(ctx)
+	round, err := s.repo.GetCurrentRound(ctx, room)
 	if err != nil {
 		return nil, err
 	}
 	return round, nil
 }
 
-func (s *service) GetCurrentRoundWithPlayers(ctx context.Context) (*model.RoundWithPlayers, error) {
+func (s *service) GetCurrentRoundWithPlayers(ctx context.Context, room string) (*model.RoundWithPlayers, error) {
+	if _, err := s.getRoom(room); err != nil {
+		return nil, err
+	}
+
 	// TODO: cash
-	curRound, err := s.repo.GetCurrentRound(ctx)
+	curRound, err := s.repo.GetCurrentRound(ctx, room)
 	if err != nil {
 		if database.IsRecordNotFoundErr(err) {
 			return nil, ErrRoundNotFound
@@ -113,260 +128,507 @@ func (s *service) GetWinner(ctx context.Context, roundID uint) (*model.Winner, e
 	return winner, nil
 }
 
-func (s *service) AddRound(ctx context.Context) error {
-	round := s.generateRound()
+func (s *service) GetRoundBets(ctx context.Context, roundID uint) ([]*model.Bet, error) {
+	if _, err := s.repo.GetRound(ctx, roundID); err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrRoundNotFound
+		}
+		return nil, err
+	}
+
+	bets, err := s.repo.GetRoundBets(ctx, roundID)
+	if err != nil {
+		return nil, err
+	}
 
-	roundDB := &dbModels.RoundDB{
-		RoundNumber: round.RoundNumber,
-		Secret:      round.Secret,
-		Hash:        round.Hash,
+	for _, bet := range bets {
+		if bet.RawValuation == nil {
+			continue
+		}
+		var valuation valuationModel.Valuation
+		if err = json.Unmarshal([]byte(*bet.RawValuation), &valuation); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal valuation of %s: %v", bet.Name, err)
+		}
+		bet.Valuation = &valuation
 	}
-	err := s.repo.AddRound(ctx, roundDB)
+
+	return bets, nil
+}
+
+func (s *service) GetRoundProof(ctx context.Context, roundID uint) (*fair.Proof, error) {
+	round, err := s.repo.GetRound(ctx, roundID)
 	if err != nil {
-		return err
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrRoundNotFound
+		}
+		return nil, err
 	}
 
-	return nil
+	winner, err := s.repo.GetWinner(ctx, roundID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrRoundNotFinished
+		}
+		return nil, err
+	}
+
+	players, err := s.repo.GetPlayers(ctx, roundID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := s.getRanges(players)
+	totalTickets := 0
+	if len(ranges) > 0 {
+		totalTickets = ranges[len(ranges)-1].To
+	}
+
+	proof := &fair.Proof{
+		RoundID:      round.ID,
+		ServerSeed:   round.Secret,
+		Hash:         round.Hash,
+		ClientSeed:   fair.RangesClientSeed(ranges),
+		RoundNumber:  round.RoundNumber,
+		TotalTickets: totalTickets,
+		Ranges:       ranges,
+		WinnerID:     winner.UserID,
+		Ticket:       int(winner.Ticket),
+	}
+
+	return proof, nil
 }
 
-func (s *service) AddUserNft(ctx context.Context, userNftID uint) error {
+func (s *service) AddUserNft(ctx context.Context, userID uint, room string, userNftID uint, clientSeed *string) error {
+	if clientSeed != nil && len(*clientSeed) > maxClientSeedLength {
+		return ErrInvalidClientSeed
+	}
+	if _, err := s.getRoom(room); err != nil {
+		return err
+	}
+	if err := s.pauseService.Check(ctx, dbModels.FeatureBetting); err != nil {
+		return err
+	}
+
+	var roundTicket *dbModels.RoundTicketDB
+	var bet int64
 	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		round, err := s.repo.GetCurrentRound(ctx)
+		round, err := s.repo.LockCurrentRound(ctx, room, false)
 		if err != nil {
 			return ErrRoundNotFound
 		}
+		if !round.IsBetting() {
+			return ErrRoundFinished
+		}
 
 		userNft, err := s.repo.GetUserNft(ctx, userNftID)
 		if err != nil {
 			return err
 		}
+		if userNft.UserID != userID {
+			return fmt.Errorf("user nft %d: %w", userNftID, database.ErrNotFound)
+		}
+		if userNft.IsBet {
+			return ErrAlreadyBet
+		}
+		if err = s.checkPendingFee(ctx, userID); err != nil {
+			return err
+		}
+		if userNft.IsStale {
+			return ErrStaleFloor
+		}
+		valuation, rawValuation, err := s.valueItem(ctx, userNft, round)
+		if err != nil {
+			return err
+		}
+		if err = s.checkBet(round, valuation.Value, false); err != nil {
+			return err
+		}
+		if err = s.limitService.Check(ctx, userID, round.ID, valuation.Value); err != nil {
+			return err
+		}
+		bet = valuation.Value
 
 		roundNft := &dbModels.RoundNftDB{
 			RoundID:   round.ID,
-			UserNftID: userNftID,
-			Bet:       userNft.Floor,
+			UserNftID: &userNftID,
+			NftID:     uint(userNft.ItemID),
+			UserID:    userID,
+			Bet:       valuation.Value,
+			Valuation: rawValuation,
 		}
 		err = s.repo.AddUserRoundNft(ctx, roundNft)
 		if err != nil {
 			return err
 		}
 
-		roundTicket := &dbModels.RoundTicketDB{
-			RoundID: round.ID,
-			UserID:  userNft.UserID,
-			Tickets: int(userNft.Floor / 100_000_000),
+		roundTicket = &dbModels.RoundTicketDB{
+			RoundID:    round.ID,
+			UserID:     userNft.UserID,
+			Tickets:    valuation.Tickets,
+			Bet:        valuation.Value,
+			ClientSeed: clientSeed,
 		}
 		err = s.repo.AddUserRoundTicket(ctx, roundTicket)
 		if err != nil {
 			return err
 		}
 
-		if round.StartedAt != nil {
-			if time.Now().Unix() >= round.StartedAt.Add(3*time.Minute).Unix() {
-				return ErrRoundFinished
-			}
-		}
-
 		return nil
 	})
 	if errTx != nil {
-		return fmt.Errorf("failed to add user: %v", errTx)
+		return fmt.Errorf("failed to add user: %w", errTx)
 	}
 
+	s.eventsService.Publish(ctx, eventsModel.BetPlaced, roundTicket.RoundID, &eventsModel.BetPlacedData{
+		UserID:  roundTicket.UserID,
+		Tickets: roundTicket.Tickets,
+		Bet:     bet,
+	})
+
 	return nil
 }
 
-func (s *service) AddUserGift(ctx context.Context, userGiftID uint) error {
+func (s *service) AddUserGift(ctx context.Context, userID uint, room string, userGiftID uint, clientSeed *string) error {
+	if clientSeed != nil && len(*clientSeed) > maxClientSeedLength {
+		return ErrInvalidClientSeed
+	}
+	if _, err := s.getRoom(room); err != nil {
+		return err
+	}
+	if err := s.pauseService.Check(ctx, dbModels.FeatureBetting); err != nil {
+		return err
+	}
+
+	var roundTicket *dbModels.RoundTicketDB
+	var bet int64
 	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		round, err := s.repo.GetCurrentRound(ctx)
+		round, err := s.repo.LockCurrentRound(ctx, room, false)
 		if err != nil {
 			return ErrRoundNotFound
 		}
+		if !round.IsBetting() {
+			return ErrRoundFinished
+		}
 
 		userGift, err := s.repo.GetUserGift(ctx, userGiftID)
 		if err != nil {
 			return err
 		}
+		if userGift.UserID != userID {
+			return fmt.Errorf("user gift %d: %w", userGiftID, database.ErrNotFound)
+		}
+		if userGift.IsBet {
+			return ErrAlreadyBet
+		}
+		if err = s.checkPendingFee(ctx, userID); err != nil {
+			return err
+		}
+		if userGift.IsStale {
+			return ErrStaleFloor
+		}
+		valuation, rawValuation, err := s.valueItem(ctx, userGift, round)
+		if err != nil {
+			return err
+		}
+		if err = s.checkBet(round, valuation.Value, true); err != nil {
+			return err
+		}
+		if err = s.limitService.Check(ctx, userID, round.ID, valuation.Value); err != nil {
+			return err
+		}
+		bet = valuation.Value
 
 		roundGift := &dbModels.RoundGiftDB{
 			RoundID:    round.ID,
-			UserGiftID: userGiftID,
-			Bet:        userGift.Floor,
+			UserGiftID: &userGiftID,
+			GiftID:     userGift.ItemID,
+			UserID:     userID,
+			Bet:        valuation.Value,
+			Valuation:  rawValuation,
 		}
 		err = s.repo.AddUserRoundGift(ctx, roundGift)
 		if err != nil {
 			return err
 		}
 
-		roundTicket := &dbModels.RoundTicketDB{
-			RoundID: round.ID,
-			UserID:  userGift.UserID,
-			Tickets: int(userGift.Floor / 100_000_000),
+		roundTicket = &dbModels.RoundTicketDB{
+			RoundID:    round.ID,
+			UserID:     userGift.UserID,
+			Tickets:    valuation.Tickets,
+			Bet:        valuation.Value,
+			ClientSeed: clientSeed,
 		}
 		err = s.repo.AddUserRoundTicket(ctx, roundTicket)
 		if err != nil {
 			return err
 		}
 
-		if round.StartedAt != nil {
-			if time.Now().Unix() >= round.StartedAt.Add(3*time.Minute).Unix() {
-				return ErrRoundFinished
-			}
-		}
-
 		return nil
 	})
 	if errTx != nil {
-		return fmt.Errorf("failed to add user: %v", errTx)
+		return fmt.Errorf("failed to add user: %w", errTx)
 	}
 
+	s.eventsService.Publish(ctx, eventsModel.BetPlaced, roundTicket.RoundID, &eventsModel.BetPlacedData{
+		UserID:  roundTicket.UserID,
+		Tickets: roundTicket.Tickets,
+		Bet:     bet,
+	})
+
 	return nil
 }
 
-func (s *service) AddWinner(ctx context.Context, roundWithPlayers *model.RoundWithPlayers) error {
-	userID, ticket := s.getWinner(roundWithPlayers.RoundNumber, roundWithPlayers.TotalTickets, roundWithPlayers.Players)
-	fee := roundWithPlayers.TotalBet / 100 * 5
+func (s *service) AddUserTon(ctx context.Context, userID uint, room string, amount int64, clientSeed *string) error {
+	if clientSeed != nil && len(*clientSeed) > maxClientSeedLength {
+		return ErrInvalidClientSeed
+	}
+	if _, err := s.getRoom(room); err != nil {
+		return err
+	}
+	if err := s.pauseService.Check(ctx, dbModels.FeatureBetting); err != nil {
+		return err
+	}
 
+	var roundTicket *dbModels.RoundTicketDB
 	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		nfts, err := s.repo.GetRoundNftIDs(ctx, roundWithPlayers.ID)
-		if err == nil {
-			if err = s.repo.UpdateUserNftOwner(ctx, userID, nfts...); err != nil {
-				return err
-			}
+		round, err := s.repo.LockCurrentRound(ctx, room, false)
+		if err != nil {
+			return ErrRoundNotFound
 		}
-
-		gifts, err := s.repo.GetRoundGiftIDs(ctx, roundWithPlayers.ID)
-		if err == nil {
-			if err = s.repo.UpdateUserGiftOwner(ctx, userID, gifts...); err != nil {
-				return err
-			}
+		if !round.IsBetting() {
+			return ErrRoundFinished
 		}
 
-		winnerRound := &dbModels.RoundWinnerDB{
-			RoundID: roundWithPlayers.ID,
-			Ticket:  ticket,
-			UserID:  userID,
-			Fee:     fee,
+		if amount < round.TicketPrice {
+			return ErrInvalidAmount
 		}
-		if err = s.repo.AddWinner(ctx, winnerRound); err != nil {
+		if err = s.checkBet(round, amount, false); err != nil {
 			return err
 		}
-
-		return nil
-	})
-	if errTx != nil {
-		return errTx
-	}
-
-	return nil
-}
-
-func (s *service) UpdateFee(ctx context.Context, userID uint) error {
-	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		balance, err := s.repo.GetUserBalance(ctx, userID)
-		if err != nil {
+		if err = s.limitService.Check(ctx, userID, round.ID, amount); err != nil {
 			return err
 		}
-		fee, err := s.repo.GetWinnerFee(ctx, userID)
-		if err != nil {
+		if err = s.checkPendingFee(ctx, userID); err != nil {
 			return err
 		}
 
-		newBalance := balance - fee
-		if newBalance < 0 {
-			return ErrNotEnoughBalance
-		}
-
-		if err = s.repo.UpdateUserBalance(ctx, userID, newBalance); err != nil {
-			return err
+		tonBet := &dbModels.RoundTonBetDB{
+			RoundID: round.ID,
+			UserID:  userID,
+			Amount:  amount,
 		}
-		if err = s.repo.UpdateFee(ctx, userID); err != nil {
+		if err = s.repo.AddUserRoundTonBet(ctx, tonBet); err != nil {
+			if database.IsFKeyConflictError(err) {
+				return fmt.Errorf("user %d: %w", userID, database.ErrNotFound)
+			}
 			return err
 		}
 
-		referrer, err := s.repo.GetReferrer(ctx, userID)
-		if referrer == nil || err != nil {
-			return nil
-		}
-
-		refFee := newBalance / 100 * 5
-		if referrer.IsSpec == true {
-			refFee = newBalance / 100 * 15
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.TonBet, tonBet.ID),
+			Kind:   ledgerModel.TonBet,
+			Debit:  ledgerModel.UserAccount(userID),
+			Credit: ledgerModel.PotAccount(round.ID),
+			Amount: amount,
 		}
-		newReferrerBalance := referrer.Balance + refFee
-		if err = s.repo.UpdateUserBalance(ctx, referrer.ID, newReferrerBalance); err != nil {
+		if err = s.ledgerService.Post(ctx, posting); err != nil {
+			if ledgerService.IsNotEnoughBalance(err) {
+				return ErrNotEnoughBalance
+			}
 			return err
 		}
-		referralFee := &dbModels.ReferralFeeDB{
-			UserID: referrer.ID,
-			Amount: fee,
-			Fee:    refFee,
+
+		roundTicket = &dbModels.RoundTicketDB{
+			RoundID:    round.ID,
+			UserID:     userID,
+			Tickets:    int(amount / round.TicketPrice),
+			Bet:        amount,
+			ClientSeed: clientSeed,
 		}
-		if err = s.repo.AddReferralFee(ctx, referralFee); err != nil {
+		err = s.repo.AddUserRoundTicket(ctx, roundTicket)
+		if err != nil {
 			return err
 		}
 
 		return nil
 	})
 	if errTx != nil {
-		return errTx
+		return fmt.Errorf("failed to add user: %w", errTx)
 	}
 
+	s.eventsService.Publish(ctx, eventsModel.BetPlaced, roundTicket.RoundID, &eventsModel.BetPlacedData{
+		UserID:  roundTicket.UserID,
+		Tickets: roundTicket.Tickets,
+		Bet:     amount,
+	})
+
 	return nil
 }
 
-func (s *service) StartRound(ctx context.Context, roundID uint) error {
-	if err := s.repo.UpdateRoundStart(ctx, roundID); err != nil {
-		return err
+func (s *service) valueItem(ctx context.Context, item *model.Gift, round *model.Round) (*valuationModel.Valuation, *string, error) {
+	attributes, err := models.UnmarshalAttributes(item.Attributes)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
+
+	valuationItem := &valuationModel.Item{
+		Floor:      item.Floor,
+		Attributes: attributes,
+	}
+	if item.CollectionID != nil {
+		valuationItem.CollectionID = *item.CollectionID
+	}
+
+	valuation, err := s.valuationService.Value(ctx, valuationItem, round.TicketPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+	if valuation.Tickets == 0 {
+		return nil, nil, ErrNoTickets
+	}
+
+	b, err := json.Marshal(valuation)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal valuation: %v", err)
+	}
+	rawValuation := string(b)
+
+	return valuation, &rawValuation, nil
 }
 
-func (s *service) getWinner(round string, totalTickets int, players []*model.Player) (uint, int) {
-	roundNumber, _ := strconv.ParseFloat(round, 64)
-	winningTicket := int(math.Floor(float64(totalTickets)*roundNumber)) + 1
+func (s *service) payTonPot(ctx context.Context, round *model.Round, userID uint) error {
+	pot, err := s.repo.GetRoundTonBet(ctx, round.ID)
+	if err != nil {
+		return err
+	}
+	if pot <= 0 {
+		return nil
+	}
 
-	currentTicket := 0
-	for _, player := range players {
-		currentTicket += player.Tickets
-		if currentTicket >= winningTicket {
-			return player.UserID, winningTicket
+	fee := pot / 100 * round.HouseFee
+	if pot > fee {
+		posting := &ledgerModel.Posting{
+			Key:    ledgerModel.Key(ledgerModel.TonWin, round.ID),
+			Kind:   ledgerModel.TonWin,
+			Debit:  ledgerModel.PotAccount(round.ID),
+			Credit: ledgerModel.UserAccount(userID),
+			Amount: pot - fee,
 		}
+		if err = s.ledgerService.Post(ctx, posting); err != nil {
+			return err
+		}
+	}
+	if fee <= 0 {
+		return nil
+	}
+
+	posting := &ledgerModel.Posting{
+		Key:    ledgerModel.Key(ledgerModel.PotFee, round.ID),
+		Kind:   ledgerModel.PotFee,
+		Debit:  ledgerModel.PotAccount(round.ID),
+		Credit: ledgerModel.HouseAccount,
+		Amount: fee,
+	}
+	if err = s.ledgerService.Post(ctx, posting); err != nil {
+		return err
 	}
 
-	return 0, -1
+	return s.payReferralFee(ctx, userID, ledgerModel.Key(ledgerModel.ReferralReward, ledgerModel.PotFee, round.ID), fee, round.ReferralFee, round.SpecReferralFee)
 }
 
-func (s *service) generateRound() *model.Round {
-	roundNumber := fmt.Sprintf("%.16f", rand.Float64())
-	secret := s.generateSecret(10)
-	hash := s.getHash(roundNumber, secret)
+func (s *service) payReferralFee(ctx context.Context, userID uint, key string, fee int64, referralFee int64, specReferralFee int64) error {
+	referrer, err := s.repo.GetReferrer(ctx, userID)
+	if err != nil || referrer == nil || referrer.ID == 0 {
+		return nil
+	}
+
+	refFee := fee / 100 * referralFee
+	if referrer.IsSpec {
+		refFee = fee / 100 * specReferralFee
+	}
+	if refFee <= 0 {
+		return nil
+	}
 
-	return &model.Round{
-		RoundNumber: roundNumber,
-		Secret:      secret,
-		Hash:        hash,
+	posting := &ledgerModel.Posting{
+		Key:    key,
+		Kind:   ledgerModel.ReferralReward,
+		Debit:  ledgerModel.HouseAccount,
+		Credit: ledgerModel.UserAccount(referrer.ID),
+		Amount: refFee,
 	}
+	if err = s.ledgerService.Post(ctx, posting); err != nil {
+		return err
+	}
+
+	referral := &dbModels.ReferralFeeDB{
+		UserID: referrer.ID,
+		Amount: fee,
+		Fee:    refFee,
+	}
+	return s.repo.AddReferralFee(ctx, referral)
+}
+
+func (s *service) getRoom(room string) (*model.Room, error) {
+	for _, r := range s.rooms {
+		if r.Name == room {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("room %q: %w", room, ErrRoomNotFound)
+}
+
+func (s *service) checkBet(round *model.Round, bet int64, isGift bool) error {
+	if round.GiftsOnly && !isGift {
+		return ErrGiftsOnly
+	}
+	if round.MinBet > 0 && bet < round.MinBet {
+		return ErrBetTooLow
+	}
+	if round.MaxBet > 0 && bet > round.MaxBet {
+		return ErrBetTooHigh
+	}
+	return nil
 }
 
-func (s *service) generateSecret(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[rand.IntN(len(charset))]
+func (s *service) getWinner(round *model.RoundWithPlayers) (uint, int, string) {
+	ranges := s.getRanges(round.Players)
+	if len(ranges) == 0 {
+		return 0, -1, ""
+	}
+
+	clientSeed := fair.RangesClientSeed(ranges)
+	roundNumber := fair.RoundNumber(round.Secret, round.ID, clientSeed)
+	ticket := fair.WinningTicket(roundNumber, ranges[len(ranges)-1].To)
+
+	userID, ok := fair.Winner(ranges, ticket)
+	if !ok {
+		return 0, -1, ""
 	}
-	return string(b)
+
+	return userID, ticket, fair.FormatRoundNumber(roundNumber)
 }
 
-func (s *service) getHash(roundNumber string, secret string) string {
-	data := fmt.Sprintf("%s%s", roundNumber, secret)
-	hash := md5.Sum([]byte(data))
-	return hex.EncodeToString(hash[:])
+func (s *service) getRanges(players []*model.Player) []*fair.Range {
+	userIDs := make([]uint, len(players))
+	tickets := make([]int, len(players))
+	clientSeeds := make([]*string, len(players))
+	for i, player := range players {
+		userIDs[i] = player.UserID
+		tickets[i] = player.Tickets
+		clientSeeds[i] = player.ClientSeed
+	}
+	return fair.Ranges(userIDs, tickets, clientSeeds)
 }
 
-func (s *service) verifyHash(round *model.Round) bool {
-	data := fmt.Sprintf("%f%s", round.RoundNumber, round.Secret)
-	hash := md5.Sum([]byte(data))
-	calculatedHash := hex.EncodeToString(hash[:])
-	return calculatedHash == round.Hash
+func (s *service) generateRound() (*model.Round, error) {
+	secret, err := fair.NewSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Round{
+		Secret: secret,
+		Hash:   fair.Commit(secret),
+	}, nil
 }
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"roulette/internal/game/model"
+)
+
+func (s *service) GetRoundResults(ctx context.Context, room string, cursor uint, limit int) ([]*model.RoundResult, error) {
+	return s.repo.GetRoundResults(ctx, room, cursor, limit)
+}
+
+func (s *service) GetUserResults(ctx context.Context, userID uint, cursor uint, limit int) ([]*model.PlayerResult, error) {
+	return s.repo.GetUserResults(ctx, userID, cursor, limit)
+}
+
+func (s *service) GetLeaderboard(ctx context.Context, period model.Period, ranking model.Ranking, limit int) ([]*model.LeaderboardEntry, error) {
+	switch period {
+	case model.PeriodDay, model.PeriodWeek, model.PeriodAll:
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	switch ranking {
+	case "":
+		ranking = model.RankingVolume
+	case model.RankingVolume, model.RankingWins, model.RankingBiggestWin:
+	default:
+		return nil, ErrInvalidRanking
+	}
+
+	return s.repo.GetLeaderboard(ctx, period, ranking, limit)
+}
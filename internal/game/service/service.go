@@ -2,47 +2,190 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"roulette/internal/config"
+	dbModels "roulette/internal/database/models"
+	eventsService "roulette/internal/events/service"
 	"roulette/internal/game/model"
 	"roulette/internal/game/repo"
+	ledgerService "roulette/internal/ledger/service"
+	limitService "roulette/internal/limit/service"
+	pauseService "roulette/internal/pause/service"
+	valuationModel "roulette/internal/valuation/model"
+	valuationService "roulette/internal/valuation/service"
+	"roulette/pkg/fair"
 )
 
 type Service interface {
-	GetCurrentRound(ctx context.Context) (*model.Round, error)
+	// GetRooms returns the configured rooms in config order
+	GetRooms(ctx context.Context) []*model.Room
 
-	GetCurrentRoundWithPlayers(ctx context.Context) (*model.RoundWithPlayers, error)
+	GetCurrentRound(ctx context.Context, room string) (*model.Round, error)
+
+	GetCurrentRoundWithPlayers(ctx context.Context, room string) (*model.RoundWithPlayers, error)
 
 	GetRoundWithPlayers(ctx context.Context, roundID uint) (*model.RoundWithPlayers, error)
 
 	GetWinner(ctx context.Context, roundID uint) (*model.Winner, error)
 
-	AddRound(ctx context.Context) error
+	GetRoundProof(ctx context.Context, roundID uint) (*fair.Proof, error)
+
+	// GetRoundBets returns the item bets of the round with the valuation of each bet
+	GetRoundBets(ctx context.Context, roundID uint) ([]*model.Bet, error)
+
+	// GetRoundResults returns settled rounds before the cursor round, newest first. Empty room is any room
+	GetRoundResults(ctx context.Context, room string, cursor uint, limit int) ([]*model.RoundResult, error)
+
+	// GetUserResults returns settled rounds of the user before the cursor round, newest first
+	GetUserResults(ctx context.Context, userID uint, cursor uint, limit int) ([]*model.PlayerResult, error)
+
+	// GetLeaderboard ranks players of the current day, week or all time. An empty ranking is by volume
+	GetLeaderboard(ctx context.Context, period model.Period, ranking model.Ranking, limit int) ([]*model.LeaderboardEntry, error)
+
+	// AddUserNft bets the nft of the user in the current round of the room, nft of another user is not found.
+	// ErrPaused is returned while betting is paused, ErrStaleFloor while the collection has no current floor
+	AddUserNft(ctx context.Context, userID uint, room string, userNftID uint, clientSeed *string) error
+
+	// AddUserGift bets the gift of the user in the current round of the room, gift of another user is not found.
+	// ErrPaused is returned while betting is paused, ErrStaleFloor while the collection has no current floor
+	AddUserGift(ctx context.Context, userID uint, room string, userGiftID uint, clientSeed *string) error
+
+	// AddUserTon stakes amount nanotons of the user balance in the current round of the room.
+	// ErrNotEnoughBalance is returned when the balance is lower than amount
+	AddUserTon(ctx context.Context, userID uint, room string, amount int64, clientSeed *string) error
+
+	// CollectFees retries pending winner fees from balance and collects expired ones
+	CollectFees(ctx context.Context) error
+
+	// Advance moves the current round of the room to its next status under the round lock
+	// and returns how long to wait for the next move. A room without rounds gets an open round
+	Advance(ctx context.Context, room string) (time.Duration, error)
 
-	AddUserNft(ctx context.Context, userNftID uint) error
+	// addRound creates an open round in the room with the current rules of the room
+	addRound(ctx context.Context, room string) (*event, error)
 
-	AddUserGift(ctx context.Context, userGiftID uint) error
+	// startRound starts the countdown by the db clock
+	startRound(ctx context.Context, round *model.Round) (*event, error)
 
-	AddWinner(ctx context.Context, roundWithPlayers *model.RoundWithPlayers) error
+	// drawRound picks the winner and settles the round, a round without tickets is canceled
+	drawRound(ctx context.Context, round *model.Round) (*event, error)
 
-	UpdateFee(ctx context.Context, userID uint) error
+	// addWinner gives the items to the winner and pays the ton part of the pot minus the house fee
+	addWinner(ctx context.Context, roundWithPlayers *model.RoundWithPlayers, userID uint, ticket int, roundNumber string) error
 
-	StartRound(ctx context.Context, roundID uint) error
+	refundTonBets(ctx context.Context, roundID uint) error
 
-	getWinner(roundNumber string, totalTickets int, players []*model.Player) (uint, int)
+	getRoom(room string) (*model.Room, error)
 
-	generateRound() *model.Round
+	// valueItem prices the item for the round and returns the valuation with its json,
+	// ErrNoTickets for an item worth less than one ticket
+	valueItem(ctx context.Context, item *model.Gift, round *model.Round) (*valuationModel.Valuation, *string, error)
 
-	generateSecret(length int) string
+	// checkPendingFee returns ErrFeePending while the user owes the fee of a won round,
+	// so the winnings covering it can't be bet or withdrawn before it is settled
+	checkPendingFee(ctx context.Context, userID uint) error
 
-	getHash(roundNumber string, secret string) string
+	// checkBet checks the bet value against the rules of the round
+	checkBet(round *model.Round, bet int64, isGift bool) error
 
-	verifyHash(round *model.Round) bool
+	// settleFee takes the winner fee in a won item or from balance. A fee short of balance stays pending
+	// until it expires, then the house keeps an inventory item covering it or waives it
+	settleFee(ctx context.Context, winner *model.UnpaidWinner, wonItems []*model.Item) error
+
+	// keepItem gives the item to the house as the winner fee
+	keepItem(ctx context.Context, winner *model.UnpaidWinner, item *model.Item) error
+
+	updateSettlement(ctx context.Context, winnerID uint, settlement dbModels.FeeSettlement) error
+
+	// payTonPot moves the ton bets of the round to the winner and the house fee to the house
+	payTonPot(ctx context.Context, round *model.Round, userID uint) error
+
+	// payReferralFee pays the referrer of the user a share of the collected fee
+	payReferralFee(ctx context.Context, userID uint, key string, fee int64, referralFee int64, specReferralFee int64) error
+
+	getWinner(round *model.RoundWithPlayers) (uint, int, string)
+
+	getRanges(players []*model.Player) []*fair.Range
+
+	generateRound() (*model.Round, error)
 }
 
 type service struct {
-	repo repo.Repo
+	repo          repo.Repo
+	eventsService eventsService.Service
+	ledgerService ledgerService.Service
+	pauseService  pauseService.Service
+	limitService  limitService.Service
+	// valuationService prices nfts and gifts above their floor
+	valuationService valuationService.Service
+
+	// rooms are in config order, the rules of a room are copied to its new rounds
+	rooms []*model.Room
+
+	// feeInItems is set only with houseUserID, which owns items kept as fees
+	feeInItems  bool
+	houseUserID uint
+	feeExpiry   time.Duration
+}
+
+func NewService(
+	repo repo.Repo,
+	eventsService eventsService.Service,
+	ledgerService ledgerService.Service,
+	pauseService pauseService.Service,
+	limitService limitService.Service,
+	valuationService valuationService.Service,
+	cfg *config.Config,
+) Service {
+	return &service{
+		repo:             repo,
+		eventsService:    eventsService,
+		ledgerService:    ledgerService,
+		pauseService:     pauseService,
+		limitService:     limitService,
+		valuationService: valuationService,
+		rooms:            newRooms(cfg.GameConfig),
+		feeInItems:       cfg.GameConfig.FeeInItems && cfg.GameConfig.HouseUserID != 0,
+		houseUserID:      uint(cfg.GameConfig.HouseUserID),
+		feeExpiry:        cfg.GameConfig.FeeExpiry,
+	}
 }
 
-func NewService(repo repo.Repo) Service {
-	return &service{repo: repo}
+// newRooms applies the room overrides to the game rules
+func newRooms(gameConfig config.GameConfig) []*model.Room {
+	rules := model.Rules{
+		DurationSecs:    int(gameConfig.RoundDuration.Seconds()),
+		MinPlayers:      gameConfig.MinPlayers,
+		HouseFee:        gameConfig.HouseFee,
+		ReferralFee:     gameConfig.ReferralFee,
+		SpecReferralFee: gameConfig.SpecReferralFee,
+		TicketPrice:     gameConfig.TicketPrice,
+	}
+	if len(gameConfig.Rooms) == 0 {
+		return []*model.Room{{Name: model.DefaultRoom, Rules: rules}}
+	}
+
+	rooms := make([]*model.Room, 0, len(gameConfig.Rooms))
+	for _, roomConfig := range gameConfig.Rooms {
+		room := &model.Room{Name: roomConfig.Name, Rules: rules}
+		room.MinBet = roomConfig.MinBet
+		room.MaxBet = roomConfig.MaxBet
+		room.GiftsOnly = roomConfig.GiftsOnly
+		if roomConfig.RoundDuration > 0 {
+			room.DurationSecs = int(roomConfig.RoundDuration.Seconds())
+		}
+		if roomConfig.MinPlayers > 0 {
+			room.MinPlayers = roomConfig.MinPlayers
+		}
+		if roomConfig.HouseFee != nil {
+			room.HouseFee = *roomConfig.HouseFee
+		}
+		if roomConfig.TicketPrice > 0 {
+			room.TicketPrice = roomConfig.TicketPrice
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms
 }
@@ -5,15 +5,17 @@ import (
 
 	"roulette/internal/gift/service"
 	tonService "roulette/internal/ton/service"
+	walletService "roulette/internal/wallet/service"
 )
 
 type Handler struct {
-	service    service.Service
-	tonService tonService.Service
+	service       service.Service
+	tonService    tonService.Service
+	walletService walletService.Service
 }
 
-func NewHandler(service service.Service, tonService tonService.Service) *Handler {
-	return &Handler{service: service, tonService: tonService}
+func NewHandler(service service.Service, tonService tonService.Service, walletService walletService.Service) *Handler {
+	return &Handler{service: service, tonService: tonService, walletService: walletService}
 }
 
 func Router(h *Handler, r *gin.Engine) {
@@ -10,6 +10,7 @@ import (
 	"roulette/internal/middleware/handler"
 	"roulette/internal/ton/service"
 	"roulette/internal/utils"
+	walletService "roulette/internal/wallet/service"
 )
 
 func (h *Handler) getCollections(c *gin.Context) {
@@ -37,6 +38,19 @@ func (h *Handler) getNfts(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
+		// only wallets proven by ton_proof are listed
+		if err := h.walletService.CheckLinked(c.Request.Context(), userID, uri.Address); err != nil {
+			if walletService.IsWalletNotLinked(err) {
+				return handler.NewErrorResponse(http.StatusForbidden, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
 		collections, err := h.service.GetCollections(c.Request.Context())
 		if err != nil {
 			return handler.NewInternalErrorResponse(err)
@@ -64,6 +78,10 @@ func (h *Handler) getUserGifts(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
+		if res := handler.CheckCaller(c, uri.UserID); res != nil {
+			return res
+		}
+
 		userGifts, fee, err := h.service.GetUserGifts(c.Request.Context(), uri.UserID)
 		if err != nil {
 			if database.IsRecordNotFoundErr(err) {
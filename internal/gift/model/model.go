@@ -1,25 +1,34 @@
 package model
 
+import "time"
+
 type Collection struct {
 	ID      int     `json:"id"`
 	Name    string  `json:"name"`
 	Address *string `json:"address"`
 	Floor   int64   `json:"floor"`
+	// IsStale is set when the floor oracle could not price the collection for too long
+	FloorUpdatedAt *time.Time `json:"floorUpdatedAt"`
+	IsStale        bool       `json:"isStale"`
 	//ImgUrl  string `json:"imgUrl"`
 }
 
+// UserNft is locked in a tx, IsBet is set while the nft is in a round that isn't settled
 type UserNft struct {
 	ID      uint   `json:"id"`
 	UserID  uint   `json:"user_id"`
 	NftID   uint   `json:"nft_id"`
 	Address string `json:"address"`
+	IsBet   bool   `json:"is_bet"`
 }
 
+// UserGift is locked in a tx, IsBet is set while the gift is in a round that isn't settled
 type UserGift struct {
 	ID     uint `json:"id"`
 	UserID uint `json:"user_id"`
 	GiftID uint `json:"gift_id"`
 	MsgID  int  `json:"message_id"`
+	IsBet  bool `json:"is_bet"`
 }
 
 // Gift used for users gifts request
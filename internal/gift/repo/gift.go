@@ -2,7 +2,8 @@ package repo
 
 import (
 	"context"
-	"math/big"
+
+	"gorm.io/gorm/clause"
 
 	"roulette/internal/database"
 	dbModels "roulette/internal/database/models"
@@ -55,10 +56,17 @@ func (r *repo) GetUserNft(ctx context.Context, userNftID uint) (*model.UserNft,
 	var userNft *model.UserNft
 	err := db.WithContext(ctx).
 		Raw(`
-			SELECT un.id, un.user_id, un.nft_id, n.address
+			SELECT un.id, un.user_id, un.nft_id, n.address,
+				   EXISTS (
+				       SELECT 1
+				       FROM rounds_nfts rn
+				           JOIN rounds r ON r.id = rn.round_id
+				       WHERE rn.user_nft_id = un.id AND r.status <> 'settled'
+				   ) AS is_bet
 			FROM users_nfts un
 				LEFT OUTER JOIN nfts n ON un.nft_id = n.id
 			WHERE un.id = ?
+			FOR UPDATE OF un
 		`, userNftID).
 		Scan(&userNft).Error
 	if err != nil {
@@ -79,33 +87,45 @@ func (r *repo) GetUserGift(ctx context.Context, userGiftID uint) (*model.UserGif
 
 	var userGift *model.UserGift
 	err := db.WithContext(ctx).
-		Model(&dbModels.UserGiftDB{}).
-		Where("id = ?", userGiftID).
-		First(&userGift).Error
+		Raw(`
+			SELECT ug.id, ug.user_id, ug.gift_id, g.msg_id,
+				   EXISTS (
+				       SELECT 1
+				       FROM rounds_gifts rg
+				           JOIN rounds r ON r.id = rg.round_id
+				       WHERE rg.user_gift_id = ug.id AND r.status <> 'settled'
+				   ) AS is_bet
+			FROM users_gifts ug
+				LEFT OUTER JOIN gifts g ON ug.gift_id = g.id
+			WHERE ug.id = ?
+			FOR UPDATE OF ug
+		`, userGiftID).
+		Scan(&userGift).Error
 	if err != nil {
-		if database.IsRecordNotFoundErr(err) {
-			return nil, database.ErrNotFound
-		}
 		return nil, err
 	}
+	if userGift == nil {
+		return nil, database.ErrNotFound
+	}
 
 	return userGift, nil
 }
 
-func (r *repo) GetUserGifts(ctx context.Context, userID uint, roundID uint) (*model.UserGifts, error) {
+func (r *repo) GetUserGifts(ctx context.Context, userID uint) (*model.UserGifts, error) {
 	db := database.FromContext(ctx, r.db)
 
 	var nfts []*model.Gift
 	_ = db.WithContext(ctx).
 		Raw(`
-			SELECT un.id AS id, n.name AS name, n.collectible_id AS collectible_id, n.lottie_url AS lottie_url, c.floor AS floor, rn.round_id = $2 AS is_bet
+			SELECT un.id AS id, n.name AS name, n.collectible_id AS collectible_id, n.lottie_url AS lottie_url, c.floor AS floor,
+				   rn.round_id IN (SELECT id FROM rounds WHERE status <> 'settled') AS is_bet
 			FROM users u
 				LEFT OUTER JOIN users_nfts un ON u.id = un.user_id
 				LEFT OUTER JOIN nfts n ON n.id = un.nft_id
 				LEFT OUTER JOIN collections c ON n.collection_id = c.id
 				LEFT OUTER JOIN rounds_nfts rn ON un.id = rn.user_nft_id
 			WHERE u.id = $1::BIGINT AND un.id IS NOT NULL
-		`, userID, roundID).
+		`, userID).
 		Scan(&nfts)
 
 	//WHERE u.id = $1::BIGINT AND un.id IS NOT NULL AND (rn.round_id = $2 OR rn.round_id = $2 IS NULL)
@@ -113,14 +133,15 @@ func (r *repo) GetUserGifts(ctx context.Context, userID uint, roundID uint) (*mo
 	var gifts []*model.Gift
 	_ = db.WithContext(ctx).
 		Raw(`
-			SELECT ug.id AS id, g.name AS name, g.collectible_id AS collectible_id, g.lottie_url AS lottie_url, c.floor AS floor, rg.round_id = $2 AS is_bet
+			SELECT ug.id AS id, g.name AS name, g.collectible_id AS collectible_id, g.lottie_url AS lottie_url, c.floor AS floor,
+				   rg.round_id IN (SELECT id FROM rounds WHERE status <> 'settled') AS is_bet
 			FROM users u
 				LEFT OUTER JOIN users_gifts ug ON u.id = ug.user_id
 				LEFT OUTER JOIN gifts g ON g.id = ug.gift_id
 				LEFT OUTER JOIN collections c ON g.collection_id = c.id
 				LEFT OUTER JOIN rounds_gifts rg ON ug.id = rg.user_gift_id
 			WHERE u.id = $1::BIGINT AND ug.id IS NOT NULL
-		`, userID, roundID).
+		`, userID).
 		Scan(&gifts)
 
 	//WHERE u.id = $1::BIGINT AND ug.id IS NOT NULL AND (rg.round_id = $2 OR rg.round_id = $2 IS NULL)
@@ -146,43 +167,24 @@ func (r *repo) GetWinnerFee(ctx context.Context, userID uint) (int64, error) {
 			SELECT COALESCE(SUM(fee), 0) AS fee
 			FROM rounds_winners rw
 			WHERE rw.user_id = $1 AND rw.is_paid IS NULL
-			GROUP BY rw.user_id
 		`, userID).
 		Scan(&fee).Error
 	if err != nil {
-		return 0, nil
-	}
-
-	return fee, nil
-}
-
-func (r *repo) GetCurrentRoundID(ctx context.Context) (uint, error) {
-	db := database.FromContext(ctx, r.db)
-
-	var roundID uint
-	err := db.WithContext(ctx).
-		Raw(`
-			SELECT id
-			FROM rounds
-			ORDER BY created_at DESC
-			LIMIT 1
-		`).
-		First(&roundID).Error
-	if err != nil {
-		if database.IsRecordNotFoundErr(err) {
-			return 0, database.ErrNotFound
-		}
 		return 0, err
 	}
 
-	return roundID, nil
+	return fee, nil
 }
 
 func (r *repo) AddNft(ctx context.Context, nft *dbModels.NftDB) (uint, error) {
 	db := database.FromContext(ctx, r.db)
 
+	// the same nft may come back after a withdraw, the nft row is kept for the bets of past rounds
 	err := db.WithContext(ctx).
-		Model(&dbModels.NftDB{}).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "collectible_id", "lottie_url", "collection_id", "attributes"}),
+		}).
 		Create(&nft).Error
 	if err != nil {
 		if database.IsKeyConflictErr(err) {
@@ -197,7 +199,13 @@ func (r *repo) AddNft(ctx context.Context, nft *dbModels.NftDB) (uint, error) {
 func (r *repo) AddGift(ctx context.Context, gift *dbModels.GiftDB) (int64, error) {
 	db := database.FromContext(ctx, r.db)
 
-	err := db.WithContext(ctx).Create(&gift).Error
+	// the same gift may come back after a withdraw with a new saved message
+	err := db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"msg_id", "name", "collectible_id", "lottie_url", "collection_id", "attributes"}),
+		}).
+		Create(&gift).Error
 	if err != nil {
 		if database.IsKeyConflictErr(err) {
 			return 0, database.ErrKeyConflict
@@ -239,20 +247,3 @@ func (r *repo) AddUserGift(ctx context.Context, userID int64, giftID int64) erro
 
 	return nil
 }
-
-func (r *repo) UpdateCollectionFloor(ctx context.Context, collectionName string, floor *big.Int) error {
-	db := database.FromContext(ctx, r.db)
-
-	res := db.WithContext(ctx).
-		Model(&dbModels.CollectionDB{}).
-		Where("name = ?", collectionName).
-		UpdateColumn("floor", floor)
-	if res.Error != nil {
-		return res.Error
-	}
-	if res.RowsAffected == 0 {
-		return database.ErrNotFound
-	}
-
-	return nil
-}
@@ -2,7 +2,6 @@ package repo
 
 import (
 	"context"
-	"math/big"
 
 	"gorm.io/gorm"
 
@@ -17,16 +16,19 @@ type Repo interface {
 
 	GetCollections(ctx context.Context) ([]*model.Collection, error)
 
+	// GetUserNft locks the user nft when called in a tx
 	GetUserNft(ctx context.Context, userNftID uint) (*model.UserNft, error)
 
+	// GetUserGift locks the user gift when called in a tx
 	GetUserGift(ctx context.Context, userGiftID uint) (*model.UserGift, error)
 
-	GetUserGifts(ctx context.Context, userID, roundID uint) (*model.UserGifts, error)
+	// GetUserGifts marks items bet in an unsettled round
+	GetUserGifts(ctx context.Context, userID uint) (*model.UserGifts, error)
 
+	// GetWinnerFee returns the sum of unsettled fees of the rounds won by the user
 	GetWinnerFee(ctx context.Context, userID uint) (int64, error)
 
-	GetCurrentRoundID(ctx context.Context) (uint, error)
-
+	// AddNft stores the nft, the nft of the same address is updated
 	AddNft(ctx context.Context, nft *dbModels.NftDB) (uint, error)
 
 	AddGift(ctx context.Context, gift *dbModels.GiftDB) (int64, error)
@@ -34,8 +36,6 @@ type Repo interface {
 	AddUserNft(ctx context.Context, userID uint, nftID uint) error
 
 	AddUserGift(ctx context.Context, userID int64, giftID int64) error
-
-	UpdateCollectionFloor(ctx context.Context, collectionName string, floor *big.Int) error
 }
 
 type repo struct {
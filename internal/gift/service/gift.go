@@ -3,12 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
-	"math/big"
 
 	"roulette/internal/database"
 	dbModels "roulette/internal/database/models"
 	"roulette/internal/gift/model"
-	tgModel "roulette/internal/tg/model"
+	"roulette/internal/models"
 )
 
 func (s *service) GetCollectionByName(ctx context.Context, name string) (*model.Collection, error) {
@@ -31,7 +30,7 @@ func (s *service) GetUserNft(ctx context.Context, userNftID uint) (*model.UserNf
 	userNft, err := s.repo.GetUserNft(ctx, userNftID)
 	if err != nil {
 		if database.IsRecordNotFoundErr(err) {
-			return nil, fmt.Errorf("user nft %v not found", userNftID)
+			return nil, fmt.Errorf("user nft %v: %w", userNftID, err)
 		}
 		return nil, err
 	}
@@ -47,9 +46,7 @@ func (s *service) GetUserGift(ctx context.Context, userGiftID uint) (*model.User
 }
 
 func (s *service) GetUserGifts(ctx context.Context, userID uint) (*model.UserGifts, int64, error) {
-	roundID, _ := s.repo.GetCurrentRoundID(ctx)
-
-	userGifts, err := s.repo.GetUserGifts(ctx, userID, roundID)
+	userGifts, err := s.repo.GetUserGifts(ctx, userID)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -62,15 +59,25 @@ func (s *service) GetUserGifts(ctx context.Context, userID uint) (*model.UserGif
 	return userGifts, fee, nil
 }
 
-func (s *service) AddUserNft(ctx context.Context, userID uint, name string, collectibleID uint, address string, lottieUrl string, collectionID uint) error {
-	nft := &dbModels.NftDB{
-		Name:          name,
-		CollectibleID: collectibleID,
-		Address:       address,
-		LottieUrl:     lottieUrl,
+func (s *service) GetWinnerFee(ctx context.Context, userID uint) (int64, error) {
+	return s.repo.GetWinnerFee(ctx, userID)
+}
+
+func (s *service) AddUserNft(ctx context.Context, userID uint, nft *models.Nft, collectionID uint) error {
+	attributes, err := models.MarshalAttributes(nft.Attributes)
+	if err != nil {
+		return err
+	}
+
+	nftDB := &dbModels.NftDB{
+		Name:          nft.Name,
+		CollectibleID: uint(nft.CollectibleID),
+		Address:       nft.Address,
+		LottieUrl:     nft.LottieUrl,
 		CollectionID:  collectionID,
+		Attributes:    attributes,
 	}
-	nftID, err := s.repo.AddNft(ctx, nft)
+	nftID, err := s.repo.AddNft(ctx, nftDB)
 	if err != nil {
 		return err
 	}
@@ -82,71 +89,18 @@ func (s *service) AddUserNft(ctx context.Context, userID uint, name string, coll
 	return nil
 }
 
-func (s *service) AddUserGift(ctx context.Context, userID, giftID, msgID int64, name string, collectibleID, collectionID int, lottieUrl string) error {
-	gift := &dbModels.GiftDB{
-		ID:            giftID,
-		MsgID:         msgID,
-		Name:          name,
-		CollectibleID: collectibleID,
-		LottieUrl:     lottieUrl,
-		CollectionID:  collectionID,
-	}
-
-	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		newGiftID, err := s.repo.AddGift(ctx, gift)
-		if err != nil {
-			return err
-		}
-
-		if err = s.repo.AddUserGift(ctx, userID, newGiftID); err != nil {
-			return err
-		}
-
-		return nil
-	})
-	if errTx != nil {
-		return errTx
+func (s *service) AddGift(ctx context.Context, gift *dbModels.GiftDB) error {
+	if _, err := s.repo.AddGift(ctx, gift); err != nil {
+		return err
 	}
-
 	return nil
 }
 
-func (s *service) UpdateCollectionsFloor(ctx context.Context, floors []*tgModel.CollectionFloor) error {
-	collections, err := s.repo.GetCollections(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get collections: %v", err)
-	}
-
-	floorMap := make(map[string]*big.Int)
-	for _, floor := range floors {
-		floorMap[floor.Name] = floor.Floor
-	}
-
-	defaultFloor := big.NewInt(550000000)
-	for _, collection := range collections {
-		if _, ok := floorMap[collection.Name]; !ok {
-			collectionFloor := &tgModel.CollectionFloor{
-				Name:  collection.Name,
-				Floor: defaultFloor,
-			}
-			floors = append(floors, collectionFloor)
-		}
-	}
-
-	fmt.Println(floors)
-
-	var errs []error
-	for _, floor := range floors {
-		if err = s.repo.UpdateCollectionFloor(ctx, floor.Name, floor.Floor); err != nil {
-			errs = append(errs, err)
-		}
-	}
-
-	if len(errs) == 0 {
-		return nil
+func (s *service) AddUserGift(ctx context.Context, userID uint, giftID int64) error {
+	if err := s.repo.AddUserGift(ctx, int64(userID), giftID); err != nil {
+		return err
 	}
-
-	return fmt.Errorf("errs: %v", errs)
+	return nil
 }
 
 func (s *service) isGiftsAvailable(ctx context.Context, userID uint) (int64, error) {
@@ -3,9 +3,10 @@ package service
 import (
 	"context"
 
+	dbModels "roulette/internal/database/models"
 	"roulette/internal/gift/model"
 	"roulette/internal/gift/repo"
-	tgModel "roulette/internal/tg/model"
+	"roulette/internal/models"
 )
 
 type Service interface {
@@ -19,11 +20,17 @@ type Service interface {
 
 	GetUserGifts(ctx context.Context, userID uint) (*model.UserGifts, int64, error)
 
-	AddUserNft(ctx context.Context, userID uint, name string, collectibleID uint, address string, lottieUrl string, collectionID uint) error
+	// GetWinnerFee returns the fees the user owes for won rounds, the winnings can't leave until they are settled
+	GetWinnerFee(ctx context.Context, userID uint) (int64, error)
 
-	AddUserGift(ctx context.Context, userID, giftID, msgID int64, name string, collectibleID, collectionID int, lottieUrl string) error
+	// AddUserNft stores the nft with its attributes and gives it to the user, an existing nft is updated.
+	// ErrKeyConflict is returned while a user holds the nft
+	AddUserNft(ctx context.Context, userID uint, nft *models.Nft, collectionID uint) error
 
-	UpdateCollectionsFloor(ctx context.Context, floors []*tgModel.CollectionFloor) error
+	// AddGift stores gift info, an existing gift is updated
+	AddGift(ctx context.Context, gift *dbModels.GiftDB) error
+
+	AddUserGift(ctx context.Context, userID uint, giftID int64) error
 
 	isGiftsAvailable(ctx context.Context, userID uint) (int64, error)
 }
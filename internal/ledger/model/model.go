@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Kind string
+
+const (
+	TonDeposit     Kind = "ton_deposit"
+	StarDeposit    Kind = "star_deposit"
+	Withdraw       Kind = "withdraw"
+	WithdrawFee    Kind = "withdraw_fee"
+	WinFee         Kind = "win_fee"
+	ReferralReward Kind = "referral_reward"
+	AdminAdjust    Kind = "admin_adjust"
+	TonBet         Kind = "ton_bet"
+	TonWin         Kind = "ton_win"
+	TonRefund      Kind = "ton_refund"
+	PotFee         Kind = "pot_fee"
+)
+
+// Account is either a user balance or one of system accounts
+type Account string
+
+const (
+	// ExternalAccount is money outside the service: deposits come from it, withdrawals go to it
+	ExternalAccount Account = "external"
+	// HouseAccount collects fees and pays rewards
+	HouseAccount Account = "house"
+
+	userAccountPrefix = "user:"
+	potAccountPrefix  = "pot:"
+)
+
+func UserAccount(userID uint) Account {
+	return Account(fmt.Sprintf("%s%d", userAccountPrefix, userID))
+}
+
+// PotAccount holds ton bets of the round until the winner is paid
+func PotAccount(roundID uint) Account {
+	return Account(fmt.Sprintf("%s%d", potAccountPrefix, roundID))
+}
+
+// UserID returns user id for user accounts
+func (a Account) UserID() (uint, bool) {
+	id, ok := strings.CutPrefix(string(a), userAccountPrefix)
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// Posting moves Amount nanotons from Debit account to Credit account
+type Posting struct {
+	Key     string
+	Kind    Kind
+	Debit   Account
+	Credit  Account
+	Amount  int64
+	Comment *string
+}
+
+// Key builds idempotency key from kind and source ids
+func Key(kind Kind, ids ...interface{}) string {
+	parts := []string{string(kind)}
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprint(id))
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+)
+
+type Repo interface {
+	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
+
+	// AddEntries inserts entries skipping existing idempotency keys, returns inserted count
+	AddEntries(ctx context.Context, entries ...*dbModels.LedgerEntryDB) (int64, error)
+
+	GetEntries(ctx context.Context, key string) ([]*dbModels.LedgerEntryDB, error)
+
+	// UpdateUserBalance adds delta to users.balance if the result is not negative
+	UpdateUserBalance(ctx context.Context, userID uint, delta int64) error
+}
+
+type repo struct {
+	db *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repo {
+	return &repo{db: db}
+}
+
+func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, r.db, f)
+}
+
+func (r *repo) AddEntries(ctx context.Context, entries ...*dbModels.LedgerEntryDB) (int64, error) {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Select("idempotency_key", "kind", "account", "user_id", "amount", "comment").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entries)
+	if res.Error != nil {
+		if database.IsFKeyConflictError(res.Error) {
+			return 0, database.ErrFKeyConflict
+		}
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repo) GetEntries(ctx context.Context, key string) ([]*dbModels.LedgerEntryDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var entries []*dbModels.LedgerEntryDB
+	err := db.WithContext(ctx).
+		Where("idempotency_key = ?", key).
+		Order("id").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *repo) UpdateUserBalance(ctx context.Context, userID uint, delta int64) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Exec(`
+			UPDATE users
+			SET balance = balance + $2
+			WHERE id = $1 AND balance + $2 >= 0
+		`, userID, delta)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package service
+
+import "errors"
+
+var (
+	ErrInvalidPosting   = errors.New("invalid ledger posting")
+	ErrAlreadyPosted    = errors.New("ledger posting already exists")
+	ErrPostingNotFound  = errors.New("ledger posting not found")
+	ErrNotEnoughBalance = errors.New("not enough balance")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
+func IsAlreadyPosted(err error) bool {
+	return errors.Is(err, ErrAlreadyPosted)
+}
+
+func IsPostingNotFound(err error) bool {
+	return errors.Is(err, ErrPostingNotFound)
+}
+
+func IsNotEnoughBalance(err error) bool {
+	return errors.Is(err, ErrNotEnoughBalance)
+}
+
+func IsUserNotFound(err error) bool {
+	return errors.Is(err, ErrUserNotFound)
+}
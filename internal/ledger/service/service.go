@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/ledger/model"
+	"roulette/internal/ledger/repo"
+)
+
+type Service interface {
+	// Post writes a balanced pair of entries and updates users.balance projection.
+	// It joins the tx from ctx, ErrAlreadyPosted is returned for a known key
+	Post(ctx context.Context, posting *model.Posting) error
+
+	// GetPostedAmount returns the amount moved by the posting with the key
+	GetPostedAmount(ctx context.Context, key string) (int64, error)
+}
+
+type service struct {
+	repo repo.Repo
+}
+
+func NewService(repo repo.Repo) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Post(ctx context.Context, posting *model.Posting) error {
+	if posting.Key == "" || posting.Amount <= 0 || posting.Debit == posting.Credit {
+		return ErrInvalidPosting
+	}
+
+	entries := []*dbModels.LedgerEntryDB{
+		s.newEntry(posting, posting.Debit, -posting.Amount),
+		s.newEntry(posting, posting.Credit, posting.Amount),
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		inserted, err := s.repo.AddEntries(ctx, entries...)
+		if err != nil {
+			if database.IsFKeyConflictError(err) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if inserted == 0 {
+			return ErrAlreadyPosted
+		}
+
+		for _, entry := range entries {
+			if entry.UserID == nil {
+				continue
+			}
+			if err = s.repo.UpdateUserBalance(ctx, *entry.UserID, entry.Amount); err != nil {
+				if database.IsRecordNotFoundErr(err) {
+					return ErrNotEnoughBalance
+				}
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *service) GetPostedAmount(ctx context.Context, key string) (int64, error) {
+	entries, err := s.repo.GetEntries(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if entry.Amount > 0 {
+			return entry.Amount, nil
+		}
+	}
+
+	return 0, ErrPostingNotFound
+}
+
+func (s *service) newEntry(posting *model.Posting, account model.Account, amount int64) *dbModels.LedgerEntryDB {
+	entry := &dbModels.LedgerEntryDB{
+		IdempotencyKey: posting.Key,
+		Kind:           string(posting.Kind),
+		Account:        string(account),
+		Amount:         amount,
+		Comment:        posting.Comment,
+	}
+	if userID, ok := account.UserID(); ok {
+		entry.UserID = &userID
+	}
+	return entry
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/database/dbtest"
+	"roulette/internal/ledger/model"
+	"roulette/internal/ledger/repo"
+)
+
+const testUserID = 1
+
+func newTestService(t *testing.T) (Service, *gorm.DB) {
+	t.Helper()
+
+	db := dbtest.OpenMigrated(t)
+	if err := db.Exec("INSERT INTO users (id, memo) VALUES (?, 'testmemo')", testUserID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return NewService(repo.NewRepo(db)), db
+}
+
+func balance(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+
+	var balance int64
+	if err := db.Raw("SELECT balance FROM users WHERE id = ?", testUserID).Scan(&balance).Error; err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func entries(t *testing.T, db *gorm.DB, key string) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM ledger_entries WHERE idempotency_key = ?", key).Scan(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func deposit(key string, amount int64) *model.Posting {
+	return &model.Posting{
+		Key:    key,
+		Kind:   model.TonDeposit,
+		Debit:  model.ExternalAccount,
+		Credit: model.UserAccount(testUserID),
+		Amount: amount,
+	}
+}
+
+func TestPostInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		posting *model.Posting
+	}{
+		{name: "no key", posting: deposit("", 10)},
+		{name: "zero amount", posting: deposit("ton_deposit:zero", 0)},
+		{name: "negative amount", posting: deposit("ton_deposit:negative", -10)},
+		{
+			name: "same accounts",
+			posting: &model.Posting{
+				Key:    "admin_adjust:same",
+				Kind:   model.AdminAdjust,
+				Debit:  model.HouseAccount,
+				Credit: model.HouseAccount,
+				Amount: 10,
+			},
+		},
+	}
+	// the posting is rejected before the repo is used
+	s := NewService(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Post(context.Background(), tt.posting); !errors.Is(err, ErrInvalidPosting) {
+				t.Fatalf("Post() = %v, want %v", err, ErrInvalidPosting)
+			}
+		})
+	}
+}
+
+func TestPostIdempotent(t *testing.T) {
+	ctx := context.Background()
+	s, db := newTestService(t)
+
+	key := model.Key(model.TonDeposit, "msg-hash")
+	if err := s.Post(ctx, deposit(key, 100)); err != nil {
+		t.Fatalf("first Post() = %v", err)
+	}
+
+	// a replay with the same key is not credited again, whatever the amount
+	for _, amount := range []int64{100, 500} {
+		if err := s.Post(ctx, deposit(key, amount)); !IsAlreadyPosted(err) {
+			t.Fatalf("Post() replay of %d = %v, want %v", amount, err, ErrAlreadyPosted)
+		}
+	}
+
+	if got := balance(t, db); got != 100 {
+		t.Fatalf("balance = %d, want 100", got)
+	}
+	if got := entries(t, db, key); got != 2 {
+		t.Fatalf("entries of %s = %d, want 2", key, got)
+	}
+
+	amount, err := s.GetPostedAmount(ctx, key)
+	if err != nil || amount != 100 {
+		t.Fatalf("GetPostedAmount() = %d, %v, want 100", amount, err)
+	}
+}
+
+func TestPostNegativeBalance(t *testing.T) {
+	ctx := context.Background()
+	s, db := newTestService(t)
+
+	if err := s.Post(ctx, deposit(model.Key(model.TonDeposit, "msg-hash"), 100)); err != nil {
+		t.Fatalf("deposit Post() = %v", err)
+	}
+
+	withdraw := func(key string, amount int64) *model.Posting {
+		return &model.Posting{
+			Key:    key,
+			Kind:   model.Withdraw,
+			Debit:  model.UserAccount(testUserID),
+			Credit: model.ExternalAccount,
+			Amount: amount,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		posting *model.Posting
+		want    error
+		balance int64
+	}{
+		{name: "more than balance", posting: withdraw(model.Key(model.Withdraw, 1), 101), want: ErrNotEnoughBalance, balance: 100},
+		{name: "whole balance", posting: withdraw(model.Key(model.Withdraw, 2), 100), balance: 0},
+		{name: "empty balance", posting: withdraw(model.Key(model.Withdraw, 3), 1), want: ErrNotEnoughBalance, balance: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Post(ctx, tt.posting)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Post() = %v, want %v", err, tt.want)
+			}
+			if got := balance(t, db); got != tt.balance {
+				t.Fatalf("balance = %d, want %d", got, tt.balance)
+			}
+
+			// a rejected posting leaves no entries, so it may be posted later
+			wantEntries := int64(2)
+			if tt.want != nil {
+				wantEntries = 0
+			}
+			if got := entries(t, db, tt.posting.Key); got != wantEntries {
+				t.Fatalf("entries of %s = %d, want %d", tt.posting.Key, got, wantEntries)
+			}
+		})
+	}
+}
+
+func TestPostUnknownUser(t *testing.T) {
+	s, _ := newTestService(t)
+
+	posting := &model.Posting{
+		Key:    model.Key(model.TonDeposit, "unknown-user"),
+		Kind:   model.TonDeposit,
+		Debit:  model.ExternalAccount,
+		Credit: model.UserAccount(testUserID + 1),
+		Amount: 10,
+	}
+	if err := s.Post(context.Background(), posting); !IsUserNotFound(err) {
+		t.Fatalf("Post() = %v, want %v", err, ErrUserNotFound)
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/limit/service"
+)
+
+type Handler struct {
+	service service.Service
+}
+
+func NewHandler(service service.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func Router(h *Handler, r *gin.Engine) {
+	router := r.Group("limits")
+	{
+		router.GET("/:user_id", h.getUserLimits)
+
+		router.POST("/:user_id/exclusion", h.exclude)
+	}
+}
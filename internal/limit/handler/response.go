@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"time"
+
+	"roulette/internal/limit/model"
+)
+
+type UserLimitsResponse struct {
+	*model.UserLimits
+}
+
+func NewUserLimitsResponse(userLimits *model.UserLimits) *UserLimitsResponse {
+	return &UserLimitsResponse{
+		UserLimits: userLimits,
+	}
+}
+
+type ExclusionResponse struct {
+	ExcludedUntil *time.Time `json:"excludedUntil"`
+}
+
+func NewExclusionResponse(excludedUntil *time.Time) *ExclusionResponse {
+	return &ExclusionResponse{
+		ExcludedUntil: excludedUntil,
+	}
+}
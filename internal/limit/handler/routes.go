@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/limit/service"
+	"roulette/internal/middleware/handler"
+)
+
+func (h *Handler) getUserLimits(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			UserID uint `uri:"user_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if res := handler.CheckCaller(c, uri.UserID); res != nil {
+			return res
+		}
+
+		userLimits, err := h.service.GetUserLimits(c.Request.Context(), uri.UserID)
+		if err != nil {
+			if service.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewUserLimitsResponse(userLimits))
+	})
+}
+
+func (h *Handler) exclude(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		type RequestUri struct {
+			UserID uint `uri:"user_id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		type RequestBody struct {
+			Days int `json:"days"`
+		}
+		var body RequestBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if res := handler.CheckCaller(c, uri.UserID); res != nil {
+			return res
+		}
+
+		excludedUntil, err := h.service.Exclude(c.Request.Context(), uri.UserID, body.Days)
+		if err != nil {
+			if service.IsInvalidExclusion(err) {
+				return handler.NewUnprocessableErrorResponse(err)
+			}
+			if service.IsUserNotFound(err) {
+				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+			}
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewExclusionResponse(excludedUntil))
+	})
+}
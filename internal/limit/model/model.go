@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Limits are the bet limits of a user, zero is unbounded
+type Limits struct {
+	RoundBets     int        `json:"roundBets"`
+	RoundValue    int64      `json:"roundValue"`
+	DailyBets     int        `json:"dailyBets"`
+	DailyValue    int64      `json:"dailyValue"`
+	ExcludedUntil *time.Time `json:"excludedUntil"`
+}
+
+// Usage is what the user bet in the round and in the last 24 hours
+type Usage struct {
+	RoundBets  int   `json:"-"`
+	RoundValue int64 `json:"-"`
+	DailyBets  int   `json:"dailyBets"`
+	DailyValue int64 `json:"dailyValue"`
+}
+
+type UserLimits struct {
+	*Limits
+	Usage *Usage `json:"usage"`
+}
+
+// LimitsUpdate replaces the limits of a user, nil limits go back to the default
+type LimitsUpdate struct {
+	RoundBets  *int   `json:"roundBets"`
+	RoundValue *int64 `json:"roundValue"`
+	DailyBets  *int   `json:"dailyBets"`
+	DailyValue *int64 `json:"dailyValue"`
+}
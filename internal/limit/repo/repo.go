@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/limit/model"
+)
+
+type Repo interface {
+	// GetUserLimits returns the overrides of the user, a user without them has nil limits
+	GetUserLimits(ctx context.Context, userID uint) (*dbModels.UserLimitsDB, error)
+
+	// LockUserLimits is GetUserLimits locking the user row until the end of tx
+	LockUserLimits(ctx context.Context, userID uint) (*dbModels.UserLimitsDB, error)
+
+	// GetUsage sums the bets of the user in the round and in the last 24 hours by the db clock
+	GetUsage(ctx context.Context, userID uint, roundID uint) (*model.Usage, error)
+
+	// UpdateLimits sets the limits of the user and keeps the exclusion
+	UpdateLimits(ctx context.Context, limits *dbModels.UserLimitsDB) error
+
+	// ExtendExclusion sets the exclusion of the user unless it already ends later
+	ExtendExclusion(ctx context.Context, userID uint, until time.Time) error
+
+	DeleteExclusion(ctx context.Context, userID uint) error
+}
+
+type repo struct {
+	db *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repo {
+	return &repo{db: db}
+}
+
+func (r *repo) GetUserLimits(ctx context.Context, userID uint) (*dbModels.UserLimitsDB, error) {
+	return r.getUserLimits(ctx, userID, "")
+}
+
+func (r *repo) LockUserLimits(ctx context.Context, userID uint) (*dbModels.UserLimitsDB, error) {
+	return r.getUserLimits(ctx, userID, "FOR UPDATE OF u")
+}
+
+func (r *repo) getUserLimits(ctx context.Context, userID uint, lock string) (*dbModels.UserLimitsDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var limits *dbModels.UserLimitsDB
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT u.id AS user_id, ul.round_bets, ul.round_value, ul.daily_bets, ul.daily_value,
+				   ul.excluded_until, ul.updated_by, COALESCE(ul.updated_at, u.created_at) AS updated_at
+			FROM users u
+				LEFT OUTER JOIN users_limits ul ON ul.user_id = u.id
+			WHERE u.id = $1
+		`+lock, userID).
+		First(&limits).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+func (r *repo) GetUsage(ctx context.Context, userID uint, roundID uint) (*model.Usage, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var usage *model.Usage
+	err := db.WithContext(ctx).
+		Raw(`
+			SELECT COUNT(*) FILTER (WHERE round_id = $2) AS round_bets,
+				   COALESCE(SUM(bet) FILTER (WHERE round_id = $2), 0) AS round_value,
+				   COUNT(*) FILTER (WHERE created_at > CURRENT_TIMESTAMP - INTERVAL '1 day') AS daily_bets,
+				   COALESCE(SUM(bet) FILTER (WHERE created_at > CURRENT_TIMESTAMP - INTERVAL '1 day'), 0) AS daily_value
+			FROM rounds_tickets
+			WHERE user_id = $1 AND (round_id = $2 OR created_at > CURRENT_TIMESTAMP - INTERVAL '1 day')
+		`, userID, roundID).
+		Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (r *repo) UpdateLimits(ctx context.Context, limits *dbModels.UserLimitsDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Exec(`
+			INSERT INTO users_limits (user_id, round_bets, round_value, daily_bets, daily_value, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id) DO UPDATE
+			SET round_bets = EXCLUDED.round_bets,
+				round_value = EXCLUDED.round_value,
+				daily_bets = EXCLUDED.daily_bets,
+				daily_value = EXCLUDED.daily_value,
+				updated_by = EXCLUDED.updated_by,
+				updated_at = CURRENT_TIMESTAMP
+		`, limits.UserID, limits.RoundBets, limits.RoundValue, limits.DailyBets, limits.DailyValue, limits.UpdatedBy).Error
+	if err != nil {
+		if database.IsFKeyConflictError(err) {
+			return database.ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) ExtendExclusion(ctx context.Context, userID uint, until time.Time) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Exec(`
+			INSERT INTO users_limits (user_id, excluded_until)
+			VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE
+			SET excluded_until = GREATEST(users_limits.excluded_until, EXCLUDED.excluded_until),
+				updated_at = CURRENT_TIMESTAMP
+		`, userID, until).Error
+	if err != nil {
+		if database.IsFKeyConflictError(err) {
+			return database.ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) DeleteExclusion(ctx context.Context, userID uint) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Model(&dbModels.UserLimitsDB{}).
+		Where("user_id = ? AND excluded_until IS NOT NULL", userID).
+		Updates(map[string]interface{}{"excluded_until": nil, "updated_at": gorm.Expr("CURRENT_TIMESTAMP")})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import "errors"
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrExcluded          = errors.New("betting is self-excluded")
+	ErrRoundBetsLimit    = errors.New("round bet count limit reached")
+	ErrRoundValueLimit   = errors.New("round bet value limit reached")
+	ErrDailyBetsLimit    = errors.New("daily bet count limit reached")
+	ErrDailyValueLimit   = errors.New("daily bet value limit reached")
+	ErrInvalidExclusion  = errors.New("invalid exclusion days")
+	ErrInvalidLimits     = errors.New("limits must not be negative")
+	ErrExclusionNotFound = errors.New("user is not excluded")
+)
+
+func IsUserNotFound(err error) bool {
+	return errors.Is(err, ErrUserNotFound)
+}
+
+func IsExcluded(err error) bool {
+	return errors.Is(err, ErrExcluded)
+}
+
+// IsLimitReached reports whether the bet would break a limit of the user
+func IsLimitReached(err error) bool {
+	return errors.Is(err, ErrRoundBetsLimit) || errors.Is(err, ErrRoundValueLimit) ||
+		errors.Is(err, ErrDailyBetsLimit) || errors.Is(err, ErrDailyValueLimit)
+}
+
+func IsInvalidExclusion(err error) bool {
+	return errors.Is(err, ErrInvalidExclusion)
+}
+
+func IsInvalidLimits(err error) bool {
+	return errors.Is(err, ErrInvalidLimits)
+}
+
+func IsExclusionNotFound(err error) bool {
+	return errors.Is(err, ErrExclusionNotFound)
+}
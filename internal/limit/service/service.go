@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"roulette/internal/config"
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/limit/model"
+	"roulette/internal/limit/repo"
+)
+
+type Service interface {
+	// Check returns ErrExcluded or a limit error when the bet would break the limits of the user.
+	// It locks the user until the end of tx, so it runs in the bet tx before the bet is added
+	Check(ctx context.Context, userID uint, roundID uint, bet int64) error
+
+	// GetUserLimits returns the limits of the user with the bets of the last 24 hours
+	GetUserLimits(ctx context.Context, userID uint) (*model.UserLimits, error)
+
+	// Exclude stops the user from betting for days, an exclusion is only extended
+	Exclude(ctx context.Context, userID uint, days int) (*time.Time, error)
+
+	// SetLimits replaces the limits of the user, nil limits go back to the default
+	SetLimits(ctx context.Context, userID uint, update *model.LimitsUpdate, updatedBy string) error
+
+	// LiftExclusion ends the exclusion of the user
+	LiftExclusion(ctx context.Context, userID uint) error
+
+	// limits applies the overrides of the user to the default limits
+	limits(userLimits *dbModels.UserLimitsDB) *model.Limits
+}
+
+type service struct {
+	repo repo.Repo
+
+	defaults         model.Limits
+	maxExclusionDays int
+}
+
+func NewService(repo repo.Repo, cfg *config.Config) Service {
+	return &service{
+		repo: repo,
+		defaults: model.Limits{
+			RoundBets:  cfg.LimitConfig.RoundBets,
+			RoundValue: cfg.LimitConfig.RoundValue,
+			DailyBets:  cfg.LimitConfig.DailyBets,
+			DailyValue: cfg.LimitConfig.DailyValue,
+		},
+		maxExclusionDays: cfg.LimitConfig.MaxExclusionDays,
+	}
+}
+
+func (s *service) Check(ctx context.Context, userID uint, roundID uint, bet int64) error {
+	userLimits, err := s.repo.LockUserLimits(ctx, userID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	limits := s.limits(userLimits)
+	if limits.ExcludedUntil != nil && limits.ExcludedUntil.After(time.Now()) {
+		return fmt.Errorf("%w until %s", ErrExcluded, limits.ExcludedUntil.UTC().Format(time.RFC3339))
+	}
+
+	usage, err := s.repo.GetUsage(ctx, userID, roundID)
+	if err != nil {
+		return err
+	}
+
+	if limits.RoundBets > 0 && usage.RoundBets >= limits.RoundBets {
+		return fmt.Errorf("%w: %d bets", ErrRoundBetsLimit, limits.RoundBets)
+	}
+	if limits.RoundValue > 0 && usage.RoundValue+bet > limits.RoundValue {
+		return fmt.Errorf("%w: %d of %d left", ErrRoundValueLimit, max(limits.RoundValue-usage.RoundValue, 0), limits.RoundValue)
+	}
+	if limits.DailyBets > 0 && usage.DailyBets >= limits.DailyBets {
+		return fmt.Errorf("%w: %d bets", ErrDailyBetsLimit, limits.DailyBets)
+	}
+	if limits.DailyValue > 0 && usage.DailyValue+bet > limits.DailyValue {
+		return fmt.Errorf("%w: %d of %d left", ErrDailyValueLimit, max(limits.DailyValue-usage.DailyValue, 0), limits.DailyValue)
+	}
+
+	return nil
+}
+
+func (s *service) GetUserLimits(ctx context.Context, userID uint) (*model.UserLimits, error) {
+	userLimits, err := s.repo.GetUserLimits(ctx, userID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	usage, err := s.repo.GetUsage(ctx, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserLimits{
+		Limits: s.limits(userLimits),
+		Usage:  usage,
+	}, nil
+}
+
+func (s *service) Exclude(ctx context.Context, userID uint, days int) (*time.Time, error) {
+	if days <= 0 || days > s.maxExclusionDays {
+		return nil, fmt.Errorf("%w: from 1 to %d", ErrInvalidExclusion, s.maxExclusionDays)
+	}
+
+	until := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+	if err := s.repo.ExtendExclusion(ctx, userID, until); err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	userLimits, err := s.repo.GetUserLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return userLimits.ExcludedUntil, nil
+}
+
+func (s *service) SetLimits(ctx context.Context, userID uint, update *model.LimitsUpdate, updatedBy string) error {
+	if isNegative(update.RoundBets) || isNegative(update.RoundValue) || isNegative(update.DailyBets) || isNegative(update.DailyValue) {
+		return ErrInvalidLimits
+	}
+
+	err := s.repo.UpdateLimits(ctx, &dbModels.UserLimitsDB{
+		UserID:     userID,
+		RoundBets:  update.RoundBets,
+		RoundValue: update.RoundValue,
+		DailyBets:  update.DailyBets,
+		DailyValue: update.DailyValue,
+		UpdatedBy:  &updatedBy,
+	})
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) LiftExclusion(ctx context.Context, userID uint) error {
+	if err := s.repo.DeleteExclusion(ctx, userID); err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return ErrExclusionNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) limits(userLimits *dbModels.UserLimitsDB) *model.Limits {
+	limits := s.defaults
+	if userLimits.RoundBets != nil {
+		limits.RoundBets = *userLimits.RoundBets
+	}
+	if userLimits.RoundValue != nil {
+		limits.RoundValue = *userLimits.RoundValue
+	}
+	if userLimits.DailyBets != nil {
+		limits.DailyBets = *userLimits.DailyBets
+	}
+	if userLimits.DailyValue != nil {
+		limits.DailyValue = *userLimits.DailyValue
+	}
+	limits.ExcludedUntil = userLimits.ExcludedUntil
+
+	return &limits
+}
+
+func isNegative[T int | int64](v *T) bool {
+	return v != nil && *v < 0
+}
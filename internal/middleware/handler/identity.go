@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/middleware"
+)
+
+// CallerID returns the user resolved by AuthMiddleware, the response is returned when the caller is unknown
+func CallerID(c *gin.Context) (uint, *Response) {
+	userID, ok := middleware.UserID(c.Request.Context())
+	if !ok {
+		return 0, NewErrorResponse(http.StatusUnauthorized, "Unauthorized")
+	}
+	return userID, nil
+}
+
+// CheckCaller rejects requests on behalf of another user
+func CheckCaller(c *gin.Context, userID uint) *Response {
+	callerID, res := CallerID(c)
+	if res != nil {
+		return res
+	}
+	if callerID != userID {
+		return NewErrorResponse(http.StatusForbidden, "Forbidden")
+	}
+	return nil
+}
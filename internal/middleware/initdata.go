@@ -4,12 +4,15 @@ import (
 	"context"
 	
 	initdata "github.com/telegram-mini-apps/init-data-golang"
+
+	adminModel "roulette/internal/admin/model"
 )
 
 type contextKey string
 
 const (
 	_initDataKey contextKey = "init-data"
+	_adminKey    contextKey = "admin"
 )
 
 func withInitData(ctx context.Context, initData initdata.InitData) context.Context {
@@ -20,3 +23,22 @@ func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
 	initData, ok := ctx.Value(_initDataKey).(initdata.InitData)
 	return initData, ok
 }
+
+// UserID returns the caller resolved by AuthMiddleware, user ids are telegram ids
+func UserID(ctx context.Context) (uint, bool) {
+	initData, ok := ctxInitData(ctx)
+	if !ok || initData.User.ID <= 0 {
+		return 0, false
+	}
+	return uint(initData.User.ID), true
+}
+
+func withAdmin(ctx context.Context, admin *adminModel.Admin) context.Context {
+	return context.WithValue(ctx, _adminKey, admin)
+}
+
+// Admin returns the caller resolved by AdminMiddleware
+func Admin(ctx context.Context) (*adminModel.Admin, bool) {
+	admin, ok := ctx.Value(_adminKey).(*adminModel.Admin)
+	return admin, ok
+}
@@ -2,18 +2,29 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	initdata "github.com/telegram-mini-apps/init-data-golang"
+
+	adminModel "roulette/internal/admin/model"
+	"roulette/internal/config"
 )
 
-// TimeoutMiddleware attaches deadline to gin.Request.Context
-func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+// TimeoutMiddleware attaches deadline to gin.Request.Context, long-lived routes are skipped
+func TimeoutMiddleware(timeout time.Duration, skipPaths ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if slices.Contains(skipPaths, c.FullPath()) {
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 
 		defer func() {
@@ -27,40 +38,139 @@ func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	}
 }
 
-// AuthMiddleware validates tg init data
-func AuthMiddleware(token string, mode string) gin.HandlerFunc {
+// TestUserHeader sets the caller in debug mode instead of init data
+const TestUserHeader = "X-Test-User-Id"
+
+// InitDataQuery carries init data on routes that can't set headers, as browser EventSource
+const InitDataQuery = "initData"
+
+// AuthMiddleware validates tg init data and stores it as the caller identity,
+// queryPaths also take it from InitDataQuery, routes of skipGroups have their own auth
+func AuthMiddleware(token string, mode string, queryPaths []string, skipGroups ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		for _, group := range skipGroups {
+			if strings.HasPrefix(ctx.FullPath(), group+"/") {
+				return
+			}
+		}
+
 		if mode == gin.DebugMode {
+			userID, err := strconv.ParseInt(ctx.GetHeader(TestUserHeader), 10, 64)
+			if err != nil || userID <= 0 {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
+					"detail": "Unauthorized",
+				})
+				return
+			}
+
+			ctx.Request = ctx.Request.WithContext(
+				withInitData(ctx.Request.Context(), initdata.InitData{User: initdata.User{ID: userID}}),
+			)
 			return
 		}
 
+		var authData string
 		auth := strings.Split(ctx.GetHeader("authorization"), " ")
-		if len(auth) != 2 {
+		switch {
+		case len(auth) == 2 && auth[0] == "Tg":
+			authData = auth[1]
+		case slices.Contains(queryPaths, ctx.FullPath()) && ctx.Query(InitDataQuery) != "":
+			authData = ctx.Query(InitDataQuery)
+		default:
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
 				"detail": "Unauthorized",
 			})
 			return
 		}
 
-		authType, authData := auth[0], auth[1]
+		if err := initdata.Validate(authData, token, time.Hour); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
+				"detail": "Invalid init data",
+			})
+			return
+		}
 
-		if authType == "Tg" {
-			if err := initdata.Validate(authData, token, time.Hour); err != nil {
-				ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
-					"detail": "Invalid init data",
-				})
-			}
+		initData, err := initdata.Parse(authData)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, map[string]string{
+				"detail": "Something went wrong",
+			})
+			return
+		}
 
-			initData, err := initdata.Parse(authData)
-			if err != nil {
-				ctx.AbortWithStatusJSON(http.StatusInternalServerError, map[string]string{
-					"detail": "Something went wrong",
-				})
+		ctx.Request = ctx.Request.WithContext(
+			withInitData(ctx.Request.Context(), initData),
+		)
+	}
+}
+
+// AdminMiddleware authenticates admin api by api key or init data of an admin user.
+// It replaces AuthMiddleware for the admin group
+func AdminMiddleware(token string, cfg config.AdminConfig, mode string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := resolveAdmin(ctx, token, cfg, mode)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
+				"detail": "Unauthorized",
+			})
+			return
+		}
+
+		ctx.Request = ctx.Request.WithContext(
+			withAdmin(ctx.Request.Context(), admin),
+		)
+	}
+}
+
+// RoleMiddleware allows admins with the role or a higher one, must be used after AdminMiddleware
+func RoleMiddleware(role adminModel.Role) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := Admin(ctx.Request.Context())
+		if !ok || !admin.Role.Allows(role) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, map[string]string{
+				"detail": "Forbidden",
+			})
+		}
+	}
+}
+
+func resolveAdmin(ctx *gin.Context, token string, cfg config.AdminConfig, mode string) (*adminModel.Admin, bool) {
+	auth := strings.Split(ctx.GetHeader("authorization"), " ")
+
+	if len(auth) == 2 && auth[0] == "Bearer" {
+		for _, key := range cfg.Keys {
+			if key.Key != "" && subtle.ConstantTimeCompare([]byte(key.Key), []byte(auth[1])) == 1 {
+				return &adminModel.Admin{Actor: "key:" + key.Name, Role: adminModel.Role(key.Role)}, true
 			}
+		}
+		return nil, false
+	}
 
-			ctx.Request = ctx.Request.WithContext(
-				withInitData(ctx.Request.Context(), initData),
-			)
+	var userID int64
+	switch {
+	case mode == gin.DebugMode:
+		id, err := strconv.ParseInt(ctx.GetHeader(TestUserHeader), 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		userID = id
+	case len(auth) == 2 && auth[0] == "Tg":
+		if err := initdata.Validate(auth[1], token, time.Hour); err != nil {
+			return nil, false
+		}
+		initData, err := initdata.Parse(auth[1])
+		if err != nil {
+			return nil, false
+		}
+		userID = initData.User.ID
+	default:
+		return nil, false
+	}
+
+	for _, user := range cfg.Users {
+		if user.ID == userID {
+			return &adminModel.Admin{Actor: fmt.Sprintf("tg:%d", userID), Role: adminModel.Role(user.Role)}, true
 		}
 	}
+	return nil, false
 }
@@ -1,9 +1,70 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type Nft struct {
 	Name          string `json:"name"`
 	CollectibleID uint64 `json:"collectibleId"`
 	Address       string `json:"address"`
 	LottieUrl     string `json:"lottieUrl"`
 	CollectionID  int    `json:"collectionId"`
+	// Attributes are nil when the metadata has none
+	Attributes []*Attribute `json:"attributes"`
+}
+
+// Attribute is a trait of an item, Rarity is permille of the collection having it, 0 when unknown
+type Attribute struct {
+	Trait  string `json:"trait"`
+	Value  string `json:"value"`
+	Rarity int    `json:"rarity,omitempty"`
+}
+
+// MetadataAttribute is a trait in the standard nft metadata, some collections have number values
+type MetadataAttribute struct {
+	TraitType string      `json:"trait_type"`
+	Value     interface{} `json:"value"`
+}
+
+// NewAttributes converts metadata traits, traits without a type or value are skipped
+func NewAttributes(metadata []*MetadataAttribute) []*Attribute {
+	var attributes []*Attribute
+	for _, attr := range metadata {
+		if attr == nil || attr.TraitType == "" || attr.Value == nil {
+			continue
+		}
+		value := fmt.Sprint(attr.Value)
+		if value == "" {
+			continue
+		}
+		attributes = append(attributes, &Attribute{Trait: attr.TraitType, Value: value})
+	}
+	return attributes
+}
+
+// MarshalAttributes returns the json stored with the item, nil for no attributes
+func MarshalAttributes(attributes []*Attribute) (*string, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal attributes: %v", err)
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// UnmarshalAttributes reads the json stored with the item, nil is no attributes
+func UnmarshalAttributes(attributes *string) ([]*Attribute, error) {
+	if attributes == nil {
+		return nil, nil
+	}
+	var res []*Attribute
+	if err := json.Unmarshal([]byte(*attributes), &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal attributes: %v", err)
+	}
+	return res, nil
 }
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	dbModels "roulette/internal/database/models"
+)
+
+type Pause struct {
+	Feature   dbModels.Feature `json:"feature"`
+	Reason    *string          `json:"reason"`
+	PausedBy  string           `json:"pausedBy"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+func NewPause(p *dbModels.PauseDB) *Pause {
+	return &Pause{
+		Feature:   p.Feature,
+		Reason:    p.Reason,
+		PausedBy:  p.PausedBy,
+		CreatedAt: p.CreatedAt,
+	}
+}
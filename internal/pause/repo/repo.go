@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+)
+
+type Repo interface {
+	GetPause(ctx context.Context, feature dbModels.Feature) (*dbModels.PauseDB, error)
+
+	GetPauses(ctx context.Context) ([]*dbModels.PauseDB, error)
+
+	// AddPause keeps the existing pause of the feature
+	AddPause(ctx context.Context, pause *dbModels.PauseDB) error
+
+	DeletePause(ctx context.Context, feature dbModels.Feature) error
+}
+
+type repo struct {
+	db *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repo {
+	return &repo{db: db}
+}
+
+func (r *repo) GetPause(ctx context.Context, feature dbModels.Feature) (*dbModels.PauseDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var pause *dbModels.PauseDB
+	err := db.WithContext(ctx).
+		First(&pause, "feature = ?", feature).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return pause, nil
+}
+
+func (r *repo) GetPauses(ctx context.Context) ([]*dbModels.PauseDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var pauses []*dbModels.PauseDB
+	err := db.WithContext(ctx).
+		Order("feature").
+		Find(&pauses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return pauses, nil
+}
+
+func (r *repo) AddPause(ctx context.Context, pause *dbModels.PauseDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	return db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Select("feature", "reason", "paused_by").
+		Create(pause).Error
+}
+
+func (r *repo) DeletePause(ctx context.Context, feature dbModels.Feature) error {
+	db := database.FromContext(ctx, r.db)
+
+	return db.WithContext(ctx).
+		Delete(&dbModels.PauseDB{}, "feature = ?", feature).Error
+}
@@ -0,0 +1,16 @@
+package service
+
+import "errors"
+
+var (
+	ErrPaused         = errors.New("feature is paused")
+	ErrUnknownFeature = errors.New("unknown feature")
+)
+
+func IsPaused(err error) bool {
+	return errors.Is(err, ErrPaused)
+}
+
+func IsUnknownFeature(err error) bool {
+	return errors.Is(err, ErrUnknownFeature)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/pause/model"
+	"roulette/internal/pause/repo"
+)
+
+type Service interface {
+	// Check returns ErrPaused while the feature is paused
+	Check(ctx context.Context, feature dbModels.Feature) error
+
+	GetPauses(ctx context.Context) ([]*model.Pause, error)
+
+	// Pause stops the feature, pausing a paused feature keeps the first pause
+	Pause(ctx context.Context, feature dbModels.Feature, pausedBy string, reason *string) error
+
+	Resume(ctx context.Context, feature dbModels.Feature) error
+}
+
+type service struct {
+	repo repo.Repo
+}
+
+func NewService(repo repo.Repo) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Check(ctx context.Context, feature dbModels.Feature) error {
+	_, err := s.repo.GetPause(ctx, feature)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+
+	return fmt.Errorf("%s: %w", feature, ErrPaused)
+}
+
+func (s *service) GetPauses(ctx context.Context) ([]*model.Pause, error) {
+	pauses, err := s.repo.GetPauses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.Pause, 0, len(pauses))
+	for _, pause := range pauses {
+		res = append(res, model.NewPause(pause))
+	}
+
+	return res, nil
+}
+
+func (s *service) Pause(ctx context.Context, feature dbModels.Feature, pausedBy string, reason *string) error {
+	if err := checkFeature(feature); err != nil {
+		return err
+	}
+
+	return s.repo.AddPause(ctx, &dbModels.PauseDB{
+		Feature:  feature,
+		Reason:   reason,
+		PausedBy: pausedBy,
+	})
+}
+
+func (s *service) Resume(ctx context.Context, feature dbModels.Feature) error {
+	if err := checkFeature(feature); err != nil {
+		return err
+	}
+
+	return s.repo.DeletePause(ctx, feature)
+}
+
+func checkFeature(feature dbModels.Feature) error {
+	switch feature {
+	case dbModels.FeatureBetting, dbModels.FeatureWithdrawals:
+		return nil
+	}
+	return fmt.Errorf("%s: %w", feature, ErrUnknownFeature)
+}
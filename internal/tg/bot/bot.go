@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"roulette/internal/config"
+)
+
+// StarsCurrency is the currency of Telegram Stars invoices
+const StarsCurrency = "XTR"
+
+// Client is a minimal Bot API client for the Stars payments flow
+type Client interface {
+	GetUpdates(ctx context.Context, offset int, timeout time.Duration) ([]*Update, error)
+
+	CreateInvoiceLink(ctx context.Context, invoice *Invoice) (string, error)
+
+	AnswerPreCheckoutQuery(ctx context.Context, queryID string, ok bool, errorMessage string) error
+
+	RefundStarPayment(ctx context.Context, userID int64, chargeID string) error
+
+	SendMessage(ctx context.Context, chatID int64, text string) error
+}
+
+type client struct {
+	apiUrl string
+	token  string
+	http   *http.Client
+}
+
+// NewClient uses cfg.TgConfig.BotApiUrl, so a fake Bot API server may be used
+func NewClient(cfg *config.Config) Client {
+	return &client{
+		apiUrl: strings.TrimRight(cfg.TgConfig.BotApiUrl, "/"),
+		token:  cfg.TgConfig.BotToken,
+		http:   &http.Client{},
+	}
+}
+
+func (c *client) GetUpdates(ctx context.Context, offset int, timeout time.Duration) ([]*Update, error) {
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message", "pre_checkout_query"},
+	}
+
+	var updates []*Update
+	if err := c.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+func (c *client) CreateInvoiceLink(ctx context.Context, invoice *Invoice) (string, error) {
+	params := map[string]interface{}{
+		"title":       invoice.Title,
+		"description": invoice.Description,
+		"payload":     invoice.Payload,
+		"currency":    StarsCurrency,
+		"prices":      []*LabeledPrice{{Label: invoice.Title, Amount: invoice.Amount}},
+	}
+
+	var link string
+	if err := c.call(ctx, "createInvoiceLink", params, &link); err != nil {
+		return "", err
+	}
+	return link, nil
+}
+
+func (c *client) AnswerPreCheckoutQuery(ctx context.Context, queryID string, ok bool, errorMessage string) error {
+	params := map[string]interface{}{
+		"pre_checkout_query_id": queryID,
+		"ok":                    ok,
+	}
+	if !ok {
+		params["error_message"] = errorMessage
+	}
+	return c.call(ctx, "answerPreCheckoutQuery", params, nil)
+}
+
+func (c *client) RefundStarPayment(ctx context.Context, userID int64, chargeID string) error {
+	params := map[string]interface{}{
+		"user_id":                    userID,
+		"telegram_payment_charge_id": chargeID,
+	}
+	return c.call(ctx, "refundStarPayment", params, nil)
+}
+
+func (c *client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	params := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	return c.call(ctx, "sendMessage", params, nil)
+}
+
+func (c *client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s params: %v", method, err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/%s", c.apiUrl, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %v", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request for %s: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	type Response struct {
+		Ok          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		ErrorCode   int             `json:"error_code"`
+		Description string          `json:"description"`
+	}
+	var res Response
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("failed to decode response for %s: %v", method, err)
+	}
+	if !res.Ok {
+		return &ApiError{Method: method, Code: res.ErrorCode, Description: res.Description}
+	}
+
+	if result != nil {
+		if err = json.Unmarshal(res.Result, result); err != nil {
+			return fmt.Errorf("failed to decode result for %s: %v", method, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrApi = errors.New("bot api error")
+
+type ApiError struct {
+	Method      string
+	Code        int
+	Description string
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("%s: %d %s", e.Method, e.Code, e.Description)
+}
+
+func (e *ApiError) Unwrap() error {
+	return ErrApi
+}
+
+func IsApiError(err error) bool {
+	return errors.Is(err, ErrApi)
+}
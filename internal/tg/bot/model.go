@@ -0,0 +1,52 @@
+package bot
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type SuccessfulPayment struct {
+	Currency                string `json:"currency"`
+	TotalAmount             int    `json:"total_amount"`
+	InvoicePayload          string `json:"invoice_payload"`
+	TelegramPaymentChargeID string `json:"telegram_payment_charge_id"`
+	ProviderPaymentChargeID string `json:"provider_payment_charge_id"`
+}
+
+type Message struct {
+	MessageID         int                `json:"message_id"`
+	From              *User              `json:"from"`
+	Chat              Chat               `json:"chat"`
+	Text              string             `json:"text"`
+	SuccessfulPayment *SuccessfulPayment `json:"successful_payment"`
+}
+
+type PreCheckoutQuery struct {
+	ID             string `json:"id"`
+	From           User   `json:"from"`
+	Currency       string `json:"currency"`
+	TotalAmount    int    `json:"total_amount"`
+	InvoicePayload string `json:"invoice_payload"`
+}
+
+type Update struct {
+	UpdateID         int               `json:"update_id"`
+	Message          *Message          `json:"message"`
+	PreCheckoutQuery *PreCheckoutQuery `json:"pre_checkout_query"`
+}
+
+type LabeledPrice struct {
+	Label  string `json:"label"`
+	Amount int    `json:"amount"`
+}
+
+type Invoice struct {
+	Title       string
+	Description string
+	Payload     string
+	Amount      int
+}
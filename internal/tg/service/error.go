@@ -0,0 +1,11 @@
+package service
+
+import "errors"
+
+var (
+	ErrGiftRejected = errors.New("gift transfer is rejected by telegram")
+)
+
+func IsGiftRejected(err error) bool {
+	return errors.Is(err, ErrGiftRejected)
+}
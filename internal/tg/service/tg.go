@@ -13,6 +13,7 @@ import (
 	"github.com/celestix/gotgproto/sessionMaker"
 	"github.com/glebarez/sqlite"
 	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
 	"github.com/xssnick/tonutils-go/tlb"
 
 	"roulette/internal/tg/model"
@@ -21,13 +22,19 @@ import (
 func (s *service) SendGift(ctx context.Context, userID int64, msgID int) error {
 	client, err := s.GetClient(ctx)
 	if err != nil {
-		return err
+		// nothing is sent without a client
+		return fmt.Errorf("%w: %v", ErrGiftRejected, err)
 	}
 
 	ctxClient := client.CreateContext()
 	gift := &tg.InputSavedStarGiftUser{MsgID: msgID}
 	if _, err = ctxClient.TransferStarGift(userID, gift); err != nil {
-		return fmt.Errorf("failed to transfer gift: %v", err)
+		// only an rpc error answered by telegram says the transfer is not done,
+		// a timeout or a dropped connection may come after the transfer
+		if tgerr.IsCode(err, 400, 403) {
+			return fmt.Errorf("%w: %v", ErrGiftRejected, err)
+		}
+		return fmt.Errorf("failed to transfer gift: %w", err)
 	}
 
 	return nil
@@ -50,54 +57,53 @@ func (s *service) GetClient(ctx context.Context) (*gotgproto.Client, error) {
 	return client, nil
 }
 
+// floorRe matches "<emoji> <Collection Name> –💎<floor>" lines of the floor channel
+var floorRe = regexp.MustCompile(`\S+\s+(\w+\s+\w+)\s*–💎([\d.]+)`)
+
+// floorMessages is how many of the newest channel posts are read for floors
+const floorMessages = 10
+
 func (s *service) GetFloors(ctx context.Context, channelID int64) ([]*model.CollectionFloor, error) {
 	msg, err := s.getChannelMessages(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
-	re := regexp.MustCompile(`\S+\s+(\w+\s+\w+)\s*–💎([\d.]+)`)
-	matches := re.FindAllStringSubmatch(msg, -1)
-
-	var floors []*model.CollectionFloor
-	for _, match := range matches {
-		if len(match) == 3 {
-			floor, errNano := tlb.FromTON(strings.TrimSpace(match[2]))
-			if errNano != nil {
-				return nil, fmt.Errorf("failed to convert to nano: %v", errNano)
-			}
-			collFloor := &model.CollectionFloor{
-				Name:  strings.TrimSpace(match[1]),
-				Floor: floor.Nano(),
-			}
-			floors = append(floors, collFloor)
-		}
-	}
-
-	return floors, nil
+	return parseFloors(msg)
 }
 
 func (s *service) GetFloorsLow(ctx context.Context, channelID, accessHash int64) ([]*model.CollectionFloor, error) {
-	msg, err := s.getChannelMessagesHistory(ctx, channelID, accessHash)
+	msgs, err := s.getChannelMessagesHistory(ctx, channelID, accessHash)
 	if err != nil {
 		return nil, err
 	}
 
-	re := regexp.MustCompile(`\S+\s+(\w+\s+\w+)\s*–💎([\d.]+)`)
-	matches := re.FindAllStringSubmatch(msg, -1)
+	return parseFloors(msgs...)
+}
+
+// parseFloors reads floors from messages newest first, a collection keeps its newest floor
+func parseFloors(msgs ...string) ([]*model.CollectionFloor, error) {
+	seen := make(map[string]bool)
 
 	var floors []*model.CollectionFloor
-	for _, match := range matches {
-		if len(match) == 3 {
+	for _, msg := range msgs {
+		for _, match := range floorRe.FindAllStringSubmatch(msg, -1) {
+			if len(match) != 3 {
+				continue
+			}
+			name := strings.TrimSpace(match[1])
+			if seen[name] {
+				continue
+			}
 			floor, errNano := tlb.FromTON(strings.TrimSpace(match[2]))
 			if errNano != nil {
 				return nil, fmt.Errorf("failed to convert to nano: %v", errNano)
 			}
-			collFloor := &model.CollectionFloor{
-				Name:  strings.TrimSpace(match[1]),
+			seen[name] = true
+			floors = append(floors, &model.CollectionFloor{
+				Name:  name,
 				Floor: floor.Nano(),
-			}
-			floors = append(floors, collFloor)
+			})
 		}
 	}
 
@@ -179,23 +185,38 @@ func (s *service) getChannelMessagesLow(ctx context.Context, channelID int64, ac
 	return msg, nil
 }
 
-func (s *service) getChannelMessagesHistory(ctx context.Context, channelID int64, accessHash int64) (string, error) {
+// getChannelMessagesHistory returns texts of the newest channel posts, newest first
+func (s *service) getChannelMessagesHistory(ctx context.Context, channelID int64, accessHash int64) ([]string, error) {
 	client, err := s.GetClient(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	api := client.API()
 
-	channel := &tg.InputPeerChannel{}
-	req := &tg.MessagesGetDialogsRequest{
-		OffsetPeer: channel,
+	req := &tg.MessagesGetHistoryRequest{
+		Peer: &tg.InputPeerChannel{
+			ChannelID:  channelID,
+			AccessHash: accessHash,
+		},
+		Limit: floorMessages,
 	}
-	res, err := api.MessagesGetDialogs(ctx, req)
+	res, err := api.MessagesGetHistory(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to get channel msg: %v", err)
+		return nil, fmt.Errorf("failed to get channel history: %v", err)
+	}
+
+	history, ok := res.AsModified()
+	if !ok {
+		return nil, fmt.Errorf("failed to get channel history: not modified")
+	}
+
+	var msgs []string
+	for _, msgClass := range history.GetMessages() {
+		if msg, ok := msgClass.(*tg.Message); ok && msg.Message != "" {
+			msgs = append(msgs, msg.Message)
+		}
 	}
-	fmt.Println(res)
 
-	return "", nil
+	return msgs, nil
 }
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Collection struct {
 	ID      uint   `json:"id"`
 	Name    string `json:"name"`
@@ -13,6 +15,7 @@ type Message struct {
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
 	Value       string `json:"value"`
+	CreatedLt   string `json:"created_lt"`
 	MsgContent  struct {
 		Body    string `json:"body"`
 		Decoded struct {
@@ -29,6 +32,24 @@ type NftTransfer struct {
 	NftCollection  string  `json:"nft_collection"`
 	ForwardPayload *string `json:"forward_payload"`
 	TraceID        string  `json:"trace_id"`
+	Lt             string  `json:"transaction_lt"`
+	// Comment is the text comment of the forward payload, nil for other payloads
+	Comment *string `json:"-"`
+}
+
+// NftData is the item state by the get_nft_data get-method, addresses are raw.
+// Owner is empty and Collection is nil when the item has none
+type NftData struct {
+	Initialized bool
+	Owner       string
+	Collection  *string
+}
+
+// NftSale is a marketplace purchase of an item, Price is in nanotons
+type NftSale struct {
+	NftAddress string
+	Price      int64
+	Utime      int64
 }
 
 type NftItem struct {
@@ -37,3 +58,28 @@ type NftItem struct {
 		Uri string `json:"uri"`
 	} `json:"content"`
 }
+
+// Transaction is an account transaction in toncenter format
+type Transaction struct {
+	Hash        string `json:"hash"`
+	Lt          string `json:"lt"`
+	Description struct {
+		Aborted bool `json:"aborted"`
+	} `json:"description"`
+}
+
+// Transfer is one message of a wallet batch, Amount is in nanotons
+type Transfer struct {
+	Destination string
+	Amount      uint
+	Comment     string
+}
+
+// ExternalMessage is a signed wallet message, it can't be processed after ExpireAt.
+// Hash is the base64 message cell hash, QueryID is set for highload wallets only
+type ExternalMessage struct {
+	Hash     string
+	Boc      []byte
+	QueryID  *uint32
+	ExpireAt time.Time
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const retryDelay = 500 * time.Millisecond
+
+// Client is the http client shared by providers, it limits request rate per provider
+// and retries rate limited and server errors
+type Client struct {
+	http    *http.Client
+	retries int
+
+	mu       sync.Mutex
+	limiters map[string]*limiter
+}
+
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewClient creates client with rate limits in requests per second by provider name
+func NewClient(retries int, rateLimits map[string]float64) *Client {
+	c := &Client{
+		http:     &http.Client{Timeout: 30 * time.Second},
+		retries:  retries,
+		limiters: make(map[string]*limiter),
+	}
+	for provider, rps := range rateLimits {
+		c.SetRate(provider, rps)
+	}
+	return c
+}
+
+// SetRate sets requests per second for the provider, zero disables limiting
+func (c *Client) SetRate(provider string, rps float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var interval time.Duration
+	if rps > 0 {
+		interval = time.Duration(float64(time.Second) / rps)
+	}
+	c.limiters[provider] = &limiter{interval: interval}
+}
+
+// GetJSON decodes json response of GET request into result
+func (c *Client) GetJSON(ctx context.Context, provider string, rawUrl string, query url.Values, header http.Header, result interface{}) error {
+	if query != nil {
+		rawUrl += "?" + query.Encode()
+	}
+
+	var err error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay << (attempt - 1)):
+			}
+		}
+
+		var retry bool
+		retry, err = c.get(ctx, provider, rawUrl, header, result)
+		if err == nil || !retry {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (c *Client) get(ctx context.Context, provider string, rawUrl string, header http.Header, result interface{}) (bool, error) {
+	if err := c.wait(ctx, provider); err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request for %s: %v", provider, err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to execute request for %s: %v", provider, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("%s: %w", provider, ErrRateLimited)
+	case resp.StatusCode == http.StatusNotFound:
+		return false, fmt.Errorf("%s: %w", provider, ErrNotFound)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return true, fmt.Errorf("%s response code: %d", provider, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("%s response code: %d", provider, resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return false, fmt.Errorf("failed to decode response for %s: %v", provider, err)
+	}
+
+	return false, nil
+}
+
+func (c *Client) wait(ctx context.Context, provider string) error {
+	c.mu.Lock()
+	l, ok := c.limiters[provider]
+	c.mu.Unlock()
+	if !ok || l.interval == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(at)):
+		return nil
+	}
+}
@@ -0,0 +1,17 @@
+package provider
+
+import "errors"
+
+var (
+	ErrNotSupported = errors.New("not supported by provider")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+func IsNotSupported(err error) bool {
+	return errors.Is(err, ErrNotSupported)
+}
+
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"roulette/internal/models"
+	"roulette/internal/ton/model"
+)
+
+// failover calls secondary provider when primary fails, ErrNotFound is a valid result
+type failover struct {
+	primary   Provider
+	secondary Provider
+}
+
+func NewFailover(primary Provider, secondary Provider) Provider {
+	return &failover{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+func (f *failover) Name() string {
+	return f.primary.Name() + "," + f.secondary.Name()
+}
+
+func (f *failover) GetTonTransfers(ctx context.Context, account string, startLt int64, limit int, offset int) ([]*model.Message, error) {
+	return call(ctx, f, "GetTonTransfers", func(p Provider) ([]*model.Message, error) {
+		return p.GetTonTransfers(ctx, account, startLt, limit, offset)
+	})
+}
+
+func (f *failover) GetOutgoingMessages(ctx context.Context, account string, startLt int64, limit int) ([]*model.Message, error) {
+	return call(ctx, f, "GetOutgoingMessages", func(p Provider) ([]*model.Message, error) {
+		return p.GetOutgoingMessages(ctx, account, startLt, limit)
+	})
+}
+
+func (f *failover) GetNftTransfer(ctx context.Context, owner string, itemAddress string) (*model.NftTransfer, error) {
+	return call(ctx, f, "GetNftTransfer", func(p Provider) (*model.NftTransfer, error) {
+		return p.GetNftTransfer(ctx, owner, itemAddress)
+	})
+}
+
+func (f *failover) GetNftTransfers(ctx context.Context, owner string, startLt int64, limit int) ([]*model.NftTransfer, error) {
+	return call(ctx, f, "GetNftTransfers", func(p Provider) ([]*model.NftTransfer, error) {
+		return p.GetNftTransfers(ctx, owner, startLt, limit)
+	})
+}
+
+func (f *failover) GetNftSales(ctx context.Context, collection string, since time.Time, limit int) ([]*model.NftSale, error) {
+	return call(ctx, f, "GetNftSales", func(p Provider) ([]*model.NftSale, error) {
+		return p.GetNftSales(ctx, collection, since, limit)
+	})
+}
+
+func (f *failover) GetNftItems(ctx context.Context, owner string, collection string) ([]*models.Nft, error) {
+	return call(ctx, f, "GetNftItems", func(p Provider) ([]*models.Nft, error) {
+		return p.GetNftItems(ctx, owner, collection)
+	})
+}
+
+func (f *failover) GetNft(ctx context.Context, address string) (*models.Nft, error) {
+	return call(ctx, f, "GetNft", func(p Provider) (*models.Nft, error) {
+		return p.GetNft(ctx, address)
+	})
+}
+
+func (f *failover) GetMessageTransaction(ctx context.Context, account string, msgHash string) (*model.Transaction, error) {
+	return call(ctx, f, "GetMessageTransaction", func(p Provider) (*model.Transaction, error) {
+		return p.GetMessageTransaction(ctx, account, msgHash)
+	})
+}
+
+func call[T any](ctx context.Context, f *failover, method string, fn func(p Provider) (T, error)) (T, error) {
+	res, err := fn(f.primary)
+	if err == nil || IsNotFound(err) || ctx.Err() != nil {
+		return res, err
+	}
+
+	log.Printf("%s %s failed, fallback to %s: %v", f.primary.Name(), method, f.secondary.Name(), err)
+
+	return fn(f.secondary)
+}
@@ -0,0 +1,612 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/nft"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+
+	"roulette/internal/models"
+	"roulette/internal/ton/model"
+	"roulette/internal/utils"
+)
+
+const (
+	litePageSize    = 32
+	liteHistorySize = 256
+	// liteScanTTL is how long a scan serves the pages that follow it
+	liteScanTTL = 30 * time.Second
+
+	opOwnershipAssigned = 0x05138d91
+)
+
+// Lite reads data directly from liteservers, the connection pool is shared with the wallet
+type Lite struct {
+	client    *Client
+	isTestnet bool
+
+	mu  sync.Mutex
+	api ton.APIClientWrapped
+
+	scansMu sync.Mutex
+	scans   map[string]*liteScan
+}
+
+// liteScan keeps messages found from startLt in ascending order, so the following pages
+// are cut from it instead of scanning the history back again
+type liteScan struct {
+	startLt  int64
+	msgs     []ltMessage
+	expireAt time.Time
+}
+
+type ltMessage struct {
+	lt  uint64
+	msg *model.Message
+}
+
+func NewLite(client *Client, isTestnet bool) *Lite {
+	return &Lite{
+		client:    client,
+		isTestnet: isTestnet,
+		scans:     make(map[string]*liteScan),
+	}
+}
+
+// API connects to liteservers on the first call
+func (l *Lite) API(ctx context.Context) (ton.APIClientWrapped, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.api != nil {
+		return l.api, nil
+	}
+
+	client := liteclient.NewConnectionPool()
+
+	configUrl := "https://ton-blockchain.github.io/global.config.json"
+	if l.isTestnet {
+		configUrl = "https://ton-blockchain.github.io/testnet-global.config.json"
+	}
+
+	if err := client.AddConnectionsFromConfigUrl(ctx, configUrl); err != nil {
+		return nil, fmt.Errorf("failed to connect to lite: %v", err)
+	}
+
+	l.api = ton.NewAPIClient(client, ton.ProofCheckPolicyFast).WithRetry()
+	return l.api, nil
+}
+
+func (l *Lite) Name() string {
+	return LiteName
+}
+
+// GetTonTransfers scans account transactions from the newest one, message lt is always less than its tx lt.
+// The first page scans the history, the next ones are served from that scan
+func (l *Lite) GetTonTransfers(ctx context.Context, account string, startLt int64, limit int, offset int) ([]*model.Message, error) {
+	addr, err := utils.GetAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "in:" + addr.StringRaw()
+	found, ok := l.cached(key, startLt)
+	if !ok || offset == 0 {
+		found, err = l.scanMessages(ctx, addr, startLt, func(tx *tlb.Transaction) []*tlb.InternalMessage {
+			if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+				return nil
+			}
+			return []*tlb.InternalMessage{tx.IO.In.AsInternal()}
+		})
+		if err != nil {
+			return nil, err
+		}
+		l.cache(key, startLt, found)
+	}
+
+	// a cached scan may start before startLt
+	found = slices.DeleteFunc(slices.Clone(found), func(m ltMessage) bool {
+		return m.lt < uint64(startLt)
+	})
+	if offset >= len(found) {
+		return nil, nil
+	}
+
+	var msgs []*model.Message
+	for _, m := range found[offset:min(offset+limit, len(found))] {
+		msgs = append(msgs, m.msg)
+	}
+
+	return msgs, nil
+}
+
+// GetOutgoingMessages is paged by startLt, a page within the last scan is served from it
+func (l *Lite) GetOutgoingMessages(ctx context.Context, account string, startLt int64, limit int) ([]*model.Message, error) {
+	addr, err := utils.GetAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "out:" + addr.StringRaw()
+	found, ok := l.cached(key, startLt)
+	if !ok {
+		found, err = l.scanMessages(ctx, addr, startLt, func(tx *tlb.Transaction) []*tlb.InternalMessage {
+			if tx.IO.Out == nil {
+				return nil
+			}
+			outs, err := tx.IO.Out.ToSlice()
+			if err != nil {
+				return nil
+			}
+
+			var msgs []*tlb.InternalMessage
+			for _, out := range outs {
+				if out.MsgType == tlb.MsgTypeInternal {
+					msgs = append(msgs, out.AsInternal())
+				}
+			}
+			return msgs
+		})
+		if err != nil {
+			return nil, err
+		}
+		l.cache(key, startLt, found)
+	}
+
+	var msgs []*model.Message
+	for _, m := range found {
+		if len(msgs) == limit {
+			break
+		}
+		if m.lt >= uint64(startLt) {
+			msgs = append(msgs, m.msg)
+		}
+	}
+
+	return msgs, nil
+}
+
+// scanMessages collects messages of transactions back until startLt and sorts them by lt,
+// only the last liteHistorySize transactions are scanned for zero startLt
+func (l *Lite) scanMessages(ctx context.Context, addr *address.Address, startLt int64, messages func(tx *tlb.Transaction) []*tlb.InternalMessage) ([]ltMessage, error) {
+	var found []ltMessage
+	count := 0
+	err := l.scan(ctx, addr, func(tx *tlb.Transaction) bool {
+		count++
+		if tx.LT < uint64(startLt) {
+			return false
+		}
+
+		for _, in := range messages(tx) {
+			if in.CreatedLT < uint64(startLt) {
+				continue
+			}
+			if msg, err := l.message(in); err == nil {
+				found = append(found, ltMessage{lt: in.CreatedLT, msg: msg})
+			}
+		}
+		return startLt > 0 || count < liteHistorySize
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(found, func(a, b ltMessage) int {
+		return cmp.Compare(a.lt, b.lt)
+	})
+
+	return found, nil
+}
+
+// cached returns the messages of the last scan by key if it is fresh and covers startLt
+func (l *Lite) cached(key string, startLt int64) ([]ltMessage, bool) {
+	l.scansMu.Lock()
+	defer l.scansMu.Unlock()
+
+	scan, ok := l.scans[key]
+	if !ok || time.Now().After(scan.expireAt) || scan.startLt > startLt {
+		return nil, false
+	}
+	return scan.msgs, true
+}
+
+func (l *Lite) cache(key string, startLt int64, msgs []ltMessage) {
+	l.scansMu.Lock()
+	defer l.scansMu.Unlock()
+
+	l.scans[key] = &liteScan{startLt: startLt, msgs: msgs, expireAt: time.Now().Add(liteScanTTL)}
+}
+
+func (l *Lite) GetNftTransfer(ctx context.Context, owner string, itemAddress string) (*model.NftTransfer, error) {
+	ownerAddr, err := utils.GetAddress(owner)
+	if err != nil {
+		return nil, err
+	}
+	itemAddr, err := utils.GetAddress(itemAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfer *model.NftTransfer
+	count := 0
+	err = l.scan(ctx, ownerAddr, func(tx *tlb.Transaction) bool {
+		count++
+		if t, ok := l.ownershipAssigned(tx); ok && t.NftAddress == itemAddr.StringRaw() {
+			transfer = t
+			return false
+		}
+		return count < liteHistorySize
+	})
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, ErrNotFound
+	}
+
+	return transfer, nil
+}
+
+// GetNftTransfers scans the history back until startLt, only the last liteHistorySize
+// transactions are scanned for zero startLt
+func (l *Lite) GetNftTransfers(ctx context.Context, owner string, startLt int64, limit int) ([]*model.NftTransfer, error) {
+	ownerAddr, err := utils.GetAddress(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []*model.NftTransfer
+	count := 0
+	err = l.scan(ctx, ownerAddr, func(tx *tlb.Transaction) bool {
+		count++
+		if int64(tx.LT) < startLt {
+			return false
+		}
+		if t, ok := l.ownershipAssigned(tx); ok {
+			transfers = append(transfers, t)
+		}
+		return startLt > 0 || count < liteHistorySize
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(transfers)
+	return transfers[:min(limit, len(transfers))], nil
+}
+
+// GetNftSales needs an indexer to find purchases by collection
+func (l *Lite) GetNftSales(ctx context.Context, collection string, since time.Time, limit int) ([]*model.NftSale, error) {
+	return nil, ErrNotSupported
+}
+
+// GetNftItems needs an indexer to find items by owner
+func (l *Lite) GetNftItems(ctx context.Context, owner string, collection string) ([]*models.Nft, error) {
+	return nil, ErrNotSupported
+}
+
+func (l *Lite) GetNft(ctx context.Context, nftAddress string) (*models.Nft, error) {
+	addr, err := utils.GetAddress(nftAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := l.API(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := nft.NewItemClient(api, addr).GetNFTData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nft data: %v", err)
+	}
+	if !data.Initialized {
+		return nil, ErrNotFound
+	}
+
+	content := data.Content
+	if data.CollectionAddress != nil && data.CollectionAddress.Type() == address.StdAddress {
+		content, err = nft.NewCollectionClient(api, data.CollectionAddress).GetNFTContent(ctx, data.Index, data.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nft content: %v", err)
+		}
+	}
+
+	offchain, ok := content.(*nft.ContentOffchain)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	return fetchMetadata(ctx, l.client, nftAddress, offchain.URI)
+}
+
+// GetNftData runs get_nft_data at the current block, an address that is not an nft item is not found
+func (l *Lite) GetNftData(ctx context.Context, nftAddress string) (*model.NftData, error) {
+	addr, err := utils.GetAddress(nftAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := l.API(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := nft.NewItemClient(api, addr).GetNFTData(ctx)
+	if err != nil {
+		var execErr ton.ContractExecError
+		if errors.As(err, &execErr) {
+			return nil, fmt.Errorf("%s: %w", nftAddress, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get nft data: %v", err)
+	}
+
+	res := &model.NftData{Initialized: data.Initialized}
+	if data.OwnerAddress != nil && data.OwnerAddress.Type() == address.StdAddress {
+		res.Owner = data.OwnerAddress.StringRaw()
+	}
+	if data.CollectionAddress != nil && data.CollectionAddress.Type() == address.StdAddress {
+		collection := data.CollectionAddress.StringRaw()
+		res.Collection = &collection
+	}
+
+	return res, nil
+}
+
+// GetPublicKey runs get_public_key of the wallet at the current block, a wallet that is not deployed
+// or has no such get-method is not found
+func (l *Lite) GetPublicKey(ctx context.Context, walletAddress string) (ed25519.PublicKey, error) {
+	addr, err := utils.GetAddress(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := l.API(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := wallet.GetPublicKey(ctx, api, addr)
+	if err != nil {
+		var execErr ton.ContractExecError
+		if errors.As(err, &execErr) {
+			return nil, fmt.Errorf("%s: %w", walletAddress, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get public key: %v", err)
+	}
+
+	return key, nil
+}
+
+// GetLastLt returns lt of the newest account transaction, zero for an account that is not active
+func (l *Lite) GetLastLt(ctx context.Context, account string) (int64, error) {
+	addr, err := utils.GetAddress(account)
+	if err != nil {
+		return 0, err
+	}
+
+	api, err := l.API(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get masterchain info: %v", err)
+	}
+
+	acc, err := api.GetAccount(ctx, block, addr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get account: %v", err)
+	}
+	if !acc.IsActive {
+		return 0, nil
+	}
+
+	return int64(acc.LastTxLT), nil
+}
+
+func (l *Lite) GetMessageTransaction(ctx context.Context, account string, msgHash string) (*model.Transaction, error) {
+	addr, err := utils.GetAddress(account)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *model.Transaction
+	count := 0
+	err = l.scan(ctx, addr, func(tx *tlb.Transaction) bool {
+		count++
+		if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeExternalIn {
+			return count < liteHistorySize
+		}
+
+		c, err := tlb.ToCell(tx.IO.In.AsExternalIn())
+		if err != nil || base64.StdEncoding.EncodeToString(c.Hash()) != msgHash {
+			return count < liteHistorySize
+		}
+
+		found = &model.Transaction{
+			Hash: base64.StdEncoding.EncodeToString(tx.Hash),
+			Lt:   strconv.FormatUint(tx.LT, 10),
+		}
+		switch d := tx.Description.(type) {
+		case tlb.TransactionDescriptionOrdinary:
+			found.Description.Aborted = d.Aborted
+		case *tlb.TransactionDescriptionOrdinary:
+			found.Description.Aborted = d.Aborted
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+
+	return found, nil
+}
+
+// scan visits account transactions from the newest one until visit returns false
+func (l *Lite) scan(ctx context.Context, addr *address.Address, visit func(tx *tlb.Transaction) bool) error {
+	api, err := l.API(ctx)
+	if err != nil {
+		return err
+	}
+
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get masterchain info: %v", err)
+	}
+
+	account, err := api.GetAccount(ctx, block, addr)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %v", err)
+	}
+	if !account.IsActive {
+		return nil
+	}
+
+	lt, hash := account.LastTxLT, account.LastTxHash
+	for lt != 0 {
+		txs, err := api.ListTransactions(ctx, addr, litePageSize, lt, hash)
+		if err != nil {
+			if errors.Is(err, ton.ErrNoTransactionsWereFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to list transactions: %v", err)
+		}
+
+		for i := len(txs) - 1; i >= 0; i-- {
+			if !visit(txs[i]) {
+				return nil
+			}
+		}
+
+		lt, hash = txs[0].PrevTxLT, txs[0].PrevTxHash
+	}
+
+	return nil
+}
+
+// message converts internal message to toncenter format, hash is the message cell hash
+func (l *Lite) message(in *tlb.InternalMessage) (*model.Message, error) {
+	c, err := tlb.ToCell(in)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &model.Message{
+		Hash:        base64.StdEncoding.EncodeToString(c.Hash()),
+		Source:      in.SrcAddr.StringRaw(),
+		Destination: in.DstAddr.StringRaw(),
+		Value:       in.Amount.Nano().String(),
+		CreatedLt:   strconv.FormatUint(in.CreatedLT, 10),
+	}
+
+	if in.Body != nil {
+		body := in.Body.BeginParse()
+		if op, err := body.LoadUInt(32); err == nil && op == 0 {
+			if comment, err := body.LoadStringSnake(); err == nil {
+				msg.MsgContent.Decoded.Type = "text_comment"
+				msg.MsgContent.Decoded.Comment = &comment
+			}
+		}
+	}
+
+	return msg, nil
+}
+
+// ownershipAssigned parses nft ownership_assigned notification
+func (l *Lite) ownershipAssigned(tx *tlb.Transaction) (*model.NftTransfer, bool) {
+	if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+		return nil, false
+	}
+	in := tx.IO.In.AsInternal()
+	if in.Body == nil {
+		return nil, false
+	}
+
+	body := in.Body.BeginParse()
+	if op, err := body.LoadUInt(32); err != nil || op != opOwnershipAssigned {
+		return nil, false
+	}
+	if _, err := body.LoadUInt(64); err != nil {
+		return nil, false
+	}
+	prevOwner, err := body.LoadAddr()
+	if err != nil {
+		return nil, false
+	}
+
+	transfer := &model.NftTransfer{
+		Sender:     prevOwner.StringRaw(),
+		NftAddress: in.SrcAddr.StringRaw(),
+		TraceID:    hex.EncodeToString(tx.Hash),
+		Lt:         strconv.FormatUint(tx.LT, 10),
+	}
+
+	var payload *cell.Cell
+	if isRef, err := body.LoadBoolBit(); err == nil {
+		if isRef {
+			if ref, err := body.LoadRef(); err == nil {
+				payload, _ = ref.ToCell()
+			}
+		} else {
+			payload, _ = body.ToCell()
+		}
+	}
+	if payload != nil && (payload.BitsSize() > 0 || payload.RefsNum() > 0) {
+		boc := base64.StdEncoding.EncodeToString(payload.ToBOC())
+		transfer.ForwardPayload = &boc
+		transfer.Comment = textComment(payload)
+	}
+
+	return transfer, true
+}
+
+// textComment decodes the text comment body, nil for other bodies
+func textComment(body *cell.Cell) *string {
+	s := body.BeginParse()
+	if op, err := s.LoadUInt(32); err != nil || op != 0 {
+		return nil
+	}
+	comment, err := s.LoadStringSnake()
+	if err != nil {
+		return nil
+	}
+	return &comment
+}
+
+// bocComment decodes the text comment of a base64 or hex encoded boc, nil for other payloads
+func bocComment(boc *string) *string {
+	if boc == nil {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(*boc)
+	if err != nil {
+		if b, err = hex.DecodeString(*boc); err != nil {
+			return nil
+		}
+	}
+	body, err := cell.FromBOC(b)
+	if err != nil {
+		return nil
+	}
+	return textComment(body)
+}
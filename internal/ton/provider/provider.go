@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"roulette/internal/config"
+	"roulette/internal/models"
+	"roulette/internal/ton/model"
+)
+
+const (
+	ToncenterName = "toncenter"
+	TonapiName    = "tonapi"
+	LiteName      = "lite"
+
+	// metadataName limits requests to nft metadata hosts
+	metadataName = "metadata"
+)
+
+// Provider reads blockchain data
+type Provider interface {
+	Name() string
+
+	// GetTonTransfers returns incoming messages of account with lt >= startLt in ascending order
+	GetTonTransfers(ctx context.Context, account string, startLt int64, limit int, offset int) ([]*model.Message, error)
+
+	// GetOutgoingMessages returns internal messages sent by account with lt >= startLt in ascending order
+	GetOutgoingMessages(ctx context.Context, account string, startLt int64, limit int) ([]*model.Message, error)
+
+	// GetNftTransfer returns the last transfer of the item to owner, ErrNotFound if there is none
+	GetNftTransfer(ctx context.Context, owner string, itemAddress string) (*model.NftTransfer, error)
+
+	// GetNftTransfers returns incoming nft transfers of owner with lt >= startLt in ascending order
+	GetNftTransfers(ctx context.Context, owner string, startLt int64, limit int) ([]*model.NftTransfer, error)
+
+	// GetNftSales returns marketplace purchases of the collection items since the time, newest first
+	GetNftSales(ctx context.Context, collection string, since time.Time, limit int) ([]*model.NftSale, error)
+
+	// GetNftItems returns owner's items of the collection with metadata
+	GetNftItems(ctx context.Context, owner string, collection string) ([]*models.Nft, error)
+
+	// GetNft returns item with metadata, Address is kept as requested
+	GetNft(ctx context.Context, address string) (*models.Nft, error)
+
+	// GetMessageTransaction returns the account transaction of the inbound message, ErrNotFound if there is none yet
+	GetMessageTransaction(ctx context.Context, account string, msgHash string) (*model.Transaction, error)
+}
+
+// NewProvider creates the primary provider wrapped with the failover one if it is configured
+func NewProvider(cfg *config.Config, client *Client, lite *Lite) (Provider, error) {
+	primary, err := newProvider(cfg, cfg.TonConfig.Provider, client, lite)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TonConfig.FailoverProvider == "" {
+		return primary, nil
+	}
+
+	secondary, err := newProvider(cfg, cfg.TonConfig.FailoverProvider, client, lite)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFailover(primary, secondary), nil
+}
+
+func newProvider(cfg *config.Config, name string, client *Client, lite *Lite) (Provider, error) {
+	tonCfg := cfg.TonConfig
+
+	switch name {
+	case ToncenterName:
+		apiKey := tonCfg.TonCenterApiKey
+		if tonCfg.IsTestnet {
+			apiKey = tonCfg.TonCenterApiKeyTestnet
+		}
+		return NewToncenter(client, tonCfg.IsTestnet, apiKey), nil
+	case TonapiName:
+		return NewTonapi(client, tonCfg.IsTestnet, tonCfg.TonApiKey), nil
+	case LiteName:
+		return lite, nil
+	default:
+		return nil, fmt.Errorf("unknown ton provider: %s", name)
+	}
+}
+
+// parseNftName splits "Name #123" into name and collectible id
+func parseNftName(fullName string) (string, uint64) {
+	name, num, ok := strings.Cut(fullName, "#")
+	if !ok {
+		return strings.TrimSpace(fullName), 0
+	}
+	collectibleID, _ := strconv.ParseUint(strings.TrimSpace(num), 10, 64)
+	return strings.TrimSpace(name), collectibleID
+}
+
+// fetchMetadata loads offchain nft metadata
+func fetchMetadata(ctx context.Context, client *Client, address string, uri string) (*models.Nft, error) {
+	type Response struct {
+		Name       string                      `json:"name"`
+		Lottie     string                      `json:"lottie"`
+		Attributes []*models.MetadataAttribute `json:"attributes"`
+	}
+	var result Response
+	if err := client.GetJSON(ctx, metadataName, uri, nil, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to fetch meta %s: %w", uri, err)
+	}
+
+	name, collectibleID := parseNftName(result.Name)
+	nft := &models.Nft{
+		Name:          name,
+		CollectibleID: collectibleID,
+		Address:       address,
+		LottieUrl:     result.Lottie,
+		Attributes:    models.NewAttributes(result.Attributes),
+	}
+
+	return nft, nil
+}
@@ -0,0 +1,396 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"roulette/internal/models"
+	"roulette/internal/ton/model"
+	"roulette/internal/utils"
+)
+
+const (
+	tonapiPageSize    = 100
+	tonapiHistorySize = 20
+)
+
+type tonapi struct {
+	client  *Client
+	baseUrl string
+	apiKey  string
+}
+
+type tonapiAccount struct {
+	Address string `json:"address"`
+}
+
+type tonapiNftItem struct {
+	Address  string `json:"address"`
+	Metadata struct {
+		Name       string                      `json:"name"`
+		Lottie     string                      `json:"lottie"`
+		Attributes []*models.MetadataAttribute `json:"attributes"`
+	} `json:"metadata"`
+}
+
+type tonapiEvent struct {
+	EventID   string `json:"event_id"`
+	Timestamp int64  `json:"timestamp"`
+	Lt        int64  `json:"lt"`
+	Actions   []struct {
+		Type            string `json:"type"`
+		NftItemTransfer *struct {
+			Sender    *tonapiAccount `json:"sender"`
+			Recipient *tonapiAccount `json:"recipient"`
+			Nft       string         `json:"nft"`
+			Comment   *string        `json:"comment"`
+			Payload   *string        `json:"payload"`
+		} `json:"NftItemTransfer"`
+	} `json:"actions"`
+}
+
+func NewTonapi(client *Client, isTestnet bool, apiKey string) Provider {
+	baseUrl := "https://tonapi.io/v2"
+	if isTestnet {
+		baseUrl = "https://testnet.tonapi.io/v2"
+	}
+
+	return &tonapi{
+		client:  client,
+		baseUrl: baseUrl,
+		apiKey:  apiKey,
+	}
+}
+
+func (p *tonapi) Name() string {
+	return TonapiName
+}
+
+// GetTonTransfers pages account transactions by lt, tonapi has no offset so it is applied locally
+func (p *tonapi) GetTonTransfers(ctx context.Context, account string, startLt int64, limit int, offset int) ([]*model.Message, error) {
+	type Transaction struct {
+		Lt    int64 `json:"lt"`
+		InMsg *struct {
+			MsgType       string         `json:"msg_type"`
+			Hash          string         `json:"hash"`
+			CreatedLt     int64          `json:"created_lt"`
+			Value         int64          `json:"value"`
+			Source        *tonapiAccount `json:"source"`
+			Destination   *tonapiAccount `json:"destination"`
+			DecodedOpName string         `json:"decoded_op_name"`
+			DecodedBody   struct {
+				Text *string `json:"text"`
+			} `json:"decoded_body"`
+		} `json:"in_msg"`
+	}
+	type Response struct {
+		Transactions []*Transaction `json:"transactions"`
+	}
+
+	var msgs []*model.Message
+	afterLt := max(startLt-1, 0)
+	for len(msgs) < offset+limit {
+		q := url.Values{}
+		q.Add("after_lt", strconv.FormatInt(afterLt, 10))
+		q.Add("limit", strconv.Itoa(tonapiPageSize))
+		q.Add("sort_order", "asc")
+
+		var result Response
+		if err := p.get(ctx, "/blockchain/accounts/"+account+"/transactions", q, &result); err != nil {
+			return nil, err
+		}
+
+		for _, tx := range result.Transactions {
+			afterLt = tx.Lt
+
+			in := tx.InMsg
+			if in == nil || in.MsgType != "int_msg" || in.Source == nil || in.CreatedLt < startLt {
+				continue
+			}
+
+			msg := &model.Message{
+				Hash:      hexToBase64(in.Hash),
+				Source:    in.Source.Address,
+				Value:     strconv.FormatInt(in.Value, 10),
+				CreatedLt: strconv.FormatInt(in.CreatedLt, 10),
+			}
+			if in.Destination != nil {
+				msg.Destination = in.Destination.Address
+			}
+			if in.DecodedOpName == "text_comment" {
+				msg.MsgContent.Decoded.Type = "text_comment"
+				msg.MsgContent.Decoded.Comment = in.DecodedBody.Text
+			}
+			msgs = append(msgs, msg)
+		}
+
+		if len(result.Transactions) < tonapiPageSize {
+			break
+		}
+	}
+
+	if offset >= len(msgs) {
+		return nil, nil
+	}
+	return msgs[offset:min(offset+limit, len(msgs))], nil
+}
+
+// GetOutgoingMessages pages account transactions by lt and collects their out messages
+func (p *tonapi) GetOutgoingMessages(ctx context.Context, account string, startLt int64, limit int) ([]*model.Message, error) {
+	type Transaction struct {
+		Lt      int64 `json:"lt"`
+		OutMsgs []struct {
+			MsgType       string         `json:"msg_type"`
+			Hash          string         `json:"hash"`
+			CreatedLt     int64          `json:"created_lt"`
+			Value         int64          `json:"value"`
+			Destination   *tonapiAccount `json:"destination"`
+			DecodedOpName string         `json:"decoded_op_name"`
+			DecodedBody   struct {
+				Text *string `json:"text"`
+			} `json:"decoded_body"`
+		} `json:"out_msgs"`
+	}
+	type Response struct {
+		Transactions []*Transaction `json:"transactions"`
+	}
+
+	var msgs []*model.Message
+	afterLt := max(startLt-1, 0)
+	for len(msgs) < limit {
+		q := url.Values{}
+		q.Add("after_lt", strconv.FormatInt(afterLt, 10))
+		q.Add("limit", strconv.Itoa(tonapiPageSize))
+		q.Add("sort_order", "asc")
+
+		var result Response
+		if err := p.get(ctx, "/blockchain/accounts/"+account+"/transactions", q, &result); err != nil {
+			return nil, err
+		}
+
+		for _, tx := range result.Transactions {
+			afterLt = tx.Lt
+
+			for _, out := range tx.OutMsgs {
+				if out.MsgType != "int_msg" || out.Destination == nil || out.CreatedLt < startLt {
+					continue
+				}
+
+				msg := &model.Message{
+					Hash:        hexToBase64(out.Hash),
+					Source:      account,
+					Destination: out.Destination.Address,
+					Value:       strconv.FormatInt(out.Value, 10),
+					CreatedLt:   strconv.FormatInt(out.CreatedLt, 10),
+				}
+				if out.DecodedOpName == "text_comment" {
+					msg.MsgContent.Decoded.Type = "text_comment"
+					msg.MsgContent.Decoded.Comment = out.DecodedBody.Text
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+
+		if len(result.Transactions) < tonapiPageSize {
+			break
+		}
+	}
+
+	return msgs[:min(limit, len(msgs))], nil
+}
+
+func (p *tonapi) GetNftTransfer(ctx context.Context, owner string, itemAddress string) (*model.NftTransfer, error) {
+	q := url.Values{}
+	q.Add("limit", strconv.Itoa(tonapiHistorySize))
+
+	type Response struct {
+		Events []*tonapiEvent `json:"events"`
+	}
+	var result Response
+	if err := p.get(ctx, "/nfts/"+itemAddress+"/history", q, &result); err != nil {
+		return nil, err
+	}
+
+	transfers := p.transfers(result.Events, owner)
+	if len(transfers) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return transfers[0], nil
+}
+
+// GetNftTransfers pages the history back by lt until startLt, the history is served newest first.
+// Only the last page is read for zero startLt
+func (p *tonapi) GetNftTransfers(ctx context.Context, owner string, startLt int64, limit int) ([]*model.NftTransfer, error) {
+	type Response struct {
+		Events []*tonapiEvent `json:"events"`
+	}
+
+	var events []*tonapiEvent
+	var beforeLt int64
+	for {
+		q := url.Values{}
+		q.Add("limit", strconv.Itoa(tonapiPageSize))
+		if beforeLt > 0 {
+			q.Add("before_lt", strconv.FormatInt(beforeLt, 10))
+		}
+
+		var result Response
+		if err := p.get(ctx, "/accounts/"+owner+"/nfts/history", q, &result); err != nil {
+			return nil, err
+		}
+
+		done := len(result.Events) < tonapiPageSize || startLt == 0
+		for _, event := range result.Events {
+			if event.Lt < startLt {
+				done = true
+				break
+			}
+			events = append(events, event)
+			beforeLt = event.Lt
+		}
+		if done {
+			break
+		}
+	}
+
+	slices.Reverse(events)
+	transfers := p.transfers(events, owner)
+
+	return transfers[:min(limit, len(transfers))], nil
+}
+
+// GetNftSales is not served by tonapi per collection
+func (p *tonapi) GetNftSales(ctx context.Context, collection string, since time.Time, limit int) ([]*model.NftSale, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *tonapi) GetNftItems(ctx context.Context, owner string, collection string) ([]*models.Nft, error) {
+	q := url.Values{}
+	q.Add("collection", collection)
+	q.Add("limit", strconv.Itoa(nftItemsLimit))
+	q.Add("indirect_ownership", "false")
+
+	type Response struct {
+		NftItems []*tonapiNftItem `json:"nft_items"`
+	}
+	var result Response
+	if err := p.get(ctx, "/accounts/"+owner+"/nfts", q, &result); err != nil {
+		return nil, err
+	}
+
+	nfts := make([]*models.Nft, 0, len(result.NftItems))
+	for _, item := range result.NftItems {
+		nfts = append(nfts, p.nft(item))
+	}
+
+	return nfts, nil
+}
+
+func (p *tonapi) GetNft(ctx context.Context, address string) (*models.Nft, error) {
+	var item tonapiNftItem
+	if err := p.get(ctx, "/nfts/"+address, nil, &item); err != nil {
+		return nil, err
+	}
+
+	nft := p.nft(&item)
+	nft.Address = address
+
+	return nft, nil
+}
+
+// transfers returns incoming nft transfers of owner, newest first
+func (p *tonapi) GetMessageTransaction(ctx context.Context, account string, msgHash string) (*model.Transaction, error) {
+	type Response struct {
+		Hash    string `json:"hash"`
+		Lt      int64  `json:"lt"`
+		Aborted bool   `json:"aborted"`
+	}
+	var result Response
+	if err := p.get(ctx, "/blockchain/messages/"+base64ToHex(msgHash)+"/transaction", nil, &result); err != nil {
+		return nil, err
+	}
+
+	tx := &model.Transaction{
+		Hash: hexToBase64(result.Hash),
+		Lt:   strconv.FormatInt(result.Lt, 10),
+	}
+	tx.Description.Aborted = result.Aborted
+
+	return tx, nil
+}
+
+func (p *tonapi) transfers(events []*tonapiEvent, owner string) []*model.NftTransfer {
+	ownerAddr, err := utils.GetAddress(owner)
+	if err != nil {
+		return nil
+	}
+
+	var transfers []*model.NftTransfer
+	for _, event := range events {
+		for _, action := range event.Actions {
+			t := action.NftItemTransfer
+			if action.Type != "NftItemTransfer" || t == nil || t.Sender == nil || t.Recipient == nil {
+				continue
+			}
+
+			recipient, err := utils.GetAddress(t.Recipient.Address)
+			if err != nil || recipient.StringRaw() != ownerAddr.StringRaw() {
+				continue
+			}
+
+			transfers = append(transfers, &model.NftTransfer{
+				Sender:         t.Sender.Address,
+				NftAddress:     t.Nft,
+				ForwardPayload: t.Payload,
+				TraceID:        event.EventID,
+				Lt:             strconv.FormatInt(event.Lt, 10),
+				Comment:        t.Comment,
+			})
+		}
+	}
+
+	return transfers
+}
+
+func (p *tonapi) nft(item *tonapiNftItem) *models.Nft {
+	name, collectibleID := parseNftName(item.Metadata.Name)
+	return &models.Nft{
+		Name:          name,
+		CollectibleID: collectibleID,
+		Address:       item.Address,
+		LottieUrl:     item.Metadata.Lottie,
+		Attributes:    models.NewAttributes(item.Metadata.Attributes),
+	}
+}
+
+func (p *tonapi) get(ctx context.Context, path string, q url.Values, result interface{}) error {
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	return p.client.GetJSON(ctx, TonapiName, p.baseUrl+path, q, header, result)
+}
+
+// hexToBase64 converts tonapi hashes to toncenter format, so msg hashes match across providers
+func hexToBase64(hash string) string {
+	b, err := hex.DecodeString(hash)
+	if err != nil {
+		return hash
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func base64ToHex(hash string) string {
+	b, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		return hash
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"roulette/internal/models"
+	"roulette/internal/ton/model"
+)
+
+const nftItemsLimit = 1000
+
+type toncenter struct {
+	client  *Client
+	baseUrl string
+	apiKey  string
+}
+
+func NewToncenter(client *Client, isTestnet bool, apiKey string) Provider {
+	baseUrl := "https://toncenter.com/api/v3"
+	if isTestnet {
+		baseUrl = "https://testnet.toncenter.com/api/v3"
+	}
+
+	return &toncenter{
+		client:  client,
+		baseUrl: baseUrl,
+		apiKey:  apiKey,
+	}
+}
+
+func (p *toncenter) Name() string {
+	return ToncenterName
+}
+
+func (p *toncenter) GetTonTransfers(ctx context.Context, account string, startLt int64, limit int, offset int) ([]*model.Message, error) {
+	q := url.Values{}
+	q.Add("destination", account)
+	q.Add("exclude_externals", "true")
+	q.Add("start_lt", strconv.FormatInt(startLt, 10))
+	q.Add("sort", "asc")
+	q.Add("limit", strconv.Itoa(limit))
+	q.Add("offset", strconv.Itoa(offset))
+
+	type Response struct {
+		Messages []*model.Message `json:"messages"`
+	}
+	var result Response
+	if err := p.get(ctx, "/messages", q, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Messages, nil
+}
+
+func (p *toncenter) GetOutgoingMessages(ctx context.Context, account string, startLt int64, limit int) ([]*model.Message, error) {
+	q := url.Values{}
+	q.Add("source", account)
+	q.Add("exclude_externals", "true")
+	q.Add("start_lt", strconv.FormatInt(startLt, 10))
+	q.Add("sort", "asc")
+	q.Add("limit", strconv.Itoa(limit))
+
+	type Response struct {
+		Messages []*model.Message `json:"messages"`
+	}
+	var result Response
+	if err := p.get(ctx, "/messages", q, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Messages, nil
+}
+
+func (p *toncenter) GetNftTransfer(ctx context.Context, owner string, itemAddress string) (*model.NftTransfer, error) {
+	q := url.Values{}
+	q.Add("owner_address", owner)
+	q.Add("direction", "in")
+	q.Add("item_address", itemAddress)
+	q.Add("sort", "desc")
+	q.Add("limit", "1")
+
+	type Response struct {
+		NftTransfers []*model.NftTransfer `json:"nft_transfers"`
+	}
+	var result Response
+	if err := p.get(ctx, "/nft/transfers", q, &result); err != nil {
+		return nil, err
+	}
+	if len(result.NftTransfers) == 0 {
+		return nil, ErrNotFound
+	}
+
+	transfer := result.NftTransfers[0]
+	transfer.Comment = bocComment(transfer.ForwardPayload)
+
+	return transfer, nil
+}
+
+func (p *toncenter) GetNftTransfers(ctx context.Context, owner string, startLt int64, limit int) ([]*model.NftTransfer, error) {
+	q := url.Values{}
+	q.Add("owner_address", owner)
+	q.Add("direction", "in")
+	q.Add("start_lt", strconv.FormatInt(startLt, 10))
+	q.Add("sort", "asc")
+	q.Add("limit", strconv.Itoa(limit))
+
+	type Response struct {
+		NftTransfers []*model.NftTransfer `json:"nft_transfers"`
+	}
+	var result Response
+	if err := p.get(ctx, "/nft/transfers", q, &result); err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range result.NftTransfers {
+		transfer.Comment = bocComment(transfer.ForwardPayload)
+	}
+
+	return result.NftTransfers, nil
+}
+
+// GetNftSales finds purchases in the traces of the collection transfers
+func (p *toncenter) GetNftSales(ctx context.Context, collection string, since time.Time, limit int) ([]*model.NftSale, error) {
+	q := url.Values{}
+	q.Add("collection_address", collection)
+	q.Add("start_utime", strconv.FormatInt(since.Unix(), 10))
+	q.Add("sort", "desc")
+	q.Add("limit", strconv.Itoa(limit))
+
+	type TransfersResponse struct {
+		NftTransfers []*model.NftTransfer `json:"nft_transfers"`
+	}
+	var transfers TransfersResponse
+	if err := p.get(ctx, "/nft/transfers", q, &transfers); err != nil {
+		return nil, err
+	}
+	if len(transfers.NftTransfers) == 0 {
+		return nil, nil
+	}
+
+	q = url.Values{}
+	for _, transfer := range transfers.NftTransfers {
+		if transfer.TraceID != "" {
+			q.Add("trace_id", transfer.TraceID)
+		}
+	}
+	q.Add("action_type", "nft_transfer")
+	q.Add("limit", strconv.Itoa(limit))
+
+	type ActionsResponse struct {
+		Actions []struct {
+			EndUtime int64 `json:"end_utime"`
+			Details  struct {
+				NftItem    string  `json:"nft_item"`
+				IsPurchase bool    `json:"is_purchase"`
+				Price      *string `json:"price"`
+			} `json:"details"`
+		} `json:"actions"`
+	}
+	var actions ActionsResponse
+	if err := p.get(ctx, "/actions", q, &actions); err != nil {
+		return nil, err
+	}
+
+	var sales []*model.NftSale
+	for _, action := range actions.Actions {
+		if !action.Details.IsPurchase || action.Details.Price == nil {
+			continue
+		}
+		price, err := strconv.ParseInt(*action.Details.Price, 10, 64)
+		if err != nil || price <= 0 {
+			continue
+		}
+		sales = append(sales, &model.NftSale{
+			NftAddress: action.Details.NftItem,
+			Price:      price,
+			Utime:      action.EndUtime,
+		})
+	}
+
+	return sales, nil
+}
+
+func (p *toncenter) GetNftItems(ctx context.Context, owner string, collection string) ([]*models.Nft, error) {
+	q := url.Values{}
+	q.Add("owner_address", owner)
+	q.Add("collection_address", collection)
+	q.Add("limit", strconv.Itoa(nftItemsLimit))
+
+	type Response struct {
+		NftItems []*model.NftItem `json:"nft_items"`
+	}
+	var result Response
+	if err := p.get(ctx, "/nft/items", q, &result); err != nil {
+		return nil, err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		nftsCh = make(chan *models.Nft, len(result.NftItems))
+	)
+	for _, item := range result.NftItems {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			nft, err := fetchMetadata(ctx, p.client, item.Address, item.Content.Uri)
+			if err != nil {
+				return
+			}
+			nftsCh <- nft
+		}()
+	}
+	wg.Wait()
+	close(nftsCh)
+
+	var nfts []*models.Nft
+	for nft := range nftsCh {
+		nfts = append(nfts, nft)
+	}
+
+	return nfts, nil
+}
+
+func (p *toncenter) GetNft(ctx context.Context, address string) (*models.Nft, error) {
+	q := url.Values{}
+	q.Add("address", address)
+	q.Add("limit", "1")
+
+	type Response struct {
+		NftItems []*model.NftItem `json:"nft_items"`
+	}
+	var result Response
+	if err := p.get(ctx, "/nft/items", q, &result); err != nil {
+		return nil, err
+	}
+	if len(result.NftItems) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return fetchMetadata(ctx, p.client, address, result.NftItems[0].Content.Uri)
+}
+
+func (p *toncenter) GetMessageTransaction(ctx context.Context, account string, msgHash string) (*model.Transaction, error) {
+	q := url.Values{}
+	q.Add("msg_hash", msgHash)
+	q.Add("direction", "in")
+
+	type Response struct {
+		Transactions []*model.Transaction `json:"transactions"`
+	}
+	var result Response
+	if err := p.get(ctx, "/transactionsByMessage", q, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Transactions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return result.Transactions[0], nil
+}
+
+func (p *toncenter) get(ctx context.Context, path string, q url.Values, result interface{}) error {
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("X-Api-Key", p.apiKey)
+	}
+	return p.client.GetJSON(ctx, ToncenterName, p.baseUrl+path, q, header, result)
+}
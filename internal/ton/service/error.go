@@ -3,9 +3,24 @@ package service
 import "errors"
 
 var (
-	ErrNftsNotFound = errors.New("nfts not found")
+	ErrNftsNotFound        = errors.New("nfts not found")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrNftNotFound         = errors.New("address is not an nft item")
+	ErrWalletKeyNotFound   = errors.New("wallet public key not found")
 )
 
 func IsNftsNotFound(err error) bool {
 	return errors.Is(err, ErrNftsNotFound)
 }
+
+func IsTransactionNotFound(err error) bool {
+	return errors.Is(err, ErrTransactionNotFound)
+}
+
+func IsNftNotFound(err error) bool {
+	return errors.Is(err, ErrNftNotFound)
+}
+
+func IsWalletKeyNotFound(err error) bool {
+	return errors.Is(err, ErrWalletKeyNotFound)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	giftModel "roulette/internal/gift/model"
+	"roulette/internal/models"
+	"roulette/internal/ton/model"
+	"roulette/internal/ton/provider"
+	"roulette/internal/utils"
+)
+
+func (s *service) GetTonTransfers(ctx context.Context, startLt int64, limit int, offset int) ([]*model.Message, error) {
+	return s.provider.GetTonTransfers(ctx, s.AdminWallet, startLt, limit, offset)
+}
+
+func (s *service) GetNftTransfer(ctx context.Context, itemAddress string) (*model.NftTransfer, error) {
+	return s.provider.GetNftTransfer(ctx, s.AdminWallet, itemAddress)
+}
+
+func (s *service) GetNftTransfers(ctx context.Context, startLt int64, limit int) ([]*model.NftTransfer, error) {
+	return s.provider.GetNftTransfers(ctx, s.AdminWallet, startLt, limit)
+}
+
+func (s *service) GetNftSales(ctx context.Context, collection string, since time.Time, limit int) ([]*model.NftSale, error) {
+	return s.provider.GetNftSales(ctx, collection, since, limit)
+}
+
+func (s *service) GetNftData(ctx context.Context, address string) (*model.NftData, error) {
+	data, err := s.lite.GetNftData(ctx, address)
+	if err != nil {
+		if provider.IsNotFound(err) {
+			return nil, ErrNftNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *service) GetWalletPublicKey(ctx context.Context, address string, stateInit *string) (ed25519.PublicKey, error) {
+	key, err := s.lite.GetPublicKey(ctx, address)
+	if err == nil {
+		return key, nil
+	}
+	if !provider.IsNotFound(err) {
+		return nil, err
+	}
+	if stateInit == nil {
+		return nil, ErrWalletKeyNotFound
+	}
+
+	return stateInitPublicKey(address, *stateInit)
+}
+
+func (s *service) GetLastLt(ctx context.Context) (int64, error) {
+	return s.lite.GetLastLt(ctx, s.AdminWallet)
+}
+
+func (s *service) IsAdminWallet(address string) bool {
+	addr, err := utils.GetAddress(address)
+	if err != nil {
+		return false
+	}
+	admin, err := utils.GetAddress(s.AdminWallet)
+	if err != nil {
+		return false
+	}
+	return addr.StringRaw() == admin.StringRaw()
+}
+
+func (s *service) GetOutgoingMessages(ctx context.Context, startLt int64, limit int) ([]*model.Message, error) {
+	return s.provider.GetOutgoingMessages(ctx, s.AdminWallet, startLt, limit)
+}
+
+func (s *service) GetMessageTransaction(ctx context.Context, msgHash string) (*model.Transaction, error) {
+	tx, err := s.provider.GetMessageTransaction(ctx, s.AdminWallet, msgHash)
+	if err != nil {
+		if provider.IsNotFound(err) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (s *service) GetWalletNfts(ctx context.Context, wallet string, collections []*giftModel.Collection) ([]*models.Nft, error) {
+	var (
+		wg     sync.WaitGroup
+		nftsCh = make(chan []*models.Nft, len(collections))
+		errCh  = make(chan error, len(collections))
+	)
+
+	for _, collection := range collections {
+		if collection.Address == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			nfts, err := s.provider.GetNftItems(ctx, wallet, *collection.Address)
+			if err != nil {
+				errCh <- fmt.Errorf("failed to get nfts of %s: %w", *collection.Address, err)
+				return
+			}
+			for _, nft := range nfts {
+				nft.CollectionID = collection.ID
+			}
+			nftsCh <- nfts
+		}()
+	}
+
+	wg.Wait()
+	close(nftsCh)
+	close(errCh)
+
+	var allNfts []*models.Nft
+	for nfts := range nftsCh {
+		allNfts = slices.Concat(allNfts, nfts)
+	}
+
+	var errs []error
+	for err := range errCh {
+		errs = append(errs, err)
+	}
+
+	if len(allNfts) == 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("errors: %v", errs)
+		}
+		return nil, ErrNftsNotFound
+	}
+
+	return allNfts, nil
+}
+
+func (s *service) GetNft(ctx context.Context, address string) (*models.Nft, error) {
+	return s.provider.GetNft(ctx, address)
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
+	"time"
 
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/wallet"
@@ -10,40 +12,81 @@ import (
 	giftModel "roulette/internal/gift/model"
 	"roulette/internal/models"
 	"roulette/internal/ton/model"
+	"roulette/internal/ton/provider"
 )
 
 type Service interface {
-	GetTonTransfers(ctx context.Context, start *int64) ([]*model.Message, error)
+	// GetTonTransfers returns incoming messages with lt >= startLt in ascending order
+	GetTonTransfers(ctx context.Context, startLt int64, limit int, offset int) ([]*model.Message, error)
 
 	GetNftTransfer(ctx context.Context, itemAddress string) (*model.NftTransfer, error)
 
-	GetNftTransfers(ctx context.Context, start *int64) ([]*model.NftTransfer, error)
+	// GetNftTransfers returns incoming nft transfers with lt >= startLt in ascending order
+	GetNftTransfers(ctx context.Context, startLt int64, limit int) ([]*model.NftTransfer, error)
+
+	// GetNftSales returns marketplace purchases of the collection items since the time, newest first
+	GetNftSales(ctx context.Context, collection string, since time.Time, limit int) ([]*model.NftSale, error)
 
 	GetWalletNfts(ctx context.Context, wallet string, collection []*giftModel.Collection) ([]*models.Nft, error)
 
 	GetNft(ctx context.Context, address string) (*models.Nft, error)
 
-	SendTon(ctx context.Context, dst string, amount uint) (string, error)
+	// GetNftData reads the item state from a liteserver whatever the provider is,
+	// ErrNftNotFound is returned for an address that is not an nft item
+	GetNftData(ctx context.Context, address string) (*model.NftData, error)
+
+	// GetWalletPublicKey reads the key by get_public_key of the deployed wallet, the key of a wallet
+	// that is not deployed yet is read from its base64 stateInit. ErrWalletKeyNotFound is returned
+	// when neither has it
+	GetWalletPublicKey(ctx context.Context, address string, stateInit *string) (ed25519.PublicKey, error)
+
+	// GetLastLt returns lt of the newest admin wallet transaction from a liteserver whatever the provider is
+	GetLastLt(ctx context.Context) (int64, error)
+
+	// IsAdminWallet reports whether the address in any form is the admin wallet
+	IsAdminWallet(address string) bool
+
+	// GetMessageTransaction returns admin wallet transaction of the external message
+	GetMessageTransaction(ctx context.Context, msgHash string) (*model.Transaction, error)
+
+	// GetOutgoingMessages returns admin wallet messages with lt >= startLt in ascending order
+	GetOutgoingMessages(ctx context.Context, startLt int64, limit int) ([]*model.Message, error)
+
+	// BuildTonTransfers signs transfers as one message, queryID is used by highload wallet only
+	BuildTonTransfers(ctx context.Context, queryID uint32, transfers []*model.Transfer) (*model.ExternalMessage, error)
 
-	SendNft(ctx context.Context, dst string, nftAddress string) (string, error)
+	// BuildNftTransfer signs an nft transfer, queryID is used by highload wallet only
+	BuildNftTransfer(ctx context.Context, queryID uint32, dst string, nftAddress string) (*model.ExternalMessage, error)
+
+	// SendMessage sends the message without waiting for its transaction
+	SendMessage(ctx context.Context, msg *model.ExternalMessage) error
 
 	getWallet(ctx context.Context) (*wallet.Wallet, ton.APIClientWrapped, error)
 }
 
 type service struct {
-	IsTestnet              bool
-	TonCenterApiKey        string
-	TonCenterApiKeyTestnet string
-	AdminWallet            string
-	Mnemonic               string
+	IsTestnet   bool
+	AdminWallet string
+	Mnemonic    string
+
+	provider provider.Provider
+	lite     *provider.Lite
 }
 
-func NewService(cfg *config.Config) Service {
-	return &service{
-		IsTestnet:              cfg.TonConfig.IsTestnet,
-		TonCenterApiKey:        cfg.TonConfig.TonCenterApiKey,
-		TonCenterApiKeyTestnet: cfg.TonConfig.TonCenterApiKeyTestnet,
-		AdminWallet:            cfg.TonConfig.AdminWallet,
-		Mnemonic:               cfg.TonConfig.Mnemonic,
+func NewService(cfg *config.Config) (Service, error) {
+	client := provider.NewClient(cfg.TonConfig.Retries, cfg.TonConfig.RateLimits)
+	lite := provider.NewLite(client, cfg.TonConfig.IsTestnet)
+
+	p, err := provider.NewProvider(cfg, client, lite)
+	if err != nil {
+		return nil, err
 	}
+
+	return &service{
+		IsTestnet:   cfg.TonConfig.IsTestnet,
+		AdminWallet: cfg.TonConfig.AdminWallet,
+		Mnemonic:    cfg.TonConfig.Mnemonic,
+		provider:    p,
+		lite:        lite,
+	}, nil
 }
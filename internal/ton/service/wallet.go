@@ -1,37 +1,62 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"encoding/base64"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/nft"
 	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
 
+	"roulette/internal/ton/model"
 	"roulette/internal/utils"
 )
 
-func (s *service) SendTon(ctx context.Context, dst string, amount uint) (string, error) {
-	w, _, err := s.getWallet(ctx)
-	if err != nil {
-		return "", err
-	}
+const (
+	// MaxTransfers fits one action list of highload wallet, regular wallets send up to 255
+	MaxTransfers = 254
+
+	// highloadTTL is how long highload wallet accepts a message after its created at
+	highloadTTL = 5 * time.Minute
+	// regularTTL is the tonutils default valid until of regular wallets
+	regularTTL = 3 * time.Minute
+	// clockSkew moves highload created at back, so liteservers with a late clock accept it
+	clockSkew = 30 * time.Second
+)
+
+// walletQuery is passed to highload wallet message builder through ctx
+type walletQuery struct {
+	id        uint32
+	createdAt int64
+}
+
+type walletQueryKey struct{}
 
-	receivers := map[string]string{
-		dst: strconv.FormatUint(uint64(amount), 10),
+// BuildTonTransfers fails on the first invalid transfer, so callers validate destinations beforehand
+func (s *service) BuildTonTransfers(ctx context.Context, queryID uint32, transfers []*model.Transfer) (*model.ExternalMessage, error) {
+	if len(transfers) == 0 || len(transfers) > MaxTransfers {
+		return nil, fmt.Errorf("invalid transfers count: %d", len(transfers))
 	}
 
-	var messages []*wallet.Message
-	for addrStr, amountStr := range receivers {
-		addr, errAddr := utils.GetAddress(addrStr)
-		if errAddr != nil {
-			continue
+	messages := make([]*wallet.Message, 0, len(transfers))
+	for _, transfer := range transfers {
+		addr, err := utils.GetAddress(transfer.Destination)
+		if err != nil {
+			return nil, err
+		}
+
+		var body *cell.Cell
+		if transfer.Comment != "" {
+			if body, err = wallet.CreateCommentCell(transfer.Comment); err != nil {
+				return nil, fmt.Errorf("failed to create comment: %v", err)
+			}
 		}
 
 		messages = append(messages, &wallet.Message{
@@ -40,66 +65,105 @@ func (s *service) SendTon(ctx context.Context, dst string, amount uint) (string,
 				IHRDisabled: true,
 				Bounce:      addr.IsBounceable(),
 				DstAddr:     addr,
-				Amount:      tlb.MustFromTON(amountStr),
+				Amount:      tlb.FromNanoTONU(uint64(transfer.Amount)),
+				Body:        body,
 			},
 		})
 	}
 
-	txHash, err := w.SendManyWaitTxHash(ctx, messages)
-	if err != nil {
-		return "", fmt.Errorf("failed to send tx: %v", err)
-	}
-
-	txHashBase := base64.StdEncoding.EncodeToString(txHash)
-	return txHashBase, nil
+	return s.buildMessage(ctx, queryID, messages)
 }
 
-func (s *service) SendNft(ctx context.Context, dst string, nftAddress string) (string, error) {
+func (s *service) BuildNftTransfer(ctx context.Context, queryID uint32, dst string, nftAddress string) (*model.ExternalMessage, error) {
 	nftAddr, err := utils.GetAddress(nftAddress)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	dstAddr, err := utils.GetAddress(dst)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	amountForward, _ := tlb.FromTON("0.15")
 
-	w, api, err := s.getWallet(ctx)
+	api, err := s.lite.API(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	nftItem := nft.NewItemClient(api, nftAddr)
 	transferPayload, err := nftItem.BuildTransferPayload(dstAddr, amountForward, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create transfer payload")
+		return nil, fmt.Errorf("failed to create transfer payload")
 	}
 
 	msg := wallet.SimpleMessage(nftAddr, tlb.MustFromTON("0.05"), transferPayload)
-	tx, _, err := w.SendWaitTransaction(ctx, msg)
+	return s.buildMessage(ctx, queryID, []*wallet.Message{msg})
+}
+
+func (s *service) SendMessage(ctx context.Context, msg *model.ExternalMessage) error {
+	api, err := s.lite.API(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to send tx: %v", err)
+		return err
 	}
 
-	txHashBase := base64.StdEncoding.EncodeToString(tx.Hash)
-	return txHashBase, nil
+	c, err := cell.FromBOC(msg.Boc)
+	if err != nil {
+		return fmt.Errorf("failed to parse message: %v", err)
+	}
+
+	var ext tlb.ExternalMessage
+	if err = tlb.LoadFromCell(&ext, c.BeginParse()); err != nil {
+		return fmt.Errorf("failed to parse message: %v", err)
+	}
+
+	if err = api.SendExternalMessage(ctx, &ext); err != nil {
+		return fmt.Errorf("failed to send message: %v", err)
+	}
+
+	return nil
 }
 
-func (s *service) getWallet(ctx context.Context) (*wallet.Wallet, ton.APIClientWrapped, error) {
-	client := liteclient.NewConnectionPool()
+// buildMessage signs messages without sending, so the hash can be stored before the message is on chain
+func (s *service) buildMessage(ctx context.Context, queryID uint32, messages []*wallet.Message) (*model.ExternalMessage, error) {
+	w, _, err := s.getWallet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &walletQuery{
+		id:        queryID % (1 << 23),
+		createdAt: time.Now().Add(-clockSkew).Unix(),
+	}
+	ext, err := w.BuildExternalMessageForMany(context.WithValue(ctx, walletQueryKey{}, query), messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message: %v", err)
+	}
 
-	configUrl := "https://ton-blockchain.github.io/global.config.json"
+	c, err := tlb.ToCell(ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %v", err)
+	}
+
+	msg := &model.ExternalMessage{
+		Hash: base64.StdEncoding.EncodeToString(c.Hash()),
+		Boc:  c.ToBOC(),
+	}
 	if s.IsTestnet {
-		configUrl = "https://ton-blockchain.github.io/testnet-global.config.json"
+		msg.ExpireAt = time.Now().Add(regularTTL)
+	} else {
+		msg.QueryID = &query.id
+		msg.ExpireAt = time.Unix(query.createdAt, 0).Add(highloadTTL)
 	}
 
-	err := client.AddConnectionsFromConfigUrl(ctx, configUrl)
+	return msg, nil
+}
+
+func (s *service) getWallet(ctx context.Context) (*wallet.Wallet, ton.APIClientWrapped, error) {
+	api, err := s.lite.API(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to connect to lite: %v", err)
+		return nil, nil, err
 	}
 
-	api := ton.NewAPIClient(client, ton.ProofCheckPolicyFast).WithRetry()
 	mnemonic := strings.Split(s.Mnemonic, " ")
 
 	var w *wallet.Wallet
@@ -109,10 +173,13 @@ func (s *service) getWallet(ctx context.Context) (*wallet.Wallet, ton.APIClientW
 		})
 	} else {
 		w, err = wallet.FromSeed(api, mnemonic, wallet.ConfigHighloadV3{
-			MessageTTL: 60 * 5,
+			MessageTTL: uint32(highloadTTL.Seconds()),
 			MessageBuilder: func(ctx context.Context, subWalletId uint32) (id uint32, createdAt int64, err error) {
-				createdAt = time.Now().Unix() - 30
-				return uint32(createdAt % (1 << 23)), createdAt, nil
+				query, ok := ctx.Value(walletQueryKey{}).(*walletQuery)
+				if !ok {
+					return 0, 0, fmt.Errorf("query id is not set")
+				}
+				return query.id, query.createdAt, nil
 			},
 		})
 	}
@@ -122,3 +189,55 @@ func (s *service) getWallet(ctx context.Context) (*wallet.Wallet, ton.APIClientW
 
 	return w, api, nil
 }
+
+// stateInitPublicKey reads the key from the data of a known regular wallet after checking
+// that the stateInit is of the address
+func stateInitPublicKey(address string, stateInit string) (ed25519.PublicKey, error) {
+	addr, err := utils.GetAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	boc, err := base64.StdEncoding.DecodeString(stateInit)
+	if err != nil {
+		return nil, ErrWalletKeyNotFound
+	}
+	c, err := cell.FromBOC(boc)
+	if err != nil {
+		return nil, ErrWalletKeyNotFound
+	}
+	var state tlb.StateInit
+	if err = tlb.LoadFromCell(&state, c.BeginParse()); err != nil || state.Code == nil || state.Data == nil {
+		return nil, ErrWalletKeyNotFound
+	}
+	if !bytes.Equal(c.Hash(), addr.Data()) {
+		return nil, ErrWalletKeyNotFound
+	}
+
+	account := &tlb.Account{
+		IsActive: true,
+		State:    &tlb.AccountState{AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusActive}},
+		Code:     state.Code,
+	}
+
+	// the key follows seqno and subwallet id, v5 has the signature allowed bit before them
+	data := state.Data.BeginParse()
+	switch wallet.GetWalletVersion(account) {
+	case wallet.V3R1, wallet.V3R2, wallet.V4R1, wallet.V4R2:
+		_, err = data.LoadSlice(64)
+	case wallet.V5R1Final:
+		_, err = data.LoadSlice(65)
+	default:
+		return nil, ErrWalletKeyNotFound
+	}
+	if err != nil {
+		return nil, ErrWalletKeyNotFound
+	}
+
+	key, err := data.LoadSlice(256)
+	if err != nil {
+		return nil, ErrWalletKeyNotFound
+	}
+
+	return key, nil
+}
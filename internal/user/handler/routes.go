@@ -19,6 +19,10 @@ func (h *Handler) getUser(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
+		if res := handler.CheckCaller(c, uri.UserID); res != nil {
+			return res
+		}
+
 		user, err := h.service.GetUser(c.Request.Context(), uri.UserID)
 		if err != nil {
 			if service.IsUserNotFound(err) {
@@ -41,6 +45,10 @@ func (h *Handler) getUserProfile(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
+		if res := handler.CheckCaller(c, uri.UserID); res != nil {
+			return res
+		}
+
 		userProfile, err := h.service.GetUserProfile(c.Request.Context(), uri.UserID)
 		if err != nil {
 			return handler.NewErrorResponse(http.StatusNotFound, err.Error())
@@ -52,8 +60,12 @@ func (h *Handler) getUserProfile(c *gin.Context) {
 
 func (h *Handler) addUser(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
 		type RequestBody struct {
-			UserID     uint    `json:"userId"`
 			Name       *string `json:"name"`
 			PhotoUrl   *string `json:"photoUrl"`
 			StartParam *string `json:"startParam"`
@@ -63,7 +75,7 @@ func (h *Handler) addUser(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
-		err := h.service.AddUser(c.Request.Context(), body.UserID, body.Name, body.PhotoUrl, body.StartParam)
+		err := h.service.AddUser(c.Request.Context(), userID, body.Name, body.PhotoUrl, body.StartParam)
 		if err != nil {
 			return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
 		}
@@ -18,5 +18,4 @@ type UserProfile struct {
 type UpdateUser struct {
 	Name     *string
 	PhotoUrl *string
-	Balance  *uint
 }
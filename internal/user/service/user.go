@@ -23,7 +23,7 @@ type Service interface {
 
 	AddUser(ctx context.Context, userID uint, name, photoUrl, startParam *string) error
 
-	UpdateUser(ctx context.Context, userID uint, name *string, photoUrl *string, balance *int) error
+	UpdateUser(ctx context.Context, userID uint, name *string, photoUrl *string) error
 
 	generateMemo(userID uint) string
 }
@@ -114,11 +114,10 @@ func (s *service) AddUser(ctx context.Context, userID uint, name, photoUrl, star
 	return nil
 }
 
-func (s *service) UpdateUser(ctx context.Context, userID uint, name *string, photoUrl *string, balance *int) error {
+func (s *service) UpdateUser(ctx context.Context, userID uint, name *string, photoUrl *string) error {
 	user := &dbModels.UserDB{
 		Name:     name,
 		PhotoUrl: photoUrl,
-		Balance:  *balance,
 	}
 
 	if err := s.repo.UpdateUser(ctx, userID, user); err != nil {
@@ -0,0 +1,46 @@
+package model
+
+import "roulette/internal/models"
+
+// Item is an nft or gift priced for a bet
+type Item struct {
+	CollectionID uint
+	Floor        int64
+	Attributes   []*models.Attribute
+}
+
+type PremiumKind string
+
+const (
+	// PremiumRarity is configured by the rarity of the attribute in its collection
+	PremiumRarity PremiumKind = "rarity"
+	// PremiumTrait is set by an operator for a trait value of the collection
+	PremiumTrait PremiumKind = "trait"
+)
+
+// Premium is a percent of the floor added to items of the collection having the trait value
+type Premium struct {
+	Trait   string `json:"trait"`
+	Value   string `json:"value"`
+	Premium int64  `json:"premium"`
+}
+
+// AppliedPremium is a premium the item got for one of its attributes
+type AppliedPremium struct {
+	Kind    PremiumKind `json:"kind"`
+	Trait   string      `json:"trait"`
+	Value   string      `json:"value"`
+	Rarity  int         `json:"rarity,omitempty"`
+	Premium int64       `json:"premium"`
+}
+
+// Valuation is the breakdown of a bet stored with it, Premium is the percent added to the floor
+// after the cap and Tickets are Value divided by TicketPrice rounded down
+type Valuation struct {
+	Floor       int64             `json:"floor"`
+	Premiums    []*AppliedPremium `json:"premiums"`
+	Premium     int64             `json:"premium"`
+	Value       int64             `json:"value"`
+	TicketPrice int64             `json:"ticketPrice"`
+	Tickets     int               `json:"tickets"`
+}
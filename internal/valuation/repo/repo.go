@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+)
+
+type Repo interface {
+	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
+
+	GetPremiums(ctx context.Context, collectionID uint) ([]*dbModels.CollectionPremiumDB, error)
+
+	DeletePremiums(ctx context.Context, collectionID uint) error
+
+	// AddPremiums returns ErrNotFound for an unknown collection
+	AddPremiums(ctx context.Context, premiums []*dbModels.CollectionPremiumDB) error
+}
+
+type repo struct {
+	db *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repo {
+	return &repo{db: db}
+}
+
+func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, r.db, f)
+}
+
+func (r *repo) GetPremiums(ctx context.Context, collectionID uint) ([]*dbModels.CollectionPremiumDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var premiums []*dbModels.CollectionPremiumDB
+	err := db.WithContext(ctx).
+		Where("collection_id = ?", collectionID).
+		Order("trait, value").
+		Find(&premiums).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return premiums, nil
+}
+
+func (r *repo) DeletePremiums(ctx context.Context, collectionID uint) error {
+	db := database.FromContext(ctx, r.db)
+
+	return db.WithContext(ctx).
+		Delete(&dbModels.CollectionPremiumDB{}, "collection_id = ?", collectionID).Error
+}
+
+func (r *repo) AddPremiums(ctx context.Context, premiums []*dbModels.CollectionPremiumDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Select("collection_id", "trait", "value", "premium", "updated_by").
+		Create(premiums).Error
+	if err != nil {
+		if database.IsFKeyConflictError(err) {
+			return database.ErrNotFound
+		}
+		if database.IsKeyConflictErr(err) {
+			return database.ErrKeyConflict
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package service
+
+import "errors"
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrInvalidPremium     = errors.New("premium needs a trait, a value and a positive percent")
+	ErrDuplicatePremium   = errors.New("premium for the trait value is set twice")
+)
+
+func IsCollectionNotFound(err error) bool {
+	return errors.Is(err, ErrCollectionNotFound)
+}
+
+// IsInvalidPremiums reports whether the premiums can't be set
+func IsInvalidPremiums(err error) bool {
+	return errors.Is(err, ErrInvalidPremium) || errors.Is(err, ErrDuplicatePremium)
+}
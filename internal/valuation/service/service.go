@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"roulette/internal/config"
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	"roulette/internal/models"
+	"roulette/internal/valuation/model"
+	"roulette/internal/valuation/repo"
+)
+
+type Service interface {
+	// Value prices the item at its floor plus the premiums of its attributes and converts the value to tickets.
+	// An attribute takes its rarity premium and the trait premium of the collection
+	Value(ctx context.Context, item *model.Item, ticketPrice int64) (*model.Valuation, error)
+
+	GetPremiums(ctx context.Context, collectionID uint) ([]*model.Premium, error)
+
+	// SetPremiums replaces the trait premiums of the collection, no premiums clear them
+	SetPremiums(ctx context.Context, collectionID uint, premiums []*model.Premium, updatedBy string) error
+
+	// rarityPremium returns the first configured tier matching the attribute
+	rarityPremium(attribute *models.Attribute) (int64, bool)
+}
+
+type service struct {
+	repo repo.Repo
+
+	rarity     []config.RarityPremiumConfig
+	maxPremium int64
+}
+
+func NewService(repo repo.Repo, cfg *config.Config) Service {
+	return &service{
+		repo:       repo,
+		rarity:     cfg.ValuationConfig.Rarity,
+		maxPremium: cfg.ValuationConfig.MaxPremium,
+	}
+}
+
+func (s *service) Value(ctx context.Context, item *model.Item, ticketPrice int64) (*model.Valuation, error) {
+	valuation := &model.Valuation{
+		Floor:       item.Floor,
+		Premiums:    []*model.AppliedPremium{},
+		TicketPrice: ticketPrice,
+	}
+
+	if len(item.Attributes) > 0 {
+		premiums, err := s.repo.GetPremiums(ctx, item.CollectionID)
+		if err != nil {
+			return nil, err
+		}
+		traitPremiums := make(map[string]int64, len(premiums))
+		for _, premium := range premiums {
+			traitPremiums[premiumKey(premium.Trait, premium.Value)] = premium.Premium
+		}
+
+		for _, attribute := range item.Attributes {
+			if premium, ok := s.rarityPremium(attribute); ok {
+				valuation.Premiums = append(valuation.Premiums, &model.AppliedPremium{
+					Kind:    model.PremiumRarity,
+					Trait:   attribute.Trait,
+					Value:   attribute.Value,
+					Rarity:  attribute.Rarity,
+					Premium: premium,
+				})
+				valuation.Premium += premium
+			}
+			if premium, ok := traitPremiums[premiumKey(attribute.Trait, attribute.Value)]; ok {
+				valuation.Premiums = append(valuation.Premiums, &model.AppliedPremium{
+					Kind:    model.PremiumTrait,
+					Trait:   attribute.Trait,
+					Value:   attribute.Value,
+					Premium: premium,
+				})
+				valuation.Premium += premium
+			}
+		}
+	}
+
+	if s.maxPremium > 0 {
+		valuation.Premium = min(valuation.Premium, s.maxPremium)
+	}
+	valuation.Value = item.Floor + item.Floor*valuation.Premium/100
+	if ticketPrice > 0 {
+		valuation.Tickets = int(valuation.Value / ticketPrice)
+	}
+
+	return valuation, nil
+}
+
+func (s *service) GetPremiums(ctx context.Context, collectionID uint) ([]*model.Premium, error) {
+	premiums, err := s.repo.GetPremiums(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.Premium, 0, len(premiums))
+	for _, premium := range premiums {
+		res = append(res, &model.Premium{
+			Trait:   premium.Trait,
+			Value:   premium.Value,
+			Premium: premium.Premium,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *service) SetPremiums(ctx context.Context, collectionID uint, premiums []*model.Premium, updatedBy string) error {
+	seen := make(map[string]bool, len(premiums))
+	rows := make([]*dbModels.CollectionPremiumDB, 0, len(premiums))
+	for _, premium := range premiums {
+		trait, value := strings.TrimSpace(premium.Trait), strings.TrimSpace(premium.Value)
+		if trait == "" || value == "" || premium.Premium <= 0 {
+			return ErrInvalidPremium
+		}
+		key := premiumKey(trait, value)
+		if seen[key] {
+			return fmt.Errorf("%w: %s %s", ErrDuplicatePremium, trait, value)
+		}
+		seen[key] = true
+
+		rows = append(rows, &dbModels.CollectionPremiumDB{
+			CollectionID: collectionID,
+			Trait:        trait,
+			Value:        value,
+			Premium:      premium.Premium,
+			UpdatedBy:    &updatedBy,
+		})
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeletePremiums(ctx, collectionID); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := s.repo.AddPremiums(ctx, rows); err != nil {
+			if database.IsRecordNotFoundErr(err) {
+				return ErrCollectionNotFound
+			}
+			if database.IsKeyConflictErr(err) {
+				return ErrDuplicatePremium
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *service) rarityPremium(attribute *models.Attribute) (int64, bool) {
+	if attribute.Rarity <= 0 {
+		return 0, false
+	}
+	for _, tier := range s.rarity {
+		if tier.Trait != "" && !strings.EqualFold(tier.Trait, attribute.Trait) {
+			continue
+		}
+		if attribute.Rarity <= tier.MaxRarity {
+			return tier.Premium, true
+		}
+	}
+	return 0, false
+}
+
+// premiumKey matches traits of nft metadata and telegram gifts regardless of case
+func premiumKey(trait, value string) string {
+	return strings.ToLower(trait) + "\x00" + strings.ToLower(value)
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/wallet/service"
+)
+
+type Handler struct {
+	service service.Service
+}
+
+func NewHandler(service service.Service) *Handler {
+	return &Handler{service: service}
+}
+
+func Router(h *Handler, r *gin.Engine) {
+	router := r.Group("wallets")
+	{
+		router.GET("", h.getWallets)
+
+		router.POST("/payload", h.addPayload)
+		router.POST("", h.linkWallet)
+
+		router.DELETE("/:address", h.unlinkWallet)
+	}
+}
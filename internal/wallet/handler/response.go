@@ -0,0 +1,27 @@
+package handler
+
+import "roulette/internal/wallet/model"
+
+type PayloadResponse struct {
+	*model.Payload
+}
+
+func NewPayloadResponse(payload *model.Payload) *PayloadResponse {
+	return &PayloadResponse{Payload: payload}
+}
+
+type WalletResponse struct {
+	*model.Wallet
+}
+
+func NewWalletResponse(wallet *model.Wallet) *WalletResponse {
+	return &WalletResponse{Wallet: wallet}
+}
+
+type WalletsResponse struct {
+	Wallets []*model.Wallet `json:"wallets"`
+}
+
+func NewWalletsResponse(wallets []*model.Wallet) *WalletsResponse {
+	return &WalletsResponse{Wallets: wallets}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"roulette/internal/middleware/handler"
+	"roulette/internal/wallet/model"
+	"roulette/internal/wallet/service"
+)
+
+func (h *Handler) getWallets(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
+		wallets, err := h.service.GetWallets(c.Request.Context(), userID)
+		if err != nil {
+			return handler.NewInternalErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, NewWalletsResponse(wallets))
+	})
+}
+
+func (h *Handler) addPayload(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
+		payload, err := h.service.NewPayload(c.Request.Context(), userID)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusCreated, NewPayloadResponse(payload))
+	})
+}
+
+func (h *Handler) linkWallet(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
+		var body model.TonProof
+		if err := c.ShouldBindJSON(&body); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		wallet, err := h.service.Link(c.Request.Context(), userID, &body)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusCreated, NewWalletResponse(wallet))
+	})
+}
+
+func (h *Handler) unlinkWallet(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
+		type RequestUri struct {
+			Address string `uri:"address"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		if err := h.service.Unlink(c.Request.Context(), userID, uri.Address); err != nil {
+			return errorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusOK, nil)
+	})
+}
+
+func errorResponse(err error) *handler.Response {
+	if service.IsProofRejected(err) {
+		return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+	}
+	if service.IsWalletLinked(err) {
+		return handler.NewErrorResponse(http.StatusConflict, err.Error())
+	}
+	if service.IsWalletNotFound(err) || service.IsUserNotFound(err) {
+		return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+	}
+	if service.IsProofUnavailable(err) {
+		return handler.NewErrorResponse(http.StatusServiceUnavailable, err.Error())
+	}
+	return handler.NewInternalErrorResponse(err)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	dbModels "roulette/internal/database/models"
+)
+
+// Wallet is a wallet linked by the user, Address is raw
+type Wallet struct {
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewWallet(w *dbModels.UserWalletDB) *Wallet {
+	return &Wallet{
+		Address:   w.Address,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+// Payload is signed by the wallet in ton_proof before ExpiresAt
+type Payload struct {
+	Payload   string    `json:"payload"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// TonProof is the account connected by TON Connect with its ton_proof reply as the sdk returns them.
+// Chain is -239 for mainnet and -3 for testnet, WalletStateInit is base64 boc
+type TonProof struct {
+	Address         string  `json:"address"`
+	Chain           string  `json:"chain"`
+	PublicKey       string  `json:"publicKey"`
+	WalletStateInit *string `json:"walletStateInit"`
+	Proof           struct {
+		Timestamp int64 `json:"timestamp"`
+		Domain    struct {
+			LengthBytes uint32 `json:"lengthBytes"`
+			Value       string `json:"value"`
+		} `json:"domain"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	} `json:"proof"`
+}
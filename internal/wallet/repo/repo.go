@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+)
+
+type Repo interface {
+	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
+
+	GetWallets(ctx context.Context, userID uint) ([]*dbModels.UserWalletDB, error)
+
+	// GetWallet returns the wallet by raw address whoever linked it
+	GetWallet(ctx context.Context, address string) (*dbModels.UserWalletDB, error)
+
+	AddPayload(ctx context.Context, payload *dbModels.WalletPayloadDB) error
+
+	// TakePayload deletes the payload of the user and returns its expiry, so a payload is checked once
+	TakePayload(ctx context.Context, userID uint, payload string) (time.Time, error)
+
+	// DeleteExpiredPayloads removes the payloads of the user that can't be signed anymore
+	DeleteExpiredPayloads(ctx context.Context, userID uint) error
+
+	// AddWallet links the wallet to the user, linking it again updates the key.
+	// ErrKeyConflict is returned when another user linked the wallet
+	AddWallet(ctx context.Context, wallet *dbModels.UserWalletDB) error
+
+	DeleteWallet(ctx context.Context, userID uint, address string) error
+}
+
+type repo struct {
+	db *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) Repo {
+	return &repo{db: db}
+}
+
+func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, r.db, f)
+}
+
+func (r *repo) GetWallets(ctx context.Context, userID uint) ([]*dbModels.UserWalletDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var wallets []*dbModels.UserWalletDB
+	err := db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&wallets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+func (r *repo) GetWallet(ctx context.Context, address string) (*dbModels.UserWalletDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var wallet *dbModels.UserWalletDB
+	err := db.WithContext(ctx).
+		First(&wallet, "address = ?", address).Error
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func (r *repo) AddPayload(ctx context.Context, payload *dbModels.WalletPayloadDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Model(&dbModels.WalletPayloadDB{}).
+		Select("payload", "user_id", "expires_at").
+		Create(&payload).Error
+	if err != nil {
+		if database.IsFKeyConflictError(err) {
+			return database.ErrFKeyConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) TakePayload(ctx context.Context, userID uint, payload string) (time.Time, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var expiresAt []time.Time
+	err := db.WithContext(ctx).
+		Raw(`
+			DELETE FROM wallet_payloads
+			WHERE payload = ? AND user_id = ?
+			RETURNING expires_at
+		`, payload, userID).
+		Scan(&expiresAt).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(expiresAt) == 0 {
+		return time.Time{}, database.ErrNotFound
+	}
+
+	return expiresAt[0], nil
+}
+
+func (r *repo) DeleteExpiredPayloads(ctx context.Context, userID uint) error {
+	db := database.FromContext(ctx, r.db)
+
+	return db.WithContext(ctx).
+		Where("user_id = ? AND expires_at < CURRENT_TIMESTAMP", userID).
+		Delete(&dbModels.WalletPayloadDB{}).Error
+}
+
+func (r *repo) AddWallet(ctx context.Context, wallet *dbModels.UserWalletDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Exec(`
+			INSERT INTO user_wallets (user_id, address, public_key) VALUES (?, ?, ?)
+			ON CONFLICT (address) DO UPDATE SET public_key = excluded.public_key
+			WHERE user_wallets.user_id = excluded.user_id
+		`, wallet.UserID, wallet.Address, wallet.PublicKey)
+	if res.Error != nil {
+		if database.IsFKeyConflictError(res.Error) {
+			return database.ErrFKeyConflict
+		}
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrKeyConflict
+	}
+
+	return nil
+}
+
+func (r *repo) DeleteWallet(ctx context.Context, userID uint, address string) error {
+	db := database.FromContext(ctx, r.db)
+
+	res := db.WithContext(ctx).
+		Where("user_id = ? AND address = ?", userID, address).
+		Delete(&dbModels.UserWalletDB{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import "errors"
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrPayloadNotFound  = errors.New("payload is not issued or already used")
+	ErrPayloadExpired   = errors.New("payload expired")
+	ErrInvalidProof     = errors.New("invalid ton proof")
+	ErrProofExpired     = errors.New("ton proof expired")
+	ErrUnknownWallet    = errors.New("wallet public key not found, the wallet state init is required")
+	ErrWalletLinked     = errors.New("wallet is linked by another user")
+	ErrWalletNotFound   = errors.New("wallet not found")
+	ErrWalletNotLinked  = errors.New("wallet is not linked")
+	ErrInvalidAddress   = errors.New("invalid wallet address")
+	ErrProofUnavailable = errors.New("ton proof domains are not configured")
+)
+
+func IsUserNotFound(err error) bool {
+	return errors.Is(err, ErrUserNotFound)
+}
+
+// IsProofRejected reports whether the wallet can't be linked by the proof
+func IsProofRejected(err error) bool {
+	return errors.Is(err, ErrPayloadNotFound) || errors.Is(err, ErrPayloadExpired) ||
+		errors.Is(err, ErrInvalidProof) || errors.Is(err, ErrProofExpired) ||
+		errors.Is(err, ErrUnknownWallet) || errors.Is(err, ErrInvalidAddress)
+}
+
+func IsWalletLinked(err error) bool {
+	return errors.Is(err, ErrWalletLinked)
+}
+
+func IsWalletNotFound(err error) bool {
+	return errors.Is(err, ErrWalletNotFound)
+}
+
+func IsWalletNotLinked(err error) bool {
+	return errors.Is(err, ErrWalletNotLinked)
+}
+
+func IsProofUnavailable(err error) bool {
+	return errors.Is(err, ErrProofUnavailable)
+}
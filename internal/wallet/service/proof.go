@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+
+	tonService "roulette/internal/ton/service"
+	"roulette/internal/wallet/model"
+)
+
+const (
+	mainnetChain = "-239"
+	testnetChain = "-3"
+
+	tonProofPrefix   = "ton-proof-item-v2/"
+	tonConnectPrefix = "ton-connect"
+
+	// proofClockSkew allows proofs signed by a wallet with the clock ahead
+	proofClockSkew = time.Minute
+)
+
+// verify follows the ton_proof spec: the wallet signs
+// sha256(0xffff ++ "ton-connect" ++ sha256(message)) where message is
+// "ton-proof-item-v2/" ++ workchain ++ address hash ++ domain length ++ domain ++ timestamp ++ payload
+func (s *service) verify(ctx context.Context, addr string, proof *model.TonProof) (ed25519.PublicKey, error) {
+	if proof.Chain != s.chain {
+		return nil, fmt.Errorf("%w: chain %s", ErrInvalidProof, proof.Chain)
+	}
+
+	domain := proof.Proof.Domain
+	if int(domain.LengthBytes) != len(domain.Value) || !slices.Contains(s.domains, domain.Value) {
+		return nil, fmt.Errorf("%w: domain %s", ErrInvalidProof, domain.Value)
+	}
+
+	signedAt := time.Unix(proof.Proof.Timestamp, 0)
+	if time.Since(signedAt) > s.proofTTL || time.Until(signedAt) > proofClockSkew {
+		return nil, ErrProofExpired
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(proof.Proof.Signature)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: signature", ErrInvalidProof)
+	}
+
+	key, err := s.tonService.GetWalletPublicKey(ctx, addr, proof.WalletStateInit)
+	if err != nil {
+		if tonService.IsWalletKeyNotFound(err) {
+			return nil, ErrUnknownWallet
+		}
+		return nil, err
+	}
+	if proof.PublicKey != "" && !strings.EqualFold(proof.PublicKey, hex.EncodeToString(key)) {
+		return nil, fmt.Errorf("%w: public key", ErrInvalidProof)
+	}
+
+	wallet, err := address.ParseRawAddr(addr)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString(tonProofPrefix)
+	_ = binary.Write(&msg, binary.BigEndian, wallet.Workchain())
+	msg.Write(wallet.Data())
+	_ = binary.Write(&msg, binary.LittleEndian, domain.LengthBytes)
+	msg.WriteString(domain.Value)
+	_ = binary.Write(&msg, binary.LittleEndian, uint64(proof.Proof.Timestamp))
+	msg.WriteString(proof.Proof.Payload)
+	msgHash := sha256.Sum256(msg.Bytes())
+
+	signed := append([]byte{0xff, 0xff}, tonConnectPrefix...)
+	signed = append(signed, msgHash[:]...)
+	signedHash := sha256.Sum256(signed)
+
+	if !ed25519.Verify(key, signedHash[:], signature) {
+		return nil, fmt.Errorf("%w: signature", ErrInvalidProof)
+	}
+
+	return key, nil
+}
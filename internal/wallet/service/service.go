@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"roulette/internal/config"
+	"roulette/internal/database"
+	dbModels "roulette/internal/database/models"
+	tonService "roulette/internal/ton/service"
+	"roulette/internal/utils"
+	"roulette/internal/wallet/model"
+	"roulette/internal/wallet/repo"
+)
+
+type Service interface {
+	// NewPayload issues a payload for the wallet of the user to sign in ton_proof, it is checked once
+	NewPayload(ctx context.Context, userID uint) (*model.Payload, error)
+
+	// Link checks the proof signed over a payload of the user and links the wallet to the user
+	Link(ctx context.Context, userID uint, proof *model.TonProof) (*model.Wallet, error)
+
+	GetWallets(ctx context.Context, userID uint) ([]*model.Wallet, error)
+
+	Unlink(ctx context.Context, userID uint, address string) error
+
+	// IsLinked reports whether the address in any form is a wallet linked by the user
+	IsLinked(ctx context.Context, userID uint, address string) (bool, error)
+
+	// CheckLinked returns ErrWalletNotLinked unless the address is a wallet linked by the user
+	CheckLinked(ctx context.Context, userID uint, address string) error
+
+	// GetWalletUser returns the user who linked the address, ErrWalletNotFound for an unlinked one
+	GetWalletUser(ctx context.Context, address string) (uint, error)
+
+	// verify checks the proof against the key of the wallet and returns the key
+	verify(ctx context.Context, addr string, proof *model.TonProof) (ed25519.PublicKey, error)
+}
+
+type service struct {
+	repo       repo.Repo
+	tonService tonService.Service
+
+	chain      string
+	domains    []string
+	payloadTTL time.Duration
+	proofTTL   time.Duration
+}
+
+func NewService(repo repo.Repo, tonService tonService.Service, cfg *config.Config) Service {
+	chain := mainnetChain
+	if cfg.TonConfig.IsTestnet {
+		chain = testnetChain
+	}
+
+	domains := cfg.WalletConfig.Domains
+	if len(domains) == 0 {
+		if u, err := url.Parse(cfg.Origin); err == nil && u.Host != "" {
+			domains = []string{u.Host}
+		}
+	}
+
+	return &service{
+		repo:       repo,
+		tonService: tonService,
+		chain:      chain,
+		domains:    domains,
+		payloadTTL: cfg.WalletConfig.PayloadTTL,
+		proofTTL:   cfg.WalletConfig.ProofTTL,
+	}
+}
+
+func (s *service) NewPayload(ctx context.Context, userID uint) (*model.Payload, error) {
+	if len(s.domains) == 0 {
+		return nil, ErrProofUnavailable
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate payload: %v", err)
+	}
+
+	payload := &dbModels.WalletPayloadDB{
+		Payload:   hex.EncodeToString(b),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.payloadTTL),
+	}
+	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteExpiredPayloads(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.repo.AddPayload(ctx, payload); err != nil {
+			if database.IsFKeyConflictError(err) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		return nil
+	})
+	if errTx != nil {
+		return nil, errTx
+	}
+
+	return &model.Payload{Payload: payload.Payload, ExpiresAt: payload.ExpiresAt}, nil
+}
+
+func (s *service) Link(ctx context.Context, userID uint, proof *model.TonProof) (*model.Wallet, error) {
+	addr, err := utils.GetAddress(proof.Address)
+	if err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	// the payload is used even when the proof is rejected
+	expiresAt, err := s.repo.TakePayload(ctx, userID, proof.Proof.Payload)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrPayloadNotFound
+		}
+		return nil, err
+	}
+	if time.Now().After(expiresAt) {
+		return nil, ErrPayloadExpired
+	}
+
+	key, err := s.verify(ctx, addr.StringRaw(), proof)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := &dbModels.UserWalletDB{
+		UserID:    userID,
+		Address:   addr.StringRaw(),
+		PublicKey: hex.EncodeToString(key),
+	}
+	if err = s.repo.AddWallet(ctx, wallet); err != nil {
+		if database.IsKeyConflictErr(err) {
+			return nil, ErrWalletLinked
+		}
+		if database.IsFKeyConflictError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	wallet, err = s.repo.GetWallet(ctx, wallet.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewWallet(wallet), nil
+}
+
+func (s *service) GetWallets(ctx context.Context, userID uint) ([]*model.Wallet, error) {
+	wallets, err := s.repo.GetWallets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.Wallet, 0, len(wallets))
+	for _, wallet := range wallets {
+		res = append(res, model.NewWallet(wallet))
+	}
+
+	return res, nil
+}
+
+func (s *service) Unlink(ctx context.Context, userID uint, address string) error {
+	addr, err := utils.GetAddress(address)
+	if err != nil {
+		return ErrInvalidAddress
+	}
+
+	if err = s.repo.DeleteWallet(ctx, userID, addr.StringRaw()); err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return ErrWalletNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) IsLinked(ctx context.Context, userID uint, address string) (bool, error) {
+	walletUserID, err := s.GetWalletUser(ctx, address)
+	if err != nil {
+		if IsWalletNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return walletUserID == userID, nil
+}
+
+func (s *service) CheckLinked(ctx context.Context, userID uint, address string) error {
+	linked, err := s.IsLinked(ctx, userID, address)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return fmt.Errorf("%w: %s", ErrWalletNotLinked, address)
+	}
+
+	return nil
+}
+
+func (s *service) GetWalletUser(ctx context.Context, address string) (uint, error) {
+	addr, err := utils.GetAddress(address)
+	if err != nil {
+		return 0, ErrWalletNotFound
+	}
+
+	wallet, err := s.repo.GetWallet(ctx, addr.StringRaw())
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return 0, ErrWalletNotFound
+		}
+		return 0, err
+	}
+
+	return wallet.UserID, nil
+}
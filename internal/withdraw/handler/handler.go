@@ -22,5 +22,6 @@ func Router(h *Handler, r *gin.Engine) {
 		router.POST("/ton", h.addTon)
 		router.POST("/nft", h.addNft)
 		router.POST("/gift", h.addGift)
+		router.GET("/:id", h.getWithdrawal)
 	}
 }
@@ -0,0 +1,13 @@
+package handler
+
+import "roulette/internal/withdraw/model"
+
+type WithdrawalResponse struct {
+	Withdrawal *model.Withdrawal `json:"withdrawal"`
+}
+
+func NewWithdrawalResponse(withdrawal *model.Withdrawal) *WithdrawalResponse {
+	return &WithdrawalResponse{
+		Withdrawal: withdrawal,
+	}
+}
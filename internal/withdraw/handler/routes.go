@@ -7,12 +7,19 @@ import (
 
 	"roulette/internal/database"
 	"roulette/internal/middleware/handler"
+	pauseService "roulette/internal/pause/service"
+	userService "roulette/internal/user/service"
+	"roulette/internal/withdraw/service"
 )
 
 func (h *Handler) addTon(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
 		type RequestBody struct {
-			UserID      uint   `json:"userId"`
 			Destination string `json:"destination"`
 			Amount      int    `json:"amount"`
 		}
@@ -20,21 +27,26 @@ func (h *Handler) addTon(c *gin.Context) {
 		if err := c.ShouldBindJSON(&body); err != nil {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
+		if body.Amount <= 0 {
+			return handler.NewErrorResponse(http.StatusBadRequest, service.ErrInvalidAmount.Error())
+		}
 
-		err := h.service.AddTon(c.Request.Context(), body.UserID, body.Destination, uint(body.Amount))
+		withdrawal, err := h.service.AddTon(c.Request.Context(), userID, body.Destination, uint(body.Amount))
 		if err != nil {
-			if database.IsRecordNotFoundErr(err) {
-				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			return addErrorResponse(err)
 		}
 
-		return handler.NewSuccessResponse(http.StatusCreated, "")
+		return handler.NewSuccessResponse(http.StatusAccepted, NewWithdrawalResponse(withdrawal))
 	})
 }
 
 func (h *Handler) addNft(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
 		type RequestBody struct {
 			UserNftID   uint   `json:"userNftId"`
 			Destination string `json:"destination"`
@@ -44,20 +56,22 @@ func (h *Handler) addNft(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
-		err := h.service.AddNft(c.Request.Context(), body.UserNftID, body.Destination)
+		withdrawal, err := h.service.AddNft(c.Request.Context(), userID, body.UserNftID, body.Destination)
 		if err != nil {
-			if database.IsRecordNotFoundErr(err) {
-				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
-			}
-			return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			return addErrorResponse(err)
 		}
 
-		return handler.NewSuccessResponse(http.StatusCreated, nil)
+		return handler.NewSuccessResponse(http.StatusAccepted, NewWithdrawalResponse(withdrawal))
 	})
 }
 
 func (h *Handler) addGift(c *gin.Context) {
 	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
 		type RequestBody struct {
 			UserGiftID uint `json:"userGiftId"`
 		}
@@ -66,14 +80,54 @@ func (h *Handler) addGift(c *gin.Context) {
 			return handler.NewUnprocessableErrorResponse(err)
 		}
 
-		err := h.service.AddGift(c.Request.Context(), body.UserGiftID)
+		withdrawal, err := h.service.AddGift(c.Request.Context(), userID, body.UserGiftID)
+		if err != nil {
+			return addErrorResponse(err)
+		}
+
+		return handler.NewSuccessResponse(http.StatusAccepted, NewWithdrawalResponse(withdrawal))
+	})
+}
+
+func (h *Handler) getWithdrawal(c *gin.Context) {
+	handler.HandleRequest(c, func(c *gin.Context) *handler.Response {
+		userID, res := handler.CallerID(c)
+		if res != nil {
+			return res
+		}
+
+		type RequestUri struct {
+			WithdrawalID uint `uri:"id"`
+		}
+		var uri RequestUri
+		if err := c.ShouldBindUri(&uri); err != nil {
+			return handler.NewUnprocessableErrorResponse(err)
+		}
+
+		withdrawal, err := h.service.GetWithdrawal(c.Request.Context(), userID, uri.WithdrawalID)
 		if err != nil {
-			if database.IsRecordNotFoundErr(err) {
+			if service.IsWithdrawalNotFound(err) {
 				return handler.NewErrorResponse(http.StatusNotFound, err.Error())
 			}
-			return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+			return handler.NewInternalErrorResponse(err)
 		}
 
-		return handler.NewSuccessResponse(http.StatusCreated, "")
+		return handler.NewSuccessResponse(http.StatusOK, NewWithdrawalResponse(withdrawal))
 	})
 }
+
+func addErrorResponse(err error) *handler.Response {
+	if pauseService.IsPaused(err) {
+		return handler.NewErrorResponse(http.StatusServiceUnavailable, "withdrawals are paused")
+	}
+	if database.IsRecordNotFoundErr(err) || userService.IsUserNotFound(err) {
+		return handler.NewErrorResponse(http.StatusNotFound, err.Error())
+	}
+	if service.IsInvalidDestination(err) || service.IsInvalidAmount(err) || service.IsNotEnoughFunds(err) {
+		return handler.NewErrorResponse(http.StatusBadRequest, err.Error())
+	}
+	if service.IsItemBet(err) || service.IsFeePending(err) {
+		return handler.NewErrorResponse(http.StatusConflict, err.Error())
+	}
+	return handler.NewInternalErrorResponse(err)
+}
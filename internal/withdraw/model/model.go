@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	dbModels "roulette/internal/database/models"
+)
+
+type Withdrawal struct {
+	ID          uint                      `json:"id"`
+	UserID      uint                      `json:"userId"`
+	Type        dbModels.WithdrawalType   `json:"type"`
+	Status      dbModels.WithdrawalStatus `json:"status"`
+	Destination *string                   `json:"destination"`
+	Amount      int64                     `json:"amount"`
+	Fee         int64                     `json:"fee"`
+	NftAddress  *string                   `json:"nftAddress"`
+	GiftID      *int64                    `json:"giftId"`
+	BatchID     *uint                     `json:"batchId"`
+	QueryID     *uint32                   `json:"queryId"`
+	MsgHash     *string                   `json:"msgHash"`
+	Error       *string                   `json:"error"`
+	SubmittedAt *time.Time                `json:"submittedAt"`
+	CompletedAt *time.Time                `json:"completedAt"`
+	CreatedAt   time.Time                 `json:"createdAt"`
+}
+
+func NewWithdrawal(w *dbModels.WithdrawalDB) *Withdrawal {
+	return &Withdrawal{
+		ID:          w.ID,
+		UserID:      w.UserID,
+		Type:        w.Type,
+		Status:      w.Status,
+		Destination: w.Destination,
+		Amount:      w.Amount,
+		Fee:         w.Fee,
+		NftAddress:  w.NftAddress,
+		GiftID:      w.GiftID,
+		BatchID:     w.BatchID,
+		QueryID:     w.QueryID,
+		MsgHash:     w.MsgHash,
+		Error:       w.Error,
+		SubmittedAt: w.SubmittedAt,
+		CompletedAt: w.CompletedAt,
+		CreatedAt:   w.CreatedAt,
+	}
+}
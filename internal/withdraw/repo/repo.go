@@ -2,9 +2,9 @@ package repo
 
 import (
 	"context"
-	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"roulette/internal/database"
 	dbModels "roulette/internal/database/models"
@@ -13,17 +13,34 @@ import (
 type Repo interface {
 	RunInTx(ctx context.Context, f func(ctx context.Context) error) error
 
-	AddTon(ctx context.Context, tonWithdraw *dbModels.TonWithdrawDB) error
+	// GetWithdrawal locks the withdrawal row when called in a tx
+	GetWithdrawal(ctx context.Context, withdrawalID uint) (*dbModels.WithdrawalDB, error)
 
-	AddNft(ctx context.Context, nftWithdraw *dbModels.NftWithdrawDB) error
+	// GetWithdrawals returns the oldest withdrawals in the status of any of types, all types if none are given
+	GetWithdrawals(ctx context.Context, status dbModels.WithdrawalStatus, limit int, types ...dbModels.WithdrawalType) ([]*dbModels.WithdrawalDB, error)
 
-	AddGift(ctx context.Context, giftWithdraw *dbModels.GiftWithdrawDB) error
+	// LockWithdrawals locks the oldest withdrawals skipping ones locked by other workers, must be called in a tx
+	LockWithdrawals(ctx context.Context, withdrawalType dbModels.WithdrawalType, status dbModels.WithdrawalStatus, limit int) ([]*dbModels.WithdrawalDB, error)
 
-	UpdateUserBalance(ctx context.Context, userID uint, amount int) error
+	GetBatchWithdrawals(ctx context.Context, batchID uint) ([]*dbModels.WithdrawalDB, error)
 
-	DeleteNft(ctx context.Context, nftID uint) error
+	GetBatches(ctx context.Context, status dbModels.WithdrawalBatchStatus, limit int) ([]*dbModels.WithdrawalBatchDB, error)
 
-	DeleteGift(ctx context.Context, giftID uint) error
+	AddBatch(ctx context.Context, batch *dbModels.WithdrawalBatchDB) error
+
+	UpdateBatch(ctx context.Context, batchID uint, batch *dbModels.WithdrawalBatchDB) error
+
+	AddWithdrawal(ctx context.Context, withdrawal *dbModels.WithdrawalDB) error
+
+	UpdateWithdrawal(ctx context.Context, withdrawalID uint, withdrawal *dbModels.WithdrawalDB) error
+
+	AddUserNft(ctx context.Context, userID uint, nftID uint) error
+
+	AddUserGift(ctx context.Context, userID uint, giftID int64) error
+
+	DeleteUserNft(ctx context.Context, userNftID uint) error
+
+	DeleteUserGift(ctx context.Context, userGiftID uint) error
 }
 
 type repo struct {
@@ -35,32 +52,100 @@ func NewRepo(db *gorm.DB) Repo {
 }
 
 func (r *repo) RunInTx(ctx context.Context, f func(ctx context.Context) error) error {
-	// TODO
 	return database.RunInTx(ctx, r.db, f)
 }
 
-func (r *repo) AddTon(ctx context.Context, tonWithdraw *dbModels.TonWithdrawDB) error {
+func (r *repo) GetWithdrawal(ctx context.Context, withdrawalID uint) (*dbModels.WithdrawalDB, error) {
 	db := database.FromContext(ctx, r.db)
 
+	var withdrawal *dbModels.WithdrawalDB
 	err := db.WithContext(ctx).
-		Model(&dbModels.TonWithdrawDB{}).
-		Create(&tonWithdraw).Error
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&withdrawal, "id = ?", withdrawalID).Error
 	if err != nil {
-		if database.IsKeyConflictErr(err) {
-			return database.ErrKeyConflict
+		if database.IsRecordNotFoundErr(err) {
+			return nil, database.ErrNotFound
 		}
-		return err
+		return nil, err
 	}
 
-	return nil
+	return withdrawal, nil
+}
+
+func (r *repo) GetWithdrawals(ctx context.Context, status dbModels.WithdrawalStatus, limit int, types ...dbModels.WithdrawalType) ([]*dbModels.WithdrawalDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	query := db.WithContext(ctx).
+		Where("status = ?", status)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+
+	var withdrawals []*dbModels.WithdrawalDB
+	err := query.
+		Order("id").
+		Limit(limit).
+		Find(&withdrawals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+func (r *repo) LockWithdrawals(ctx context.Context, withdrawalType dbModels.WithdrawalType, status dbModels.WithdrawalStatus, limit int) ([]*dbModels.WithdrawalDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var withdrawals []*dbModels.WithdrawalDB
+	err := db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("type = ? AND status = ?", withdrawalType, status).
+		Order("id").
+		Limit(limit).
+		Find(&withdrawals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
 }
 
-func (r *repo) AddNft(ctx context.Context, nftWithdraw *dbModels.NftWithdrawDB) error {
+func (r *repo) GetBatchWithdrawals(ctx context.Context, batchID uint) ([]*dbModels.WithdrawalDB, error) {
 	db := database.FromContext(ctx, r.db)
 
+	var withdrawals []*dbModels.WithdrawalDB
 	err := db.WithContext(ctx).
-		Model(&dbModels.NftWithdrawDB{}).
-		Create(&nftWithdraw).Error
+		Where("batch_id = ?", batchID).
+		Order("id").
+		Find(&withdrawals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawals, nil
+}
+
+func (r *repo) GetBatches(ctx context.Context, status dbModels.WithdrawalBatchStatus, limit int) ([]*dbModels.WithdrawalBatchDB, error) {
+	db := database.FromContext(ctx, r.db)
+
+	var batches []*dbModels.WithdrawalBatchDB
+	err := db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("id").
+		Limit(limit).
+		Find(&batches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+func (r *repo) AddBatch(ctx context.Context, batch *dbModels.WithdrawalBatchDB) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Create(&batch).Error
 	if err != nil {
 		if database.IsKeyConflictErr(err) {
 			return database.ErrKeyConflict
@@ -71,47 +156,53 @@ func (r *repo) AddNft(ctx context.Context, nftWithdraw *dbModels.NftWithdrawDB)
 	return nil
 }
 
-func (r *repo) AddGift(ctx context.Context, giftWithdraw *dbModels.GiftWithdrawDB) error {
+func (r *repo) UpdateBatch(ctx context.Context, batchID uint, batch *dbModels.WithdrawalBatchDB) error {
 	db := database.FromContext(ctx, r.db)
 
-	err := db.WithContext(ctx).
-		Model(&dbModels.GiftWithdrawDB{}).
-		Create(&giftWithdraw).Error
-	if err != nil {
-		if database.IsKeyConflictErr(err) {
+	res := db.WithContext(ctx).
+		Where("id = ?", batchID).
+		Updates(batch)
+	if res.Error != nil {
+		if database.IsKeyConflictErr(res.Error) {
 			return database.ErrKeyConflict
 		}
-		return err
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return database.ErrNotFound
 	}
 
 	return nil
 }
 
-func (r *repo) UpdateUserBalance(ctx context.Context, userID uint, amount int) error {
+func (r *repo) AddWithdrawal(ctx context.Context, withdrawal *dbModels.WithdrawalDB) error {
 	db := database.FromContext(ctx, r.db)
 
-	var user *dbModels.UserDB
 	err := db.WithContext(ctx).
-		Model(&dbModels.UserDB{}).
-		Where("id = ?", userID).
-		First(&user).Error
+		Create(&withdrawal).Error
 	if err != nil {
-		if database.IsRecordNotFoundErr(err) {
-			return database.ErrNotFound
+		if database.IsKeyConflictErr(err) {
+			return database.ErrKeyConflict
+		}
+		if database.IsFKeyConflictError(err) {
+			return database.ErrFKeyConflict
 		}
 		return err
 	}
 
-	if user.Balance < amount {
-		return fmt.Errorf("user %d has not enough funds", userID)
-	}
+	return nil
+}
+
+func (r *repo) UpdateWithdrawal(ctx context.Context, withdrawalID uint, withdrawal *dbModels.WithdrawalDB) error {
+	db := database.FromContext(ctx, r.db)
 
-	balance := user.Balance - amount
 	res := db.WithContext(ctx).
-		Model(&dbModels.UserDB{}).
-		Where("id = ?", userID).
-		UpdateColumn("balance", balance)
+		Where("id = ?", withdrawalID).
+		Updates(withdrawal)
 	if res.Error != nil {
+		if database.IsKeyConflictErr(res.Error) {
+			return database.ErrKeyConflict
+		}
 		return res.Error
 	}
 	if res.RowsAffected == 0 {
@@ -121,12 +212,43 @@ func (r *repo) UpdateUserBalance(ctx context.Context, userID uint, amount int) e
 	return nil
 }
 
-func (r *repo) DeleteNft(ctx context.Context, nftID uint) error {
+func (r *repo) AddUserNft(ctx context.Context, userID uint, nftID uint) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Select("user_id", "nft_id").
+		Create(&dbModels.UserNftDB{UserID: userID, NftID: nftID}).Error
+	if err != nil {
+		if database.IsKeyConflictErr(err) {
+			return database.ErrKeyConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) AddUserGift(ctx context.Context, userID uint, giftID int64) error {
+	db := database.FromContext(ctx, r.db)
+
+	err := db.WithContext(ctx).
+		Select("user_id", "gift_id").
+		Create(&dbModels.UserGiftDB{UserID: int64(userID), GiftID: giftID}).Error
+	if err != nil {
+		if database.IsKeyConflictErr(err) {
+			return database.ErrKeyConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) DeleteUserNft(ctx context.Context, userNftID uint) error {
 	db := database.FromContext(ctx, r.db)
 
 	res := db.WithContext(ctx).
-		Model(&dbModels.NftDB{}).
-		Delete(&dbModels.NftDB{}, "id = ?", nftID)
+		Delete(&dbModels.UserNftDB{}, "id = ?", userNftID)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -137,12 +259,11 @@ func (r *repo) DeleteNft(ctx context.Context, nftID uint) error {
 	return nil
 }
 
-func (r *repo) DeleteGift(ctx context.Context, giftID uint) error {
+func (r *repo) DeleteUserGift(ctx context.Context, userGiftID uint) error {
 	db := database.FromContext(ctx, r.db)
 
 	res := db.WithContext(ctx).
-		Model(&dbModels.GiftDB{}).
-		Where("id = $1", giftID)
+		Delete(&dbModels.UserGiftDB{}, "id = ?", userGiftID)
 	if res.Error != nil {
 		return res.Error
 	}
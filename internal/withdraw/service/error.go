@@ -0,0 +1,44 @@
+package service
+
+import "errors"
+
+var (
+	ErrWithdrawalNotFound = errors.New("withdrawal not found")
+	ErrWithdrawalMoved    = errors.New("withdrawal status has changed")
+	ErrInvalidDestination = errors.New("invalid destination address")
+	ErrInvalidAmount      = errors.New("invalid withdrawal amount")
+	ErrNotEnoughFunds     = errors.New("not enough funds")
+	ErrItemBet            = errors.New("item is bet in a round that isn't settled")
+	ErrFeePending         = errors.New("fee of a won round is not paid yet")
+	ErrMessageExpired     = errors.New("message expired without transaction")
+	ErrTransactionAborted = errors.New("transaction aborted")
+	ErrTransferSkipped    = errors.New("transfer was not sent by the batch transaction")
+)
+
+func IsWithdrawalNotFound(err error) bool {
+	return errors.Is(err, ErrWithdrawalNotFound)
+}
+
+func IsWithdrawalMoved(err error) bool {
+	return errors.Is(err, ErrWithdrawalMoved)
+}
+
+func IsInvalidDestination(err error) bool {
+	return errors.Is(err, ErrInvalidDestination)
+}
+
+func IsInvalidAmount(err error) bool {
+	return errors.Is(err, ErrInvalidAmount)
+}
+
+func IsNotEnoughFunds(err error) bool {
+	return errors.Is(err, ErrNotEnoughFunds)
+}
+
+func IsItemBet(err error) bool {
+	return errors.Is(err, ErrItemBet)
+}
+
+func IsFeePending(err error) bool {
+	return errors.Is(err, ErrFeePending)
+}
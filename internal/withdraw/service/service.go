@@ -2,145 +2,318 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"roulette/internal/config"
+	"roulette/internal/database"
 	dbModels "roulette/internal/database/models"
 	giftService "roulette/internal/gift/service"
+	ledgerModel "roulette/internal/ledger/model"
+	ledgerService "roulette/internal/ledger/service"
+	pauseService "roulette/internal/pause/service"
 	tgService "roulette/internal/tg/service"
 	tonService "roulette/internal/ton/service"
 	userService "roulette/internal/user/service"
+	"roulette/internal/utils"
+	walletService "roulette/internal/wallet/service"
+	"roulette/internal/withdraw/model"
 	"roulette/internal/withdraw/repo"
 )
 
 type Service interface {
-	// AddTon adds new ton withdraw
-	AddTon(ctx context.Context, userID uint, dst string, amount uint) error
+	// AddTon debits amount with fee and queues the withdrawal.
+	// The destination has to be linked by the user when withdrawals are limited to linked wallets
+	AddTon(ctx context.Context, userID uint, dst string, amount uint) (*model.Withdrawal, error)
 
-	// AddNft adds new nft withdraw
-	AddNft(ctx context.Context, userNftID uint, dst string) error
+	// AddNft takes nft from the user, debits fee and queues the withdrawal, nft of another user is not found.
+	// The nft can't be withdrawn while it is bet in a round that isn't settled
+	AddNft(ctx context.Context, userID uint, userNftID uint, dst string) (*model.Withdrawal, error)
 
-	// AddGift adds new gift withdraw
-	AddGift(ctx context.Context, userGiftID uint) error
+	// AddGift takes gift from the user, debits fee and queues the withdrawal, gift of another user is not found.
+	// The gift can't be withdrawn while it is bet in a round that isn't settled
+	AddGift(ctx context.Context, userID uint, userGiftID uint) (*model.Withdrawal, error)
+
+	// GetWithdrawal returns the withdrawal of the user
+	GetWithdrawal(ctx context.Context, userID uint, withdrawalID uint) (*model.Withdrawal, error)
+
+	// SubmitWithdrawals sends debited withdrawals, nothing is sent while withdrawals are paused
+	SubmitWithdrawals(ctx context.Context) error
+
+	// ConfirmWithdrawals checks submitted messages on chain, expired ones are failed
+	ConfirmWithdrawals(ctx context.Context) error
+
+	// RefundWithdrawals gives funds and items of failed withdrawals back
+	RefundWithdrawals(ctx context.Context) error
+
+	add(ctx context.Context, withdrawal *dbModels.WithdrawalDB, take func(ctx context.Context) error) error
+
+	debit(ctx context.Context, withdrawal *dbModels.WithdrawalDB) error
+
+	lock(ctx context.Context, withdrawalID uint, status dbModels.WithdrawalStatus) (*dbModels.WithdrawalDB, error)
+
+	// checkDestination returns ErrInvalidDestination for an address the user can't withdraw to
+	checkDestination(ctx context.Context, userID uint, dst string) error
 }
 
 type service struct {
-	repo        repo.Repo
-	tonService  tonService.Service
-	tgService   tgService.Service
-	giftService giftService.Service
-	userService userService.Service
+	repo          repo.Repo
+	tonService    tonService.Service
+	tgService     tgService.Service
+	giftService   giftService.Service
+	userService   userService.Service
+	ledgerService ledgerService.Service
+	pauseService  pauseService.Service
+	walletService walletService.Service
+
+	batchSize   int
+	batchWindow time.Duration
+	// linkedOnly limits destinations to the wallets linked by the user
+	linkedOnly bool
 }
 
-func NewService(repo repo.Repo, tonService tonService.Service, tgService tgService.Service, giftService giftService.Service, userService userService.Service) Service {
+func NewService(
+	repo repo.Repo,
+	tonService tonService.Service,
+	tgService tgService.Service,
+	giftService giftService.Service,
+	userService userService.Service,
+	ledgerService ledgerService.Service,
+	pauseService pauseService.Service,
+	walletService walletService.Service,
+	cfg *config.Config,
+) Service {
+	batchSize := cfg.WithdrawConfig.BatchSize
+	if batchSize <= 0 || batchSize > maxBatchSize {
+		batchSize = maxBatchSize
+	}
+
 	return &service{
-		repo:        repo,
-		tonService:  tonService,
-		tgService:   tgService,
-		giftService: giftService,
-		userService: userService,
+		repo:          repo,
+		tonService:    tonService,
+		tgService:     tgService,
+		giftService:   giftService,
+		userService:   userService,
+		ledgerService: ledgerService,
+		pauseService:  pauseService,
+		walletService: walletService,
+		batchSize:     batchSize,
+		batchWindow:   cfg.WithdrawConfig.BatchWindow,
+		linkedOnly:    cfg.WalletConfig.LinkedWithdrawals,
 	}
 }
 
-func (s *service) AddTon(ctx context.Context, userID uint, dst string, amount uint) error {
-	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		if err := s.repo.UpdateUserBalance(ctx, userID, int(amount)+TonFee); err != nil {
-			return err
-		}
+func (s *service) AddTon(ctx context.Context, userID uint, dst string, amount uint) (*model.Withdrawal, error) {
+	if amount == 0 {
+		return nil, ErrInvalidAmount
+	}
+	if err := s.checkDestination(ctx, userID, dst); err != nil {
+		return nil, err
+	}
+
+	withdrawal := &dbModels.WithdrawalDB{
+		UserID:      userID,
+		Type:        dbModels.WithdrawalTon,
+		Destination: &dst,
+		Amount:      int64(amount),
+		Fee:         int64(TonFee),
+	}
+	if err := s.add(ctx, withdrawal, nil); err != nil {
+		return nil, err
+	}
+
+	return model.NewWithdrawal(withdrawal), nil
+}
 
-		_, err := s.tonService.SendTon(ctx, dst, amount)
+func (s *service) AddNft(ctx context.Context, userID uint, userNftID uint, dst string) (*model.Withdrawal, error) {
+	if err := s.checkDestination(ctx, userID, dst); err != nil {
+		return nil, err
+	}
+
+	var withdrawal *dbModels.WithdrawalDB
+	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		userNft, err := s.giftService.GetUserNft(ctx, userNftID)
 		if err != nil {
 			return err
 		}
-
-		tonWithdraw := &dbModels.TonWithdrawDB{
-			UserID:      userID,
-			Destination: dst,
-			Amount:      amount,
-			CreatedAt:   time.Time{},
+		if userNft.UserID != userID {
+			return fmt.Errorf("user nft %d: %w", userNftID, database.ErrNotFound)
 		}
-		if err = s.repo.AddTon(ctx, tonWithdraw); err != nil {
-			return err
+		if userNft.IsBet {
+			return fmt.Errorf("user nft %d: %w", userNftID, ErrItemBet)
 		}
 
-		return nil
+		withdrawal = &dbModels.WithdrawalDB{
+			UserID:      userNft.UserID,
+			Type:        dbModels.WithdrawalNft,
+			Destination: &dst,
+			Fee:         int64(NftFee),
+			NftID:       &userNft.NftID,
+			NftAddress:  &userNft.Address,
+		}
+		return s.add(ctx, withdrawal, func(ctx context.Context) error {
+			return s.repo.DeleteUserNft(ctx, userNft.ID)
+		})
 	})
 	if errTx != nil {
-		return errTx
+		return nil, errTx
 	}
 
-	return nil
+	return model.NewWithdrawal(withdrawal), nil
 }
 
-func (s *service) AddNft(ctx context.Context, userNftID uint, dst string) error {
+func (s *service) AddGift(ctx context.Context, userID uint, userGiftID uint) (*model.Withdrawal, error) {
+	var withdrawal *dbModels.WithdrawalDB
 	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		userNft, err := s.giftService.GetUserNft(ctx, userNftID)
+		userGift, err := s.giftService.GetUserGift(ctx, userGiftID)
 		if err != nil {
 			return err
 		}
-
-		if err = s.repo.UpdateUserBalance(ctx, userNft.UserID, NftFee); err != nil {
-			return err
-		}
-
-		_, err = s.tonService.SendNft(ctx, dst, userNft.Address)
-		if err != nil {
-			return err
+		if userGift.UserID != userID {
+			return fmt.Errorf("user gift %d: %w", userGiftID, database.ErrNotFound)
 		}
-
-		if err = s.repo.DeleteNft(ctx, userNft.NftID); err != nil {
-			return err
+		if userGift.IsBet {
+			return fmt.Errorf("user gift %d: %w", userGiftID, ErrItemBet)
 		}
 
-		nftWithdraw := &dbModels.NftWithdrawDB{
-			UserID:      userNft.UserID,
-			Destination: dst,
-			NftAddress:  userNft.Address,
-		}
-		if err = s.repo.AddNft(ctx, nftWithdraw); err != nil {
-			return err
+		giftID := int64(userGift.GiftID)
+		msgID := int64(userGift.MsgID)
+		withdrawal = &dbModels.WithdrawalDB{
+			UserID:    userGift.UserID,
+			Type:      dbModels.WithdrawalGift,
+			Fee:       int64(GiftFee),
+			GiftID:    &giftID,
+			GiftMsgID: &msgID,
 		}
-
-		return nil
+		return s.add(ctx, withdrawal, func(ctx context.Context) error {
+			return s.repo.DeleteUserGift(ctx, userGift.ID)
+		})
 	})
 	if errTx != nil {
-		return errTx
+		return nil, errTx
 	}
 
-	return nil
+	return model.NewWithdrawal(withdrawal), nil
 }
 
-func (s *service) AddGift(ctx context.Context, userGiftID uint) error {
-	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
-		userGift, err := s.giftService.GetUserGift(ctx, userGiftID)
+func (s *service) GetWithdrawal(ctx context.Context, userID uint, withdrawalID uint) (*model.Withdrawal, error) {
+	withdrawal, err := s.repo.GetWithdrawal(ctx, withdrawalID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	if withdrawal.UserID != userID {
+		return nil, ErrWithdrawalNotFound
+	}
+
+	return model.NewWithdrawal(withdrawal), nil
+}
+
+// add stores the requested withdrawal to get the id for ledger keys, then takes
+// the item from the user and debits the balance, so it is debited when committed.
+// Nothing is withdrawn while the user owes the fee of a won round
+func (s *service) add(ctx context.Context, withdrawal *dbModels.WithdrawalDB, take func(ctx context.Context) error) error {
+	if err := s.pauseService.Check(ctx, dbModels.FeatureWithdrawals); err != nil {
+		return err
+	}
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		fee, err := s.giftService.GetWinnerFee(ctx, withdrawal.UserID)
 		if err != nil {
 			return err
 		}
+		if fee > 0 {
+			return fmt.Errorf("user %d: %w", withdrawal.UserID, ErrFeePending)
+		}
 
-		if err = s.repo.UpdateUserBalance(ctx, userGift.UserID, GiftFee); err != nil {
+		withdrawal.Status = dbModels.WithdrawalRequested
+		if err = s.repo.AddWithdrawal(ctx, withdrawal); err != nil {
+			if database.IsFKeyConflictError(err) {
+				return userService.ErrUserNotFound
+			}
 			return err
 		}
 
-		if err = s.tgService.SendGift(ctx, int64(userGift.UserID), userGift.MsgID); err != nil {
-			return err
+		if take != nil {
+			if err = take(ctx); err != nil {
+				return err
+			}
 		}
 
-		if err = s.repo.DeleteGift(ctx, userGift.GiftID); err != nil {
+		if err = s.debit(ctx, withdrawal); err != nil {
 			return err
 		}
 
-		giftWithdraw := &dbModels.GiftWithdrawDB{
-			UserID:    userGift.UserID,
-			GiftID:    userGift.GiftID,
-			CreatedAt: time.Time{},
+		withdrawal.Status = dbModels.WithdrawalDebited
+		return s.repo.UpdateWithdrawal(ctx, withdrawal.ID, &dbModels.WithdrawalDB{Status: withdrawal.Status})
+	})
+}
+
+func (s *service) debit(ctx context.Context, withdrawal *dbModels.WithdrawalDB) error {
+	postings := []*ledgerModel.Posting{
+		{
+			Key:    ledgerModel.Key(ledgerModel.Withdraw, withdrawal.ID),
+			Kind:   ledgerModel.Withdraw,
+			Debit:  ledgerModel.UserAccount(withdrawal.UserID),
+			Credit: ledgerModel.ExternalAccount,
+			Amount: withdrawal.Amount,
+		},
+		{
+			Key:    ledgerModel.Key(ledgerModel.WithdrawFee, withdrawal.ID),
+			Kind:   ledgerModel.WithdrawFee,
+			Debit:  ledgerModel.UserAccount(withdrawal.UserID),
+			Credit: ledgerModel.HouseAccount,
+			Amount: withdrawal.Fee,
+		},
+	}
+
+	for _, posting := range postings {
+		if posting.Amount == 0 {
+			continue
 		}
-		if err = s.repo.AddGift(ctx, giftWithdraw); err != nil {
+		if err := s.ledgerService.Post(ctx, posting); err != nil {
+			if ledgerService.IsNotEnoughBalance(err) {
+				return fmt.Errorf("user %d: %w", withdrawal.UserID, ErrNotEnoughFunds)
+			}
 			return err
 		}
+	}
+
+	return nil
+}
+
+// lock returns the withdrawal locked in the tx, ErrWithdrawalMoved if another worker has changed its status
+func (s *service) lock(ctx context.Context, withdrawalID uint, status dbModels.WithdrawalStatus) (*dbModels.WithdrawalDB, error) {
+	withdrawal, err := s.repo.GetWithdrawal(ctx, withdrawalID)
+	if err != nil {
+		if database.IsRecordNotFoundErr(err) {
+			return nil, ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	if withdrawal.Status != status {
+		return nil, ErrWithdrawalMoved
+	}
 
+	return withdrawal, nil
+}
+
+func (s *service) checkDestination(ctx context.Context, userID uint, dst string) error {
+	if _, err := utils.GetAddress(dst); err != nil {
+		return ErrInvalidDestination
+	}
+	if !s.linkedOnly {
 		return nil
-	})
-	if errTx != nil {
-		return errTx
+	}
+
+	linked, err := s.walletService.IsLinked(ctx, userID, dst)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return fmt.Errorf("%w: wallet is not linked", ErrInvalidDestination)
 	}
 
 	return nil
@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	dbModels "roulette/internal/database/models"
+	ledgerModel "roulette/internal/ledger/model"
+	pauseService "roulette/internal/pause/service"
+	tgService "roulette/internal/tg/service"
+	tonModel "roulette/internal/ton/model"
+	tonService "roulette/internal/ton/service"
+	"roulette/internal/utils"
+)
+
+const (
+	workerBatchSize = 50
+	maxBatchSize    = tonService.MaxTransfers
+	// expireGrace gives indexers time to show a transaction processed right before the message expired
+	expireGrace = 2 * time.Minute
+	// outgoingPageSize limits one page of wallet messages searched for batch transfers
+	outgoingPageSize = 256
+)
+
+// SubmitWithdrawals sends a ton batch, then nfts and gifts one by one
+func (s *service) SubmitWithdrawals(ctx context.Context) error {
+	if err := s.pauseService.Check(ctx, dbModels.FeatureWithdrawals); err != nil {
+		if pauseService.IsPaused(err) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	if err := s.submitTonBatch(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("ton batch: %w", err))
+	}
+
+	withdrawals, err := s.repo.GetWithdrawals(ctx, dbModels.WithdrawalDebited, workerBatchSize, dbModels.WithdrawalNft, dbModels.WithdrawalGift)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	for _, withdrawal := range withdrawals {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var errSubmit error
+		if withdrawal.Type == dbModels.WithdrawalGift {
+			errSubmit = s.submitGift(ctx, withdrawal.ID)
+		} else {
+			errSubmit = s.submitNft(ctx, withdrawal.ID)
+		}
+		if errSubmit != nil && !IsWithdrawalMoved(errSubmit) {
+			errs = append(errs, fmt.Errorf("withdrawal %d: %w", withdrawal.ID, errSubmit))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *service) ConfirmWithdrawals(ctx context.Context) error {
+	batches, err := s.repo.GetBatches(ctx, dbModels.WithdrawalBatchSubmitted, workerBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, batch := range batches {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if errConfirm := s.confirmBatch(ctx, batch); errConfirm != nil {
+			errs = append(errs, fmt.Errorf("batch %d: %w", batch.ID, errConfirm))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *service) RefundWithdrawals(ctx context.Context) error {
+	withdrawals, err := s.repo.GetWithdrawals(ctx, dbModels.WithdrawalFailed, workerBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, withdrawal := range withdrawals {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if errRefund := s.refund(ctx, withdrawal.ID); errRefund != nil && !IsWithdrawalMoved(errRefund) {
+			errs = append(errs, fmt.Errorf("withdrawal %d: %w", withdrawal.ID, errRefund))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// submitTonBatch waits for the batch window unless the batch is full. Withdrawals are locked
+// while the message is built and the hash is stored before sending, so a message lost after
+// the send is still tracked and expires into a refund instead of a second payout
+func (s *service) submitTonBatch(ctx context.Context) error {
+	var msg *tonModel.ExternalMessage
+	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		withdrawals, err := s.repo.LockWithdrawals(ctx, dbModels.WithdrawalTon, dbModels.WithdrawalDebited, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(withdrawals) == 0 {
+			return nil
+		}
+		if len(withdrawals) < s.batchSize && time.Since(withdrawals[0].CreatedAt) < s.batchWindow {
+			return nil
+		}
+
+		var (
+			batched   []*dbModels.WithdrawalDB
+			transfers []*tonModel.Transfer
+		)
+		for _, withdrawal := range withdrawals {
+			if _, err = utils.GetAddress(*withdrawal.Destination); err != nil {
+				if err = s.fail(ctx, withdrawal.ID, dbModels.WithdrawalDebited, ErrInvalidDestination); err != nil {
+					return err
+				}
+				continue
+			}
+
+			batched = append(batched, withdrawal)
+			transfers = append(transfers, &tonModel.Transfer{
+				Destination: *withdrawal.Destination,
+				Amount:      uint(withdrawal.Amount),
+				Comment:     transferComment(withdrawal.ID),
+			})
+		}
+		if len(batched) == 0 {
+			return nil
+		}
+
+		batch := &dbModels.WithdrawalBatchDB{Status: dbModels.WithdrawalBatchSubmitted}
+		if err = s.repo.AddBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		msg, err = s.tonService.BuildTonTransfers(ctx, uint32(batch.ID), transfers)
+		if err != nil {
+			return err
+		}
+
+		return s.submitBatch(ctx, batch, msg, batched)
+	})
+	if errTx != nil || msg == nil {
+		return errTx
+	}
+
+	return s.tonService.SendMessage(ctx, msg)
+}
+
+// submitNft sends the nft as a batch of one, so its query id comes from the same sequence
+func (s *service) submitNft(ctx context.Context, withdrawalID uint) error {
+	var msg *tonModel.ExternalMessage
+	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		withdrawal, err := s.lock(ctx, withdrawalID, dbModels.WithdrawalDebited)
+		if err != nil {
+			return err
+		}
+
+		batch := &dbModels.WithdrawalBatchDB{Status: dbModels.WithdrawalBatchSubmitted}
+		if err = s.repo.AddBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		msg, err = s.tonService.BuildNftTransfer(ctx, uint32(batch.ID), *withdrawal.Destination, *withdrawal.NftAddress)
+		if err != nil {
+			return err
+		}
+
+		return s.submitBatch(ctx, batch, msg, []*dbModels.WithdrawalDB{withdrawal})
+	})
+	if errTx != nil {
+		return errTx
+	}
+
+	return s.tonService.SendMessage(ctx, msg)
+}
+
+// submitBatch stores the message on the batch and its withdrawals
+func (s *service) submitBatch(ctx context.Context, batch *dbModels.WithdrawalBatchDB, msg *tonModel.ExternalMessage, withdrawals []*dbModels.WithdrawalDB) error {
+	err := s.repo.UpdateBatch(ctx, batch.ID, &dbModels.WithdrawalBatchDB{
+		QueryID:  msg.QueryID,
+		MsgHash:  &msg.Hash,
+		ExpireAt: &msg.ExpireAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	submittedAt := time.Now()
+	for _, withdrawal := range withdrawals {
+		err = s.repo.UpdateWithdrawal(ctx, withdrawal.ID, &dbModels.WithdrawalDB{
+			Status:      dbModels.WithdrawalSubmitted,
+			BatchID:     &batch.ID,
+			QueryID:     msg.QueryID,
+			MsgHash:     &msg.Hash,
+			ExpireAt:    &msg.ExpireAt,
+			SubmittedAt: &submittedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// submitGift can't be tracked after the send, so only a transfer rejected by telegram fails.
+// A gift left submitted by a crash or an unclear send error is resolved manually
+func (s *service) submitGift(ctx context.Context, withdrawalID uint) error {
+	var withdrawal *dbModels.WithdrawalDB
+	errTx := s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		if withdrawal, err = s.lock(ctx, withdrawalID, dbModels.WithdrawalDebited); err != nil {
+			return err
+		}
+
+		submittedAt := time.Now()
+		return s.repo.UpdateWithdrawal(ctx, withdrawalID, &dbModels.WithdrawalDB{
+			Status:      dbModels.WithdrawalSubmitted,
+			SubmittedAt: &submittedAt,
+		})
+	})
+	if errTx != nil {
+		return errTx
+	}
+
+	if err := s.tgService.SendGift(ctx, int64(withdrawal.UserID), int(*withdrawal.GiftMsgID)); err != nil {
+		if tgService.IsGiftRejected(err) {
+			return s.fail(ctx, withdrawalID, dbModels.WithdrawalSubmitted, err)
+		}
+		return fmt.Errorf("gift is left submitted: %w", err)
+	}
+
+	return s.complete(ctx, withdrawalID)
+}
+
+// confirmBatch finds the batch transaction, then settles each withdrawal by its own
+// transfer, as highload wallet skips transfers it can't send without failing the batch
+func (s *service) confirmBatch(ctx context.Context, batch *dbModels.WithdrawalBatchDB) error {
+	if batch.TxLt == nil {
+		tx, err := s.tonService.GetMessageTransaction(ctx, *batch.MsgHash)
+		if err != nil {
+			if !tonService.IsTransactionNotFound(err) {
+				return err
+			}
+			if time.Since(*batch.ExpireAt) > expireGrace {
+				return s.failBatch(ctx, batch.ID, ErrMessageExpired)
+			}
+			return nil
+		}
+		if tx.Description.Aborted {
+			return s.failBatch(ctx, batch.ID, fmt.Errorf("%w: %s", ErrTransactionAborted, tx.Hash))
+		}
+
+		txLt, err := strconv.ParseInt(tx.Lt, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid transaction lt %s: %v", tx.Lt, err)
+		}
+		if err = s.repo.UpdateBatch(ctx, batch.ID, &dbModels.WithdrawalBatchDB{TxHash: &tx.Hash, TxLt: &txLt}); err != nil {
+			return err
+		}
+		batch.TxLt = &txLt
+	}
+
+	withdrawals, err := s.repo.GetBatchWithdrawals(ctx, batch.ID)
+	if err != nil {
+		return err
+	}
+
+	pending := make(map[string]*dbModels.WithdrawalDB)
+	for _, withdrawal := range withdrawals {
+		if withdrawal.Status != dbModels.WithdrawalSubmitted {
+			continue
+		}
+		key, err := transferKey(withdrawal)
+		if err != nil {
+			if err = s.fail(ctx, withdrawal.ID, dbModels.WithdrawalSubmitted, err); err != nil && !IsWithdrawalMoved(err) {
+				return err
+			}
+			continue
+		}
+		pending[key] = withdrawal
+	}
+
+	if len(pending) > 0 {
+		if err = s.confirmTransfers(ctx, *batch.TxLt, pending); err != nil {
+			return err
+		}
+	}
+	if len(pending) > 0 {
+		if time.Since(*batch.ExpireAt) <= expireGrace {
+			return nil
+		}
+		for _, withdrawal := range pending {
+			if err = s.fail(ctx, withdrawal.ID, dbModels.WithdrawalSubmitted, ErrTransferSkipped); err != nil && !IsWithdrawalMoved(err) {
+				return err
+			}
+		}
+	}
+
+	return s.repo.UpdateBatch(ctx, batch.ID, &dbModels.WithdrawalBatchDB{Status: dbModels.WithdrawalBatchCompleted})
+}
+
+// confirmTransfers completes withdrawals found among wallet messages sent after the batch transaction,
+// found ones are removed from pending
+func (s *service) confirmTransfers(ctx context.Context, startLt int64, pending map[string]*dbModels.WithdrawalDB) error {
+	for len(pending) > 0 {
+		msgs, err := s.tonService.GetOutgoingMessages(ctx, startLt, outgoingPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			if lt, errLt := strconv.ParseInt(msg.CreatedLt, 10, 64); errLt == nil {
+				startLt = max(startLt, lt+1)
+			}
+
+			key, ok := matchTransfer(msg, pending)
+			if !ok {
+				continue
+			}
+
+			if err = s.complete(ctx, pending[key].ID); err != nil && !IsWithdrawalMoved(err) {
+				return err
+			}
+			delete(pending, key)
+		}
+
+		if len(msgs) < outgoingPageSize {
+			break
+		}
+	}
+
+	return nil
+}
+
+// transferKey identifies the wallet message of the withdrawal, ton transfers by the comment
+// and nft transfers by the item the message is sent to
+func transferKey(withdrawal *dbModels.WithdrawalDB) (string, error) {
+	if withdrawal.Type != dbModels.WithdrawalNft {
+		return transferComment(withdrawal.ID), nil
+	}
+
+	addr, err := utils.GetAddress(*withdrawal.NftAddress)
+	if err != nil {
+		return "", err
+	}
+	return addr.StringRaw(), nil
+}
+
+// matchTransfer returns the key of the pending withdrawal sent by the message
+func matchTransfer(msg *tonModel.Message, pending map[string]*dbModels.WithdrawalDB) (string, bool) {
+	if comment := msg.MsgContent.Decoded.Comment; comment != nil {
+		withdrawal, ok := pending[*comment]
+		if ok && withdrawal.Type == dbModels.WithdrawalTon && strconv.FormatInt(withdrawal.Amount, 10) == msg.Value {
+			return *comment, true
+		}
+	}
+
+	addr, err := utils.GetAddress(msg.Destination)
+	if err != nil {
+		return "", false
+	}
+	key := addr.StringRaw()
+	if withdrawal, ok := pending[key]; ok && withdrawal.Type == dbModels.WithdrawalNft {
+		return key, true
+	}
+
+	return "", false
+}
+
+// complete confirms the withdrawal. The sent nft or gift is kept for the bets of past rounds,
+// the user item was removed when the withdrawal was added
+func (s *service) complete(ctx context.Context, withdrawalID uint) error {
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if _, err := s.lock(ctx, withdrawalID, dbModels.WithdrawalSubmitted); err != nil {
+			return err
+		}
+
+		completedAt := time.Now()
+		return s.repo.UpdateWithdrawal(ctx, withdrawalID, &dbModels.WithdrawalDB{
+			Status:      dbModels.WithdrawalConfirmed,
+			CompletedAt: &completedAt,
+		})
+	})
+}
+
+func (s *service) fail(ctx context.Context, withdrawalID uint, status dbModels.WithdrawalStatus, reason error) error {
+	log.Printf("withdrawal %d failed: %v", withdrawalID, reason)
+
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if _, err := s.lock(ctx, withdrawalID, status); err != nil {
+			return err
+		}
+
+		errMsg := reason.Error()
+		return s.repo.UpdateWithdrawal(ctx, withdrawalID, &dbModels.WithdrawalDB{
+			Status: dbModels.WithdrawalFailed,
+			Error:  &errMsg,
+		})
+	})
+}
+
+func (s *service) failBatch(ctx context.Context, batchID uint, reason error) error {
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		withdrawals, err := s.repo.GetBatchWithdrawals(ctx, batchID)
+		if err != nil {
+			return err
+		}
+
+		for _, withdrawal := range withdrawals {
+			if err = s.fail(ctx, withdrawal.ID, dbModels.WithdrawalSubmitted, reason); err != nil && !IsWithdrawalMoved(err) {
+				return err
+			}
+		}
+
+		return s.repo.UpdateBatch(ctx, batchID, &dbModels.WithdrawalBatchDB{Status: dbModels.WithdrawalBatchFailed})
+	})
+}
+
+// refund reverses debit postings and gives the item back to the user
+func (s *service) refund(ctx context.Context, withdrawalID uint) error {
+	return s.repo.RunInTx(ctx, func(ctx context.Context) error {
+		withdrawal, err := s.lock(ctx, withdrawalID, dbModels.WithdrawalFailed)
+		if err != nil {
+			return err
+		}
+
+		postings := []*ledgerModel.Posting{
+			{
+				Key:    ledgerModel.Key(ledgerModel.Withdraw, "refund", withdrawal.ID),
+				Kind:   ledgerModel.Withdraw,
+				Debit:  ledgerModel.ExternalAccount,
+				Credit: ledgerModel.UserAccount(withdrawal.UserID),
+				Amount: withdrawal.Amount,
+			},
+			{
+				Key:    ledgerModel.Key(ledgerModel.WithdrawFee, "refund", withdrawal.ID),
+				Kind:   ledgerModel.WithdrawFee,
+				Debit:  ledgerModel.HouseAccount,
+				Credit: ledgerModel.UserAccount(withdrawal.UserID),
+				Amount: withdrawal.Fee,
+			},
+		}
+		for _, posting := range postings {
+			if posting.Amount == 0 {
+				continue
+			}
+			if err = s.ledgerService.Post(ctx, posting); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case withdrawal.NftID != nil:
+			err = s.repo.AddUserNft(ctx, withdrawal.UserID, *withdrawal.NftID)
+		case withdrawal.GiftID != nil:
+			err = s.repo.AddUserGift(ctx, withdrawal.UserID, *withdrawal.GiftID)
+		}
+		if err != nil {
+			return err
+		}
+
+		completedAt := time.Now()
+		return s.repo.UpdateWithdrawal(ctx, withdrawalID, &dbModels.WithdrawalDB{
+			Status:      dbModels.WithdrawalRefunded,
+			CompletedAt: &completedAt,
+		})
+	})
+}
+
+// transferComment tags the transfer, so it is matched to the withdrawal among batch messages
+func transferComment(withdrawalID uint) string {
+	return fmt.Sprintf("withdrawal #%d", withdrawalID)
+}
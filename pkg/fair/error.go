@@ -0,0 +1,24 @@
+package fair
+
+import "errors"
+
+var (
+	ErrInvalidSeed    = errors.New("invalid server seed")
+	ErrHashMismatch   = errors.New("server seed does not match hash")
+	ErrInvalidRanges  = errors.New("invalid ticket ranges")
+	ErrSeedMismatch   = errors.New("client seed does not match ranges")
+	ErrTicketMismatch = errors.New("winning ticket mismatch")
+	ErrWinnerMismatch = errors.New("winner mismatch")
+)
+
+func IsHashMismatch(err error) bool {
+	return errors.Is(err, ErrHashMismatch)
+}
+
+func IsTicketMismatch(err error) bool {
+	return errors.Is(err, ErrTicketMismatch)
+}
+
+func IsWinnerMismatch(err error) bool {
+	return errors.Is(err, ErrWinnerMismatch)
+}
@@ -0,0 +1,166 @@
+// Package fair implements the commit-reveal scheme used to draw round winners.
+//
+// A random server seed is generated when a round is created and only its
+// SHA-256 hash is published. Players may attach client seeds to their bets.
+// Once the winner is stored the server seed is revealed, so anyone can
+// recompute the winning ticket with Verify.
+package fair
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+const (
+	seedSize   = 32
+	numberBits = 52
+)
+
+type Range struct {
+	UserID     uint    `json:"userId"`
+	From       int     `json:"from"`
+	To         int     `json:"to"`
+	ClientSeed *string `json:"clientSeed"`
+}
+
+type Proof struct {
+	RoundID      uint     `json:"roundId"`
+	ServerSeed   string   `json:"serverSeed"`
+	Hash         string   `json:"hash"`
+	ClientSeed   string   `json:"clientSeed"`
+	RoundNumber  string   `json:"roundNumber"`
+	TotalTickets int      `json:"totalTickets"`
+	Ranges       []*Range `json:"ranges"`
+	WinnerID     uint     `json:"winnerId"`
+	Ticket       int      `json:"ticket"`
+}
+
+// NewSeed returns a hex encoded random server seed
+func NewSeed() (string, error) {
+	b := make([]byte, seedSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate seed: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Commit returns the hash published before the round starts
+func Commit(serverSeed string) string {
+	hash := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(hash[:])
+}
+
+// ClientSeed joins players' seeds in ticket order, each one is prefixed by its length
+// as "len:seed", so seeds containing ':' can't be split another way
+func ClientSeed(seeds []string) string {
+	var b strings.Builder
+	for _, seed := range seeds {
+		fmt.Fprintf(&b, "%d:%s", len(seed), seed)
+	}
+	return b.String()
+}
+
+// RoundNumber returns 52 bits of HMAC-SHA256(serverSeed, "roundID:clientSeed")
+func RoundNumber(serverSeed string, roundID uint, clientSeed string) uint64 {
+	mac := hmac.New(sha256.New, []byte(serverSeed))
+	mac.Write([]byte(fmt.Sprintf("%d:%s", roundID, clientSeed)))
+	sum := mac.Sum(nil)
+	return binary.BigEndian.Uint64(sum[:8]) >> (64 - numberBits)
+}
+
+// FormatRoundNumber formats round number as a fraction in [0, 1)
+func FormatRoundNumber(roundNumber uint64) string {
+	return fmt.Sprintf("%.16f", float64(roundNumber)/float64(uint64(1)<<numberBits))
+}
+
+// WinningTicket maps round number onto [1, totalTickets]
+func WinningTicket(roundNumber uint64, totalTickets int) int {
+	if totalTickets <= 0 {
+		return -1
+	}
+	hi, lo := bits.Mul64(roundNumber, uint64(totalTickets))
+	return int(hi<<(64-numberBits)|lo>>numberBits) + 1
+}
+
+// Ranges assigns consecutive ticket ranges to bets in the given order
+func Ranges(userIDs []uint, tickets []int, clientSeeds []*string) []*Range {
+	ranges := make([]*Range, 0, len(userIDs))
+	from := 1
+	for i, userID := range userIDs {
+		if tickets[i] <= 0 {
+			continue
+		}
+		ranges = append(ranges, &Range{
+			UserID:     userID,
+			From:       from,
+			To:         from + tickets[i] - 1,
+			ClientSeed: clientSeeds[i],
+		})
+		from += tickets[i]
+	}
+	return ranges
+}
+
+// RangesClientSeed collects client seeds of the ranges
+func RangesClientSeed(ranges []*Range) string {
+	var seeds []string
+	for _, r := range ranges {
+		if r.ClientSeed != nil && *r.ClientSeed != "" {
+			seeds = append(seeds, *r.ClientSeed)
+		}
+	}
+	return ClientSeed(seeds)
+}
+
+// Winner returns the owner of the ticket
+func Winner(ranges []*Range, ticket int) (uint, bool) {
+	for _, r := range ranges {
+		if ticket >= r.From && ticket <= r.To {
+			return r.UserID, true
+		}
+	}
+	return 0, false
+}
+
+// Verify checks the proof without trusting the server
+func Verify(p *Proof) error {
+	if _, err := hex.DecodeString(p.ServerSeed); err != nil || p.ServerSeed == "" {
+		return ErrInvalidSeed
+	}
+	if Commit(p.ServerSeed) != strings.ToLower(p.Hash) {
+		return ErrHashMismatch
+	}
+
+	next := 1
+	for _, r := range p.Ranges {
+		if r.From != next || r.To < r.From {
+			return ErrInvalidRanges
+		}
+		next = r.To + 1
+	}
+	if next-1 != p.TotalTickets {
+		return ErrInvalidRanges
+	}
+
+	if RangesClientSeed(p.Ranges) != p.ClientSeed {
+		return ErrSeedMismatch
+	}
+
+	ticket := WinningTicket(RoundNumber(p.ServerSeed, p.RoundID, p.ClientSeed), p.TotalTickets)
+	if ticket != p.Ticket {
+		return fmt.Errorf("%w: expected %d, got %d", ErrTicketMismatch, ticket, p.Ticket)
+	}
+
+	winnerID, ok := Winner(p.Ranges, ticket)
+	if !ok || winnerID != p.WinnerID {
+		return fmt.Errorf("%w: expected %d, got %d", ErrWinnerMismatch, winnerID, p.WinnerID)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package fair
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSeed is a fixed server seed, the expected numbers below are computed from it
+const (
+	testSeed    = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	testHash    = "7b3d979ca8330a94fa7e9e1b466d8b99e0bcdea1ec90596c0dcc8d7ef6b4300c"
+	testRoundID = 42
+)
+
+func seed(s string) *string {
+	return &s
+}
+
+// testProof is a valid proof of a round with client seeds containing ':'
+func testProof() *Proof {
+	return &Proof{
+		RoundID:    testRoundID,
+		ServerSeed: testSeed,
+		Hash:       testHash,
+		ClientSeed: "3:a:b1:c",
+		Ranges: []*Range{
+			{UserID: 1, From: 1, To: 3, ClientSeed: seed("a:b")},
+			{UserID: 2, From: 4, To: 4},
+			{UserID: 3, From: 5, To: 10, ClientSeed: seed("c")},
+		},
+		TotalTickets: 10,
+		WinnerID:     3,
+		Ticket:       5,
+	}
+}
+
+func TestCommit(t *testing.T) {
+	if got := Commit(testSeed); got != testHash {
+		t.Fatalf("Commit() = %s, want %s", got, testHash)
+	}
+}
+
+func TestClientSeed(t *testing.T) {
+	tests := []struct {
+		name  string
+		seeds []string
+		want  string
+	}{
+		{name: "no seeds", seeds: nil, want: ""},
+		{name: "one seed", seeds: []string{"abc"}, want: "3:abc"},
+		{name: "colon in first seed", seeds: []string{"a:b", "c"}, want: "3:a:b1:c"},
+		{name: "colon in second seed", seeds: []string{"a", "b:c"}, want: "1:a3:b:c"},
+		{name: "empty seed", seeds: []string{""}, want: "0:"},
+		{name: "multibyte seed", seeds: []string{"é"}, want: "2:é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientSeed(tt.seeds); got != tt.want {
+				t.Fatalf("ClientSeed(%q) = %q, want %q", tt.seeds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRangesClientSeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []*Range
+		want   string
+	}{
+		{name: "no ranges", ranges: nil, want: ""},
+		{name: "no seeds", ranges: []*Range{{UserID: 1}, {UserID: 2, ClientSeed: seed("")}}, want: ""},
+		{name: "seeds with colons", ranges: testProof().Ranges, want: "3:a:b1:c"},
+		{
+			name:   "same text split another way",
+			ranges: []*Range{{ClientSeed: seed("a")}, {ClientSeed: seed("b:c")}},
+			want:   "1:a3:b:c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RangesClientSeed(tt.ranges); got != tt.want {
+				t.Fatalf("RangesClientSeed() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		userIDs []uint
+		tickets []int
+		seeds   []*string
+		want    []*Range
+	}{
+		{name: "no bets", want: []*Range{}},
+		{
+			name:    "consecutive ranges",
+			userIDs: []uint{1, 2, 3},
+			tickets: []int{3, 1, 6},
+			seeds:   []*string{seed("a:b"), nil, seed("c")},
+			want:    testProof().Ranges,
+		},
+		{
+			name:    "bets without tickets are skipped",
+			userIDs: []uint{1, 2, 3},
+			tickets: []int{2, 0, 1},
+			seeds:   []*string{nil, seed("x"), nil},
+			want:    []*Range{{UserID: 1, From: 1, To: 2}, {UserID: 3, From: 3, To: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Ranges(tt.userIDs, tt.tickets, tt.seeds); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Ranges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundNumber(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientSeed string
+		want       uint64
+		formatted  string
+	}{
+		{name: "no client seed", clientSeed: "", want: 4138579286376517, formatted: "0.9189492025943917"},
+		{name: "colon in first seed", clientSeed: "3:a:b1:c", want: 2149571050083129, formatted: "0.4773006545739931"},
+		{name: "colon in second seed", clientSeed: "1:a3:b:c", want: 843357356274918, formatted: "0.1872629509846830"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RoundNumber(testSeed, testRoundID, tt.clientSeed)
+			if got != tt.want {
+				t.Fatalf("RoundNumber() = %d, want %d", got, tt.want)
+			}
+			if f := FormatRoundNumber(got); f != tt.formatted {
+				t.Fatalf("FormatRoundNumber() = %s, want %s", f, tt.formatted)
+			}
+		})
+	}
+}
+
+func TestWinningTicket(t *testing.T) {
+	const maxNumber = uint64(1)<<numberBits - 1
+
+	tests := []struct {
+		name         string
+		roundNumber  uint64
+		totalTickets int
+		want         int
+	}{
+		{name: "lowest number", roundNumber: 0, totalTickets: 10, want: 1},
+		{name: "highest number", roundNumber: maxNumber, totalTickets: 10, want: 10},
+		{name: "middle number", roundNumber: 1 << (numberBits - 1), totalTickets: 10, want: 6},
+		{name: "one ticket", roundNumber: maxNumber, totalTickets: 1, want: 1},
+		{name: "fixed seed", roundNumber: 2149571050083129, totalTickets: 10, want: 5},
+		{name: "no tickets", roundNumber: 1, totalTickets: 0, want: -1},
+		{name: "negative tickets", roundNumber: 1, totalTickets: -3, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WinningTicket(tt.roundNumber, tt.totalTickets); got != tt.want {
+				t.Fatalf("WinningTicket(%d, %d) = %d, want %d", tt.roundNumber, tt.totalTickets, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *Proof)
+		want   error
+	}{
+		{name: "valid proof", modify: func(p *Proof) {}},
+		{name: "upper case hash", modify: func(p *Proof) { p.Hash = strings.ToUpper(p.Hash) }},
+		{name: "empty server seed", modify: func(p *Proof) { p.ServerSeed = "" }, want: ErrInvalidSeed},
+		{name: "server seed is not hex", modify: func(p *Proof) { p.ServerSeed = "seed" }, want: ErrInvalidSeed},
+		{name: "another server seed", modify: func(p *Proof) { p.ServerSeed = testSeed[2:] + "00" }, want: ErrHashMismatch},
+		{name: "gap in ranges", modify: func(p *Proof) { p.Ranges[1].From = 5 }, want: ErrInvalidRanges},
+		{name: "reversed range", modify: func(p *Proof) { p.Ranges[1].To = 3 }, want: ErrInvalidRanges},
+		{name: "total tickets mismatch", modify: func(p *Proof) { p.TotalTickets = 11 }, want: ErrInvalidRanges},
+		{name: "client seed mismatch", modify: func(p *Proof) { p.ClientSeed = "a:b:c" }, want: ErrSeedMismatch},
+		{
+			name: "seeds split another way",
+			modify: func(p *Proof) {
+				p.Ranges[0].ClientSeed, p.Ranges[2].ClientSeed = seed("a"), seed("b:c")
+			},
+			want: ErrSeedMismatch,
+		},
+		{name: "ticket mismatch", modify: func(p *Proof) { p.Ticket = 4 }, want: ErrTicketMismatch},
+		{name: "winner mismatch", modify: func(p *Proof) { p.WinnerID = 1 }, want: ErrWinnerMismatch},
+		{name: "another round", modify: func(p *Proof) { p.RoundID++ }, want: ErrTicketMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testProof()
+			tt.modify(p)
+			err := Verify(p)
+			if tt.want == nil && err != nil {
+				t.Fatalf("Verify() = %v, want nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWinner(t *testing.T) {
+	ranges := testProof().Ranges
+	tests := []struct {
+		ticket int
+		want   uint
+		ok     bool
+	}{
+		{ticket: 1, want: 1, ok: true},
+		{ticket: 3, want: 1, ok: true},
+		{ticket: 4, want: 2, ok: true},
+		{ticket: 10, want: 3, ok: true},
+		{ticket: 0},
+		{ticket: 11},
+	}
+	for _, tt := range tests {
+		got, ok := Winner(ranges, tt.ticket)
+		if got != tt.want || ok != tt.ok {
+			t.Fatalf("Winner(%d) = %d, %v, want %d, %v", tt.ticket, got, ok, tt.want, tt.ok)
+		}
+	}
+}